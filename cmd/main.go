@@ -1,6 +1,10 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
 	"weather-bot/internal/app"
 	"weather-bot/internal/config"
 	"weather-bot/pkg/logger"
@@ -13,13 +17,32 @@ func main() {
 	logger.New()
 	log.Info().Msg("Logger initialized")
 
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Ошибка загрузки конфигурации")
+	}
+
+	// Отменяется по SIGINT/SIGTERM, останавливает получение обновлений и фоновые задачи
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// bot migrate ... - управление миграциями PostgreSQL без запуска бота
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, cfg.PostgresURL, os.Args[2:]); err != nil {
+			log.Fatal().Err(err).Msg("Ошибка миграций")
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Ошибка конфигурации")
+	}
 	log.Info().Msg("Config initialized")
 
-	var aplication app.Application = app.New(cfg)
+	var aplication app.Application = app.New(ctx, cfg)
 
-	aplication.Bootstrap()
+	aplication.Bootstrap(ctx)
 	defer aplication.Shutdown()
 
-	aplication.Run()
+	aplication.Run(ctx)
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"weather-bot/internal/database"
+)
+
+const migrateUsage = "использование: bot migrate [up | down [N] | status]"
+
+// runMigrate выполняет подкоманду migrate:
+//
+//	bot migrate up        - применить новые миграции (по умолчанию)
+//	bot migrate down [N]  - откатить N последних миграций (по умолчанию одну)
+//	bot migrate status    - показать применённые и ожидающие миграции
+func runMigrate(ctx context.Context, postgresURL string, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	if postgresURL == "" {
+		return fmt.Errorf("не задан POSTGRES_URL")
+	}
+	pool, err := database.Connect(ctx, postgresURL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	migrator, err := database.NewMigrator(pool)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		count, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Применено миграций: %d\n", count)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("неверное количество миграций %q, %s", args[1], migrateUsage)
+			}
+		}
+		count, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Откачено миграций: %d\n", count)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ВЕРСИЯ\tНАЗВАНИЕ\tПРИМЕНЕНА")
+		for _, s := range statuses {
+			applied := "ожидает"
+			if !s.AppliedAt.IsZero() {
+				applied = s.AppliedAt.Local().Format("02.01.2006 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("неизвестная команда %q, %s", command, migrateUsage)
+	}
+	return nil
+}
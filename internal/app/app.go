@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"weather-bot/internal/app/handlers"
@@ -8,10 +9,14 @@ import (
 	"weather-bot/internal/app/loader"
 	"weather-bot/internal/app/monitoring"
 	"weather-bot/internal/app/reply"
+	"weather-bot/internal/app/search"
 	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/app/weather"
 	"weather-bot/internal/cache"
 	"weather-bot/internal/config"
 	"weather-bot/internal/database"
+	"weather-bot/internal/memory"
 	"weather-bot/pkg/telegram"
 
 	"github.com/rs/zerolog/log"
@@ -20,21 +25,57 @@ import (
 )
 
 type Application interface {
-	Bootstrap()
-	Run()
+	Bootstrap(ctx context.Context)
+	Run(ctx context.Context)
 	Shutdown()
 }
 
 type App struct {
 	Bot   *tgbotapi.BotAPI
-	DB    *database.Database
-	Cache *cache.Cache
+	DB    storage.Database
+	Cache storage.Cache
+	cfg   *config.Config
+	// Закрывает соединения с хранилищами, для хранилища в памяти - ничего не делает
+	closeStorage func()
+
+	// Собираются в Bootstrap
+	dispatcher *telegram.Dispatcher
+	handler    *handlers.Handler
+	jobs       *jobs.Runner
 }
 
-func New(cfg *config.Config) *App {
+func New(ctx context.Context, cfg *config.Config) *App {
+	primary, secondary, closeStorage := openStorage(ctx, cfg)
+
+	// Инициализация сервера метрик
+	go monitoring.StartMetricsServer(cfg.MetricsPort)
+
+	bot, err := tgbotapi.NewBotAPI(cfg.BotToken)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error creating bot")
+	}
+
+	log.Info().Msgf("Бот %s запущен", bot.Self.UserName)
+
+	return &App{
+		Bot:          bot,
+		DB:           secondary,
+		Cache:        primary,
+		cfg:          cfg,
+		closeStorage: closeStorage,
+	}
+}
+
+// openStorage подключает хранилище из настроек. В памяти одно хранилище служит и основным, и резервным.
+func openStorage(ctx context.Context, cfg *config.Config) (storage.Cache, storage.Database, func()) {
+	if cfg.Storage == config.StorageMemory {
+		log.Warn().Msg("Данные хранятся в памяти и будут потеряны при перезапуске")
+		store := memory.NewStorage(cfg.Weather.CacheTTL)
+		return store, store, func() {}
+	}
 
 	// Инициализация Postgres
-	pool, err := database.Init(cfg.PostgresURL)
+	pool, err := database.Init(ctx, cfg.PostgresURL)
 	if err != nil {
 		log.Fatal().Err(err).Msgf("Ошибка подключения к БД: %v", err)
 	}
@@ -48,30 +89,63 @@ func New(cfg *config.Config) *App {
 
 	log.Info().Msg("Connected to Redis")
 
-	// Инициализация сервера метрик
-	go monitoring.StartMetricsServer(os.Getenv("METRICS_SERVER_ADDR"))
-
 	db := database.NewDatabase(pool)
-	redis := cache.NewCache(client)
+	redis := cache.NewCache(client, cfg.Weather.CacheTTL)
+	return redis, db, func() {
+		db.Close()
+		redis.Close()
+	}
+}
 
-	bot, err := tgbotapi.NewBotAPI(cfg.BotToken)
+func (a *App) Bootstrap(ctx context.Context) {
+	svc := services.NewServiceContainer(a.Cache, a.DB)
+
+	a.dispatcher = telegram.NewDispatcher(telegram.New(a.Bot), telegram.DispatcherConfig{
+		Rate:         a.cfg.Telegram.Rate,
+		Burst:        a.cfg.Telegram.Burst,
+		ChatInterval: a.cfg.Telegram.ChatInterval,
+		Workers:      a.cfg.Telegram.Workers,
+		QueueSize:    a.cfg.Telegram.QueueSize,
+		MaxRetries:   a.cfg.Telegram.MaxRetries,
+	})
+	monitoring.RegisterTelegramDispatcher(a.dispatcher)
+	replier := reply.New(a.dispatcher, svc)
+
+	provider, err := weather.NewProvider(a.cfg.Weather.Provider, a.cfg.Weather.APIKey)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Error creating bot")
+		log.Fatal().Err(err).Msg("Ошибка инициализации поставщика погоды")
 	}
-
-	log.Info().Msgf("Бот %s запущен", bot.Self.UserName)
-
-	return &App{
-		Bot:   bot,
-		DB:    db,
-		Cache: redis,
+	if a.cfg.Weather.FallbackProvider != "" && a.cfg.Weather.FallbackProvider != provider.Name() {
+		fallback, err := weather.NewProvider(a.cfg.Weather.FallbackProvider, a.cfg.Weather.APIKey)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Ошибка инициализации запасного поставщика погоды")
+		}
+		provider = weather.NewFailover(provider, fallback)
+	}
+	weatherClient := weather.NewClient(svc, provider)
+	log.Info().Msgf("Поставщик погоды: %s", provider.Name())
+
+	scheduler := jobs.NewScheduler(svc, a.cfg.Jobs)
+	a.handler = handlers.NewHandler(handlers.Deps{
+		Services:  svc,
+		Reply:     replier,
+		Weather:   weatherClient,
+		Search:    search.NewSearcher(svc),
+		Scheduler: scheduler,
+		AdminIDs:  a.cfg.AdminIDs,
+	})
+	a.jobs = jobs.NewRunner(jobs.Deps{
+		Services:  svc,
+		Weather:   weatherClient,
+		Reply:     replier,
+		Scheduler: scheduler,
+		Config:    a.cfg.Jobs,
+	})
+
+	// Меню команд в Telegram строится из тех же регистраций, что и роутер
+	if _, err := a.Bot.Request(tgbotapi.NewSetMyCommands(a.handler.BotCommands()...)); err != nil {
+		log.Error().Err(err).Msg("Ошибка публикации списка команд")
 	}
-}
-
-func (a *App) Bootstrap() {
-	services.Init(a.Cache, a.DB)
-
-	reply.Init(telegram.New(a.Bot))
 
 	// Загрузка городов
 	basePath, err := os.Getwd()
@@ -79,33 +153,73 @@ func (a *App) Bootstrap() {
 		log.Fatal().Err(err).Msg("Ошибка получения текущего каталога")
 	}
 	filePath := filepath.Join(basePath, "internal", "app", "loader", "enriched_cities.json")
-	if err := loader.LoadCities(filePath, services.InitCityService(a.Cache, a.DB)); err != nil {
+	if err := loader.LoadCities(ctx, filePath, svc.CityService); err != nil {
 		log.Fatal().Err(err).Msg("Error loading cities to storage")
 	}
 
 	log.Info().Msg("Cities loaded to Redis and Database")
 
-	jobs.Init()
+	if redis, ok := a.Cache.(*cache.Cache); ok {
+		if err := redis.MigrateLegacyStreams(ctx); err != nil {
+			log.Error().Err(err).Msg("Ошибка переноса задач из Redis Streams")
+		}
+	}
+
+	if err := a.jobs.Start(ctx); err != nil {
+		log.Error().Err(err).Msg("Ошибка запуска фоновых задач")
+	}
 }
 
-func (a *App) Run() {
+// Run получает обновления до отмены ctx, затем дожидается обработки принятых
+// обновлений и остановки фоновых задач
+func (a *App) Run(ctx context.Context) {
 	log.Info().Msg("Bot started")
 
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 	updates := a.Bot.GetUpdatesChan(u)
 
+	// Обработчики не прерываются сигналом остановки: принятые обновления дорабатываются,
+	// их контекст отменяется, только если не уложились в ShutdownTimeout
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+
+	// Обновления разных пользователей обрабатываются параллельно, одного - по порядку
+	pool := handlers.NewUpdatePool(handlerCtx, a.cfg.Updates.Workers, a.cfg.Updates.QueueSize, a.handler.Update)
+
+	go func() {
+		<-ctx.Done()
+		log.Info().Msg("Получен сигнал остановки, прекращаем получать обновления...")
+		// Закрывает канал updates
+		a.Bot.StopReceivingUpdates()
+	}()
+
 	for update := range updates {
-		if update.Message == nil { // Пропускаем неполные сообщения
+		if update.Message == nil && update.CallbackQuery == nil { // Пропускаем неполные сообщения
 			continue
 		}
 
-		handlers.Update(update)
+		pool.Submit(update)
+	}
+
+	deadline, cancel := context.WithTimeout(context.Background(), a.cfg.Updates.ShutdownTimeout)
+	defer cancel()
+
+	if err := pool.Close(deadline); err != nil {
+		log.Error().Err(err).Msg("Ошибка остановки обработчиков обновлений")
+		cancelHandlers()
+	}
+	if err := a.jobs.Wait(deadline); err != nil {
+		log.Error().Err(err).Msg("Ошибка остановки фоновых задач")
+	}
+	// Обработчики и задачи остановлены, отправляем сообщения, которые ещё в очереди
+	if err := a.dispatcher.Close(deadline); err != nil {
+		log.Error().Err(err).Int64("queued", a.dispatcher.QueueDepth()).Msg("Ошибка отправки оставшихся сообщений")
 	}
+	log.Info().Msg("Bot stopped")
 }
 
 func (a *App) Shutdown() {
 	log.Info().Msg("Отключение БД и Redis...")
-	a.DB.Close()
-	a.Cache.Close()
+	a.closeStorage()
 }
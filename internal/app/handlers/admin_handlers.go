@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+	"weather-bot/internal/app/storage"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Сколько недоставленных уведомлений показывает /dlq и читает за раз /dlq replay
+const deadLettersPage = 20
+
+func (h *Handler) isAdmin(userID int64) bool {
+	return h.admins[userID]
+}
+
+// handleDeadLetters - служебная команда:
+// "/dlq" - последние недоставленные уведомления,
+// "/dlq replay" - вернуть в очередь все, "/dlq replay <id>" - вернуть одно.
+func (h *Handler) handleDeadLetters(ctx *Context) {
+	action, id, _ := strings.Cut(ctx.args, " ")
+	id = strings.TrimSpace(id)
+	switch {
+	case action == "":
+		letters, err := h.services.DeadLetters(ctx, "", deadLettersPage)
+		if err != nil {
+			log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка чтения очереди недоставленных")
+			h.reply.Message(ctx, ctx.user, "❌ Очередь недоставленных недоступна.", mainMenu())
+			return
+		}
+		h.reply.Message(ctx, ctx.user, deadLettersMessage(letters), mainMenu())
+	case action == "replay" && id != "":
+		h.replayDeadLetter(ctx, id)
+	case action == "replay":
+		h.replayDeadLetters(ctx)
+	default:
+		h.reply.Message(ctx, ctx.user, "Использование: /dlq или /dlq replay [id]", mainMenu())
+	}
+}
+
+func (h *Handler) replayDeadLetter(ctx *Context, id string) {
+	letter, err := h.services.GetDeadLetter(ctx, id)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("id", id).Msg("Ошибка чтения очереди недоставленных")
+		h.reply.Message(ctx, ctx.user, "❌ Очередь недоставленных недоступна.", mainMenu())
+		return
+	}
+	if letter == nil {
+		h.reply.Message(ctx, ctx.user, fmt.Sprintf("🤷‍♀️ Недоставленное уведомление <code>%s</code> не найдено.", html.EscapeString(id)), mainMenu())
+		return
+	}
+
+	if err := h.services.ReplayDeadLetter(ctx, *letter, time.Now().Unix()); err != nil {
+		log.Error().Err(err).Str("job", letter.JobID).Msg("Ошибка возврата недоставленного уведомления в очередь")
+		h.reply.Message(ctx, ctx.user, "❌ Не удалось вернуть уведомление в очередь.", mainMenu())
+		return
+	}
+	log.Info().Int64("user", ctx.user.TgID).Str("job", letter.JobID).Msg("Недоставленное уведомление возвращено в очередь")
+	h.reply.Message(ctx, ctx.user, "🔁 Возвращено в очередь: 1", mainMenu())
+}
+
+// replayDeadLetters возвращает в очередь все недоставленные уведомления, страница за страницей
+// от новых к старым. Записи, появившиеся за это время, не трогаем: это могут быть те же
+// уведомления, снова не доставленные после возврата.
+func (h *Handler) replayDeadLetters(ctx *Context) {
+	replayed, failed := 0, 0
+	before := ""
+	for {
+		letters, err := h.services.DeadLetters(ctx, before, deadLettersPage)
+		if err != nil {
+			log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка чтения очереди недоставленных")
+			h.reply.Message(ctx, ctx.user, fmt.Sprintf("❌ Очередь недоставленных недоступна. Возвращено в очередь: %d", replayed), mainMenu())
+			return
+		}
+		if len(letters) == 0 {
+			break
+		}
+
+		for _, letter := range letters {
+			if err := h.services.ReplayDeadLetter(ctx, letter, time.Now().Unix()); err != nil {
+				log.Error().Err(err).Str("job", letter.JobID).Msg("Ошибка возврата недоставленного уведомления в очередь")
+				failed++
+				continue
+			}
+			replayed++
+		}
+		before = letters[len(letters)-1].ID
+	}
+
+	log.Info().Int64("user", ctx.user.TgID).Int("jobs", replayed).Int("failed", failed).Msg("Недоставленные уведомления возвращены в очередь")
+	msg := fmt.Sprintf("🔁 Возвращено в очередь: %d", replayed)
+	if failed > 0 {
+		msg += fmt.Sprintf(", не удалось вернуть: %d", failed)
+	}
+	h.reply.Message(ctx, ctx.user, msg, mainMenu())
+}
+
+func deadLettersMessage(letters []storage.DeadLetter) string {
+	if len(letters) == 0 {
+		return "📭 Недоставленных уведомлений нет."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📬 Недоставленные уведомления (последние %d):\n", len(letters)))
+	for _, letter := range letters {
+		sb.WriteString(fmt.Sprintf("\n<code>%s</code> %s, %s, попыток: %d\n%s\n",
+			letter.ID,
+			html.EscapeString(letter.JobID),
+			time.Unix(letter.FailedAt, 0).Format("02.01 15:04"),
+			letter.Attempts,
+			html.EscapeString(letter.Reason),
+		))
+	}
+	sb.WriteString("\nВернуть в очередь: /dlq replay или /dlq replay <code>id</code>")
+	return sb.String()
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Префиксы данных inline-кнопок
+const (
+	callbackDays = "days:"
+	callbackCity = "city:"
+)
+
+// answerCallback убирает индикатор загрузки с нажатой кнопки
+func (h *Handler) answerCallback(ctx *Context, text string) {
+	if err := h.reply.AnswerCallback(ctx, ctx.callback.ID, text); err != nil {
+		log.Warn().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка ответа на нажатие кнопки")
+	}
+}
+
+// editCallbackMessage меняет сообщение, к которому прикреплена нажатая кнопка
+func (h *Handler) editCallbackMessage(ctx *Context, text string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	if err := h.reply.Edit(ctx, ctx.user, ctx.callback.Message.MessageID, text, keyboard); err != nil {
+		log.Warn().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка редактирования сообщения")
+	}
+}
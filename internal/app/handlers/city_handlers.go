@@ -1,11 +1,10 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"regexp"
 	"strconv"
-	"strings"
-	"weather-bot/internal/app/reply"
-	"weather-bot/internal/app/search"
 	"weather-bot/internal/app/weather"
 	"weather-bot/internal/models"
 
@@ -13,201 +12,183 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func handleCityInput(ctx *Context) {
+func (h *Handler) handleCityInput(ctx *Context) {
+	if ctx.location != nil {
+		h.handleLocation(ctx, StateAwaitingCitySelection, cityInputMenu)
+		return
+	}
+
 	if !IsValidCity(ctx.text) {
-		reply.Send().Message(ctx.user.ChatID, "⛔️ Принимается название города только на кириллице. Попробуйте еще раз:", tgbotapi.NewRemoveKeyboard(true))
+		h.reply.Message(ctx, ctx.user, "⛔️ Принимается название города только на кириллице. Попробуйте еще раз:", cityInputMenu())
 		return
 	}
 
-	cities, err := search.SearchCity(ctx.text)
+	cities, err := h.search.SearchCity(ctx, ctx.text)
 	if err != nil {
 		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("city", ctx.text).Msg("Ошибка при поиске города")
-		reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), tgbotapi.NewRemoveKeyboard(true))
+		h.reply.Message(ctx, ctx.user, errorFindCityMessage(), cityInputMenu())
 		return
 	}
 
 	if len(cities) == 1 {
-		log.Info().Int64("user", ctx.user.TgID).Str("city", ctx.text).Msg("Пользователь выбрал город")
-		city := cities[0]
-		ctx.user.Update(city.Name, strconv.Itoa(city.ID), string(StateNone), ctx.user.Sticker, city.Region)
-
-		reply.Send().Message(ctx.user.ChatID, successSaveCityMessage(city.Name), mainMenu())
+		h.saveUserCity(ctx, &cities[0])
 		return
 	}
 
 	if len(cities) > 1 {
 		keyboard := makeCityKeyboard(cities)
 		ctx.user.State = string(StateAwaitingCitySelection)
-		reply.Send().Message(ctx.user.ChatID, "🔍 Найдено несколько городов. Пожалуйста, выберите нужный:", keyboard)
+		h.reply.Message(ctx, ctx.user, "🔍 Найдено несколько городов. Пожалуйста, выберите нужный:", keyboard)
 		return
 	}
 
-	reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), tgbotapi.NewRemoveKeyboard(true))
+	h.reply.Message(ctx, ctx.user, errorFindCityMessage(), cityInputMenu())
 }
 
-func IsValidCity(city string) bool {
-	r := regexp.MustCompile(`^[а-яА-ЯёЁ\s-]+$`)
-	return r.MatchString(city)
-}
-
-func handleCitySelection(ctx *Context) {
-
-	if ctx.text == "🔄 Ввести название города заново." {
-		ctx.user.State = string(StateAwaitingCityInput)
-		reply.Send().Message(ctx.user.ChatID, enterNameCityMessage(), tgbotapi.NewRemoveKeyboard(true))
-		return
-	}
-
-	parts := strings.Split(ctx.text, "|")
-	if len(parts) < 2 || len(parts) > 3 {
-		log.Error().Str("city", ctx.text).Msg("Неверный формат выбранного города")
-		ctx.user.State = string(StateAwaitingCityInput)
-		reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), tgbotapi.NewRemoveKeyboard(true))
-		return
-	}
-
-	cityName := parts[0]
-	cityID, err := strconv.Atoi(parts[1])
+// handleLocation предлагает ближайший к геолокации город, подтверждение идёт через обычный выбор города
+func (h *Handler) handleLocation(ctx *Context, selectionState UserState, menu func() tgbotapi.ReplyKeyboardMarkup) {
+	city, distance, err := h.search.NearestCity(ctx, ctx.location.Latitude, ctx.location.Longitude)
 	if err != nil {
-		log.Error().Int("cityID", cityID).Err(err).Msg("Ошибка при парсинге ID города")
-		ctx.user.State = string(StateAwaitingCityInput)
-		reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), tgbotapi.NewRemoveKeyboard(true))
-
-		return
-	}
-
-	cities, err := search.SearchCity(cityName)
-	if err != nil || len(cities) == 0 {
-		log.Error().Int("cityID", cityID).Str("city", cityName).Err(err).Msg("Ошибка при поиске выбранного города")
-		ctx.user.State = string(StateAwaitingCityInput)
-		reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), tgbotapi.NewRemoveKeyboard(true))
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Float64("lat", ctx.location.Latitude).Float64("lon", ctx.location.Longitude).Msg("Ошибка при поиске ближайшего города")
+		h.reply.Message(ctx, ctx.user, errorFindCityMessage(), menu())
 		return
 	}
 
-	var selectedCity *models.City
-	for _, city := range cities {
-		if city.ID == cityID {
-			selectedCity = &city
-			break
-		}
-	}
-
-	if selectedCity == nil {
-		log.Error().Int("cityID", cityID).Str("city", cityName).Msg("Выбранный город не найден")
-		ctx.user.State = string(StateAwaitingCityInput)
-		reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), tgbotapi.NewRemoveKeyboard(true))
+	log.Info().Int64("user", ctx.user.TgID).Str("city", city.Name).Float64("distance", distance).Msg("Найден ближайший к геолокации город")
+	ctx.user.State = string(selectionState)
+	h.reply.Message(ctx, ctx.user, nearestCityMessage(city.Name, distance), makeCityKeyboard([]models.City{*city}))
+}
 
-		return
-	}
+func IsValidCity(city string) bool {
+	r := regexp.MustCompile(`^[а-яА-ЯёЁ\s-]+$`)
+	return r.MatchString(city)
+}
 
-	ctx.user.Update(selectedCity.Name, strconv.Itoa(selectedCity.ID), string(StateNone), ctx.user.Sticker, selectedCity.Region)
+// saveUserCity делает выбранный город основным городом пользователя
+func (h *Handler) saveUserCity(ctx *Context, city *models.City) {
+	ctx.user.Update(city.Name, strconv.Itoa(city.ID), string(StateNone), ctx.user.Sticker, city.Region)
 
-	log.Info().Int64("user", ctx.user.TgID).Str("city", selectedCity.Name).Msg("Пользователь выбрал город")
+	log.Info().Int64("user", ctx.user.TgID).Str("city", city.Name).Msg("Пользователь выбрал город")
 
-	reply.Send().Message(ctx.user.ChatID, successSaveCityMessage(selectedCity.Name), mainMenu())
+	h.reply.Message(ctx, ctx.user, successSaveCityMessage(city.Name), mainMenu())
 }
 
-func handleDiffCityInput(ctx *Context) {
+func (h *Handler) handleDiffCityInput(ctx *Context) {
 	if ctx.text == "↩ Отмена" {
 		ctx.user.State = string(StateNone)
-		reply.Send().Message(ctx.user.ChatID, "Отменено.", mainMenu())
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+		return
+	}
+	if ctx.location != nil {
+		h.handleLocation(ctx, StateAwaitingDiffCitySelection, diffCityInputMenu)
 		return
 	}
 	if !IsValidCity(ctx.text) {
-		reply.Send().Message(ctx.user.ChatID, "⛔️ Принимается название города только на кириллице. Попробуйте еще раз:", tgbotapi.NewRemoveKeyboard(true))
+		h.reply.Message(ctx, ctx.user, "⛔️ Принимается название города только на кириллице. Попробуйте еще раз:", diffCityInputMenu())
 		return
 	}
 
-	cities, err := search.SearchCity(ctx.text)
+	cities, err := h.search.SearchCity(ctx, ctx.text)
 	if err != nil {
 		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("city", ctx.text).Msg("Ошибка при поиске города")
-		reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), cancelMenu())
+		h.reply.Message(ctx, ctx.user, errorFindCityMessage(), diffCityInputMenu())
 		return
 	}
 
 	if len(cities) == 1 {
-		ctx.user.State = string(StateNone)
-		city := cities[0]
-		forecast, err := weather.GetNewWeather(city.ID)
-		if err != nil {
-			log.Error().Err(err).Int64("user", ctx.user.TgID).Int("cityID", city.ID).Msg("Ошибка при получении погоды")
-			reply.Send().Message(ctx.user.ChatID, errorGetWeatherMessage(), mainMenu())
-			return
-		}
-		msg := weather.FormatFiveDayForecast(city.Name, forecast.ShortDays)
-
-		reply.Send().Message(ctx.user.ChatID, msg, mainMenu())
+		h.sendDiffCityWeather(ctx, &cities[0])
 		return
 	}
 
 	if len(cities) > 1 {
 		keyboard := makeCityKeyboard(cities)
 		ctx.user.State = string(StateAwaitingDiffCitySelection)
-		reply.Send().Message(ctx.user.ChatID, "🔍 Найдено несколько городов. Пожалуйста, выберите нужный:", keyboard)
+		h.reply.Message(ctx, ctx.user, "🔍 Найдено несколько городов. Пожалуйста, выберите нужный:", keyboard)
 		return
 	}
 
-	reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), cancelMenu())
+	h.reply.Message(ctx, ctx.user, errorFindCityMessage(), diffCityInputMenu())
 }
 
-func handleDiffCitySelection(ctx *Context) {
-
-	if ctx.text == "🔄 Ввести название города заново." {
-		ctx.user.State = string(StateAwaitingDiffCityInput)
-		reply.Send().Message(ctx.user.ChatID, enterNameDiffCityMessage(), cancelMenu())
+// sendDiffCityWeather отправляет прогноз на 5 дней для города, не сохраняя его
+func (h *Handler) sendDiffCityWeather(ctx *Context, city *models.City) {
+	ctx.user.State = string(StateNone)
+	forecast, err := h.weather.GetNewWeather(ctx, city.ID)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Int("cityID", city.ID).Msg("Ошибка при получении погоды")
+		h.reply.Message(ctx, ctx.user, errorGetWeatherMessage(), mainMenu())
 		return
 	}
+	msg := weather.FormatFiveDayForecast(city.Name, forecast.ShortDays)
 
-	parts := strings.Split(ctx.text, "|")
-	if len(parts) < 2 || len(parts) > 3 {
-		log.Error().Str("city", ctx.text).Msg("Неверный формат выбранного города")
-		ctx.user.State = string(StateAwaitingDiffCityInput)
-		reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), cancelMenu())
-		return
-	}
+	h.reply.Message(ctx, ctx.user, msg, mainMenu())
+}
 
-	cityName := parts[0]
-	cityID, err := strconv.Atoi(parts[1])
-	if err != nil {
-		log.Error().Int("cityID", cityID).Err(err).Msg("Ошибка при парсинге ID города")
-		ctx.user.State = string(StateAwaitingDiffCityInput)
-		reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), cancelMenu())
+// handleCityCallback обрабатывает нажатие кнопки inline-клавиатуры выбора города
+func (h *Handler) handleCityCallback(ctx *Context) {
+	value := ctx.args
+	state := UserState(ctx.user.State)
 
+	var inputState UserState
+	var enterMessage string
+	var menu tgbotapi.ReplyKeyboardMarkup
+	switch state {
+	case StateAwaitingCitySelection:
+		inputState, enterMessage, menu = StateAwaitingCityInput, enterNameCityMessage(), cityInputMenu()
+	case StateAwaitingDiffCitySelection:
+		inputState, enterMessage, menu = StateAwaitingDiffCityInput, enterNameDiffCityMessage(), diffCityInputMenu()
+	case StateAwaitingSavedCitySelection:
+		inputState, enterMessage, menu = StateAwaitingSavedCityInput, enterSavedCityMessage(ctx.user.Draft), diffCityInputMenu()
+	default:
+		// Клавиатура из старого сообщения, пользователь уже ушёл из выбора города
+		h.answerCallback(ctx, "Этот выбор уже неактуален")
+		h.editCallbackMessage(ctx, ctx.callback.Message.Text, nil)
 		return
 	}
+	h.answerCallback(ctx, "")
 
-	cities, err := search.SearchCity(cityName)
-	if err != nil || len(cities) == 0 {
-		log.Error().Int("cityID", cityID).Str("city", cityName).Err(err).Msg("Ошибка при поиске выбранного города")
-		ctx.user.State = string(StateAwaitingDiffCityInput)
-		reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), cancelMenu())
+	if value == cityActionRetry {
+		h.editCallbackMessage(ctx, ctx.callback.Message.Text, nil)
+		ctx.user.State = string(inputState)
+		h.reply.Message(ctx, ctx.user, enterMessage, menu)
 		return
 	}
 
-	var selectedCity *models.City
-	for _, city := range cities {
-		if city.ID == cityID {
-			selectedCity = &city
-			break
-		}
+	city, err := h.selectedCity(ctx, value)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("data", value).Msg("Ошибка при выборе города")
+		h.editCallbackMessage(ctx, ctx.callback.Message.Text, nil)
+		ctx.user.State = string(inputState)
+		h.reply.Message(ctx, ctx.user, errorFindCityMessage(), menu)
+		return
 	}
 
-	if selectedCity == nil {
-		log.Error().Int("cityID", cityID).Str("city", cityName).Msg("Выбранный город не найден")
-		ctx.user.State = string(StateAwaitingDiffCityInput)
-		reply.Send().Message(ctx.user.ChatID, errorFindCityMessage(), cancelMenu())
+	// Заменяем список городов выбранным, чтобы кнопки нельзя было нажать повторно
+	h.editCallbackMessage(ctx, "📍 "+cityLabel(*city), nil)
 
-		return
+	switch state {
+	case StateAwaitingCitySelection:
+		h.saveUserCity(ctx, city)
+	case StateAwaitingDiffCitySelection:
+		h.sendDiffCityWeather(ctx, city)
+	case StateAwaitingSavedCitySelection:
+		h.saveDraftCity(ctx, city)
 	}
+}
 
-	ctx.user.State = string(StateNone)
-	forecast, err := weather.GetNewWeather(selectedCity.ID)
+// selectedCity находит город по ID из данных кнопки
+func (h *Handler) selectedCity(ctx context.Context, value string) (*models.City, error) {
+	cityID, err := strconv.Atoi(value)
 	if err != nil {
-		log.Error().Err(err).Int64("user", ctx.user.TgID).Int("cityID", selectedCity.ID).Msg("Ошибка при получении погоды")
-		reply.Send().Message(ctx.user.ChatID, errorGetWeatherMessage(), mainMenu())
-		return
+		return nil, fmt.Errorf("ошибка при парсинге ID города: %w", err)
 	}
-	msg := weather.FormatFiveDayForecast(selectedCity.Name, forecast.ShortDays)
 
-	reply.Send().Message(ctx.user.ChatID, msg, mainMenu())
+	city, err := h.services.GetCity(ctx, cityID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении города %d: %w", cityID, err)
+	}
+	if city == nil {
+		return nil, fmt.Errorf("выбранный город %d не найден", cityID)
+	}
+	return city, nil
 }
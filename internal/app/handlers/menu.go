@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"weather-bot/internal/models"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -10,41 +12,134 @@ import (
 const BG = "BG"
 
 func mainMenu() tgbotapi.ReplyKeyboardMarkup {
-	return tgbotapi.NewReplyKeyboard(tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("Узнать погоду")))
+	return tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("Узнать погоду")),
+		tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("🏙 Мои города")),
+	)
 }
 
 func startMenu() tgbotapi.ReplyKeyboardMarkup {
 	return tgbotapi.NewReplyKeyboard(tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("/start")))
 }
 
-func makeCityKeyboard(cities []models.City) tgbotapi.ReplyKeyboardMarkup {
-	var keyboard [][]tgbotapi.KeyboardButton
+// Действия inline-клавиатуры выбора города, к ним добавляется префикс callbackCity.
+// Остальные кнопки клавиатуры содержат ID города.
+const cityActionRetry = "retry"
+
+func makeCityKeyboard(cities []models.City) tgbotapi.InlineKeyboardMarkup {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
 	for _, city := range cities {
-		text := fmt.Sprintf("%s|%d", city.Name, city.ID)
-		if city.Region != "" {
-			text = fmt.Sprintf("%s|%d|(%s)", city.Name, city.ID, city.Region)
-		}
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(cityLabel(city), callbackCity+strconv.Itoa(city.ID)),
+		))
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Ввести название заново", callbackCity+cityActionRetry),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
 
-		if city.Country != "" { // не российский город
-			var country string
-			switch city.Country {
-			case BG:
-				country = "Болгария"
-			}
-			text += fmt.Sprintf("|(%s)", country)
+// cityLabel - название города с регионом, по нему различаются одноимённые города
+func cityLabel(city models.City) string {
+	var details []string
+	if city.Region != "" {
+		details = append(details, city.Region)
+	}
+	if city.Country != "" { // не российский город
+		switch city.Country {
+		case BG:
+			details = append(details, "Болгария")
 		}
-		row := tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(text))
-		keyboard = append(keyboard, row)
 	}
-	keyboard = append(keyboard, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("🔄 Ввести название города заново.")))
+	if len(details) == 0 {
+		return city.Name
+	}
+	return fmt.Sprintf("%s (%s)", city.Name, strings.Join(details, ", "))
+}
+
+func notificationMenu(hasSubscriptions bool) tgbotapi.ReplyKeyboardMarkup {
+	row := tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("➕ Добавить"))
+	if hasSubscriptions {
+		row = append(row, tgbotapi.NewKeyboardButton("✏ Изменить"), tgbotapi.NewKeyboardButton("❌ Удалить"))
+	}
+	return tgbotapi.NewReplyKeyboard(row, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("↩ Отмена")))
+}
+
+// notificationCityMenu предлагает основной город пользователя и его сохранённые места
+func notificationCityMenu(user *models.User, places []models.UserCity) tgbotapi.ReplyKeyboardMarkup {
+	var keyboard [][]tgbotapi.KeyboardButton
+	if user.City != "" {
+		keyboard = append(keyboard, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(user.City)))
+	}
+	for _, place := range places {
+		keyboard = append(keyboard, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(place.Name)))
+	}
+	keyboard = append(keyboard, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("↩ Отмена")))
+	return tgbotapi.NewReplyKeyboard(keyboard...)
+}
+
+func subscriptionsPickMenu(subs []models.Subscription) tgbotapi.ReplyKeyboardMarkup {
+	var keyboard [][]tgbotapi.KeyboardButton
+	for i, sub := range subs {
+		keyboard = append(keyboard, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(fmt.Sprintf("%d. %s", i+1, sub))))
+	}
+	keyboard = append(keyboard, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("↩ Отмена")))
 	return tgbotapi.NewReplyKeyboard(keyboard...)
 }
 
-func notificationMenu() tgbotapi.ReplyKeyboardMarkup {
+// Действия inline-клавиатуры выбора дней, к ним добавляется префикс callbackDays
+const (
+	daysActionDone    = "done"
+	daysActionWork    = "work"
+	daysActionWeekend = "weekend"
+	daysActionAll     = "all"
+)
+
+const sameWeekendTime = "Так же, как в будни"
+
+func notificationDaysKeyboard(days models.Weekdays) tgbotapi.InlineKeyboardMarkup {
+	var week []tgbotapi.InlineKeyboardButton
+	for _, day := range models.WeekOrder {
+		text := models.WeekdayName(day)
+		if days.Has(day) {
+			text = "✅ " + text
+		}
+		week = append(week, tgbotapi.NewInlineKeyboardButtonData(text, fmt.Sprintf("%s%d", callbackDays, day)))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		week[:4],
+		week[4:],
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Будни", callbackDays+daysActionWork),
+			tgbotapi.NewInlineKeyboardButtonData("Выходные", callbackDays+daysActionWeekend),
+			tgbotapi.NewInlineKeyboardButtonData("Каждый день", callbackDays+daysActionAll),
+		),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("👌 Готово", callbackDays+daysActionDone)),
+	)
+}
+
+func weekendTimeMenu() tgbotapi.ReplyKeyboardMarkup {
+	return tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(sameWeekendTime)),
+		tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("↩ Отмена")),
+	)
+}
+
+func cityInputMenu() tgbotapi.ReplyKeyboardMarkup {
 	return tgbotapi.NewReplyKeyboard(
 		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("✏ Изменить"),
-			tgbotapi.NewKeyboardButton("❌ Удалить"),
+			tgbotapi.NewKeyboardButtonLocation("📍 Отправить геолокацию"),
+		),
+	)
+}
+
+func diffCityInputMenu() tgbotapi.ReplyKeyboardMarkup {
+	return tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButtonLocation("📍 Отправить геолокацию"),
+		),
+		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton("↩ Отмена"),
 		),
 	)
@@ -57,3 +152,28 @@ func cancelMenu() tgbotapi.ReplyKeyboardMarkup {
 		),
 	)
 }
+
+func savedCitiesMenu(cities []models.UserCity) tgbotapi.ReplyKeyboardMarkup {
+	var keyboard [][]tgbotapi.KeyboardButton
+	for _, city := range cities {
+		keyboard = append(keyboard, tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(savedCityTodayPrefix+city.Name),
+			tgbotapi.NewKeyboardButton(savedCityFiveDaysPrefix+city.Name),
+		))
+	}
+	actions := []tgbotapi.KeyboardButton{tgbotapi.NewKeyboardButton("➕ Добавить место")}
+	if len(cities) > 0 {
+		actions = append(actions, tgbotapi.NewKeyboardButton("❌ Удалить место"))
+	}
+	keyboard = append(keyboard, actions, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("↩ Отмена")))
+	return tgbotapi.NewReplyKeyboard(keyboard...)
+}
+
+func savedCitiesRemovalMenu(cities []models.UserCity) tgbotapi.ReplyKeyboardMarkup {
+	var keyboard [][]tgbotapi.KeyboardButton
+	for _, city := range cities {
+		keyboard = append(keyboard, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(city.Name)))
+	}
+	keyboard = append(keyboard, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("↩ Отмена")))
+	return tgbotapi.NewReplyKeyboard(keyboard...)
+}
@@ -1,6 +1,10 @@
 package handlers
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"weather-bot/internal/models"
+)
 
 func startMessage() string {
 	return `👋 Привет! Я бот, который поможет вам быть в курсе погоды каждый день!
@@ -10,7 +14,7 @@ func startMessage() string {
 - Показывать погоду прямо сейчас по запросу 🔍
 - Работать с базой из более чем 1500 городов России и не только 🗺️
 
-✏ Введите название вашего города, чтобы я мог отправлять актуальную погоду:`
+✏ Введите название вашего города или отправьте геолокацию 📍, чтобы я мог отправлять актуальную погоду:`
 }
 
 func errorFindCityMessage() string {
@@ -24,11 +28,55 @@ func enterNotificationTimeMessage() string {
 	return "✏ Введите время в формате: часы:минуты (например: 09:15)"
 }
 func enterNameCityMessage() string {
-	return "✏ Введите название вашего города:"
+	return "✏ Введите название вашего города или отправьте геолокацию:"
 }
 func enterNameDiffCityMessage() string {
-	return "✏ Введите название другого города (ваш город не изменится):"
+	return "✏ Введите название другого города или отправьте геолокацию (ваш город не изменится):"
+}
+func nearestCityMessage(name string, distance float64) string {
+	return fmt.Sprintf("📍 Ближайший к вам город — %s (~%.f км). Если всё верно, выберите его:", name, distance)
 }
 func errorGetWeatherMessage() string {
 	return "⛔️ Произошла ошибка при получении погоды. Попробуйте повторить позже."
 }
+func savedCitiesMessage(cities []models.UserCity) string {
+	if len(cities) == 0 {
+		return "🏙 У вас пока нет сохранённых мест. Добавьте, например, «Дом», «Дача» или «Работа»."
+	}
+	var b strings.Builder
+	b.WriteString("🏙 Ваши места:\n")
+	for _, city := range cities {
+		fmt.Fprintf(&b, "- %s: %s\n", city.Name, city.City)
+	}
+	b.WriteString("\n☀️ - погода на сегодня, 🗓 - на 5 дней.\nТакже можно написать /weather <место> или /weather5 <место>.")
+	return b.String()
+}
+func enterSavedCityNameMessage() string {
+	return fmt.Sprintf("✏ Как назвать место? Например: Дом, Дача, Работа (не длиннее %d символов):", maxSavedCityNameLen)
+}
+func enterSavedCityMessage(name string) string {
+	return fmt.Sprintf("✏ Введите название города для места «%s» или отправьте геолокацию:", name)
+}
+func successSaveSavedCityMessage(name, city string) string {
+	return fmt.Sprintf("🎉 Место «%s» (%s) сохранено.", name, city)
+}
+func unknownSavedCityMessage(name string) string {
+	return fmt.Sprintf("🤷‍♀️ Место «%s» не найдено. Откройте «🏙 Мои города», чтобы посмотреть сохранённые.", name)
+}
+func notificationsUnavailableMessage() string {
+	return "😢 Уведомления сейчас не работают. Попробуйте повторить позже."
+}
+func subscriptionsMessage(subs []models.Subscription) string {
+	if len(subs) == 0 {
+		return "🔔 У вас пока нет уведомлений. Добавьте первое: выберите город, время и дни недели."
+	}
+	var b strings.Builder
+	b.WriteString("🔔 Ваши уведомления:\n")
+	for i, sub := range subs {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, sub)
+	}
+	return b.String()
+}
+func chooseDaysMessage(sub models.Subscription) string {
+	return fmt.Sprintf("❔ В какие дни присылать прогноз в %s? Отметьте дни и нажмите «Готово».\nСейчас: %s", sub.Time, sub.Days)
+}
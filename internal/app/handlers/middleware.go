@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"runtime/debug"
+	"time"
+	"weather-bot/internal/app/monitoring"
+
+	"github.com/rs/zerolog/log"
+)
+
+// recoverMiddleware не даёт панике в обработчике остановить бота и возвращает пользователя в главное меню
+func (h *Handler) recoverMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				monitoring.BotErrorsTotal.Inc()
+				log.Error().Int64("id", ctx.user.TgID).Str("route", ctx.route).Str("stack", string(debug.Stack())).
+					Msgf("Паника при обработке сообщения: %v", r)
+
+				ctx.user.State = string(StateNone)
+				ctx.user.Draft = ""
+				h.reply.Message(ctx, ctx.user, "🔄 Произошла ошибка. Начнем сначала.", mainMenu())
+			}
+		}()
+		next(ctx)
+	}
+}
+
+func loggingMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) {
+		user := ctx.user
+		switch {
+		case ctx.callback != nil:
+			log.Info().Int64("id", user.TgID).Str("user", user.Name).Str("state", user.State).
+				Msgf("Пользователь нажал кнопку: %s", ctx.callback.Data)
+		case ctx.location != nil:
+			log.Info().Int64("id", user.TgID).Str("user", user.Name).Str("state", user.State).
+				Msgf("Пользователь отправил геолокацию: %f, %f", ctx.location.Latitude, ctx.location.Longitude)
+		default:
+			log.Info().Int64("id", user.TgID).Str("user", user.Name).Str("username", ctx.username).Str("city", user.City).Str("state", user.State).Bool("sticker", user.Sticker).
+				Msgf("Пользователь отправил сообщение: %s", ctx.text)
+		}
+		next(ctx)
+	}
+}
+
+func metricsMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) {
+		monitoring.BotRequestsTotal.Inc()
+		start := time.Now()
+		next(ctx)
+		monitoring.BotHandlerDuration.WithLabelValues(ctx.route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// adminOnly пропускает только администраторов, остальные получают ответ как на неизвестную команду
+func (h *Handler) adminOnly(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) {
+		if !h.isAdmin(ctx.user.TgID) {
+			log.Warn().Int64("id", ctx.user.TgID).Str("route", ctx.route).Msg("Служебная команда от пользователя без прав")
+			h.handleUnknownCommand(ctx)
+			return
+		}
+		next(ctx)
+	}
+}
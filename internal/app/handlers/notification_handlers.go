@@ -1,59 +1,355 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 	"weather-bot/internal/app/jobs"
-	"weather-bot/internal/app/reply"
-	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/models"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/rs/zerolog/log"
 )
 
-func handleTimeInput(ctx *Context) {
+const maxSubscriptions = 10
 
-	switch ctx.text {
-	case "↩ Отмена":
+// Действия над подпиской, выбранной из списка (хранятся в user.Draft)
+const (
+	subscriptionActionEdit   = "edit"
+	subscriptionActionRemove = "remove"
+)
+
+func (h *Handler) handleNotifications(ctx *Context) {
+	subs, err := h.userSubscriptions(ctx, ctx.user)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении подписок")
 		ctx.user.State = string(StateNone)
-		reply.Send().Message(ctx.user.ChatID, "Отменено.", mainMenu())
+		h.reply.Message(ctx, ctx.user, notificationsUnavailableMessage(), mainMenu())
 		return
-	case "❌ Удалить":
+	}
+
+	ctx.user.Draft = ""
+	ctx.user.State = string(StateAwaitingNotificationAction)
+	h.reply.Message(ctx, ctx.user, subscriptionsMessage(subs), notificationMenu(len(subs) > 0))
+}
+
+// userSubscriptions возвращает подписки пользователя. Уведомление, заведённое до появления подписок,
+// переносится в подписку на основной город.
+func (h *Handler) userSubscriptions(ctx context.Context, user *models.User) ([]models.Subscription, error) {
+	subs, err := h.services.GetSubscriptions(ctx, user.TgID)
+	if err != nil || len(subs) > 0 {
+		return subs, err
+	}
+
+	executeAt, err := h.services.ScheduledAt(ctx, storage.QueueUserNotifications, storage.NotificationJobID(user.TgID, ""))
+	if err != nil || executeAt == 0 {
+		return subs, err
+	}
+
+	sub := models.NewMainCitySubscription(time.Unix(executeAt, 0).Format("15:04"), models.EveryDay)
+	if err := h.services.SaveSubscription(ctx, user.TgID, sub); err != nil {
+		return nil, err
+	}
+	if err := h.scheduler.UnscheduleUserUpdate(ctx, user.TgID, ""); err != nil {
+		return nil, err
+	}
+	if err := h.scheduler.ScheduleUserUpdate(ctx, user.TgID, sub); err != nil {
+		return nil, err
+	}
+	return []models.Subscription{sub}, nil
+}
+
+func (h *Handler) handleNotificationAction(ctx *Context) {
+	switch ctx.text {
+	case "↩ Отмена":
 		ctx.user.State = string(StateNone)
-		err := services.Global().RemoveUserNotification(ctx.user.TgID)
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+	case "➕ Добавить":
+		subs, err := h.services.GetSubscriptions(ctx, ctx.user.TgID)
 		if err != nil {
-			log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при удалении уведомления")
-			reply.Send().Message(ctx.user.ChatID, "❌ Ошибка при удалении уведомления.", mainMenu())
-		} else {
-			reply.Send().Message(ctx.user.ChatID, "✅ Уведомление удалено.", mainMenu())
+			log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении подписок")
+			ctx.user.State = string(StateNone)
+			h.reply.Message(ctx, ctx.user, notificationsUnavailableMessage(), mainMenu())
+			return
+		}
+		if len(subs) >= maxSubscriptions {
+			h.reply.Message(ctx, ctx.user, fmt.Sprintf("⛔️ Можно завести не больше %d уведомлений. Удалите одно из них, чтобы добавить новое.", maxSubscriptions), notificationMenu(true))
+			return
 		}
-		return
-	case "✏ Изменить":
-		reply.Send().Message(ctx.user.ChatID, enterNotificationTimeMessage(), tgbotapi.NewRemoveKeyboard(true))
-		return
-	}
 
-	if isValidTime(ctx.text) {
-		// Парсим `HH:MM` в `time.Time`
-		notifTime, err := time.Parse("15:04", ctx.text)
+		places, err := h.services.GetUserCities(ctx, ctx.user.TgID)
 		if err != nil {
-			log.Error().Err(err).Int64("user", ctx.user.TgID).Time("notification time", notifTime).Msgf("Ошибка парсинга времени: %s", ctx.text)
+			log.Warn().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении сохранённых мест")
 		}
-		err = jobs.ScheduleUserUpdate(ctx.user.TgID, notifTime)
+		ctx.user.State = string(StateAwaitingNotificationCity)
+		h.reply.Message(ctx, ctx.user, "❔ Для какого города присылать прогноз?", notificationCityMenu(ctx.user, places))
+	case "✏ Изменить", "❌ Удалить":
+		subs, err := h.services.GetSubscriptions(ctx, ctx.user.TgID)
 		if err != nil {
-			log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при добавлении уведомлений")
+			log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении подписок")
+			ctx.user.State = string(StateNone)
+			h.reply.Message(ctx, ctx.user, notificationsUnavailableMessage(), mainMenu())
+			return
 		}
-		log.Info().Int64("user", ctx.user.TgID).Msgf("Время %s для юзера %s сохранено", ctx.text, ctx.user.Name)
+
+		ctx.user.Draft = subscriptionActionEdit
+		msg := "❔ Какое уведомление изменить?"
+		if ctx.text == "❌ Удалить" {
+			ctx.user.Draft = subscriptionActionRemove
+			msg = "❔ Какое уведомление удалить?"
+		}
+		ctx.user.State = string(StateAwaitingNotificationPick)
+		h.reply.Message(ctx, ctx.user, msg, subscriptionsPickMenu(subs))
+	default:
+		h.reply.Message(ctx, ctx.user, "🤷‍♀️ Выберите действие из меню.", nil)
+	}
+}
+
+func (h *Handler) handleNotificationCity(ctx *Context) {
+	if ctx.text == "↩ Отмена" {
 		ctx.user.State = string(StateNone)
-		reply.Send().Message(ctx.user.ChatID, fmt.Sprintf("🎉 Отлично! Завтра в %s вам придет прогноз погоды.", ctx.text), mainMenu())
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+		return
+	}
 
+	var sub models.Subscription
+	if place, err := h.findSavedCity(ctx, ctx.user.TgID, ctx.text); err == nil && place != nil {
+		sub = models.NewSubscription(place.Name, place.City, place.CityID, "", models.EveryDay)
+	} else if ctx.text == ctx.user.City && ctx.user.CityID != "" {
+		sub = models.NewMainCitySubscription("", models.EveryDay)
 	} else {
-		reply.Send().Message(ctx.user.ChatID, "⛔️ Неверный формат времени (часы:минуты). Попробуйте ввести еще раз.", tgbotapi.NewReplyKeyboard(
-			tgbotapi.NewKeyboardButtonRow(
-				tgbotapi.NewKeyboardButton("↩ Отмена"))))
+		h.reply.Message(ctx, ctx.user, "🤷‍♀️ Выберите город из меню.", nil)
+		return
+	}
+
+	setDraftSubscription(ctx.user, sub)
+	ctx.user.State = string(StateAwaitingTimeInput)
+	h.reply.Message(ctx, ctx.user, enterNotificationTimeMessage(), cancelMenu())
+}
+
+func (h *Handler) handleNotificationPick(ctx *Context) {
+	action := ctx.user.Draft
+	ctx.user.Draft = ""
+	if ctx.text == "↩ Отмена" {
+		ctx.user.State = string(StateNone)
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+		return
+	}
+
+	subs, err := h.services.GetSubscriptions(ctx, ctx.user.TgID)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении подписок")
+		ctx.user.State = string(StateNone)
+		h.reply.Message(ctx, ctx.user, notificationsUnavailableMessage(), mainMenu())
+		return
+	}
+
+	// Кнопка имеет вид "2. Дача в 07:00 (по выходным)"
+	number, _, _ := strings.Cut(ctx.text, ".")
+	index, err := strconv.Atoi(number)
+	if err != nil || index < 1 || index > len(subs) {
+		ctx.user.Draft = action
+		h.reply.Message(ctx, ctx.user, "🤷‍♀️ Выберите уведомление из меню.", subscriptionsPickMenu(subs))
+		return
 	}
+	sub := subs[index-1]
+
+	switch action {
+	case subscriptionActionRemove:
+		ctx.user.State = string(StateNone)
+		if err := h.services.RemoveSubscription(ctx, ctx.user.TgID, sub.ID); err != nil {
+			log.Error().Err(err).Int64("user", ctx.user.TgID).Str("subscription", sub.ID).Msg("Ошибка при удалении подписки")
+			h.reply.Message(ctx, ctx.user, "❌ Ошибка при удалении уведомления.", mainMenu())
+			return
+		}
+		if err := h.scheduler.UnscheduleUserUpdate(ctx, ctx.user.TgID, sub.ID); err != nil {
+			log.Error().Err(err).Int64("user", ctx.user.TgID).Str("subscription", sub.ID).Msg("Ошибка при удалении уведомления")
+		}
+		h.reply.Message(ctx, ctx.user, "✅ Уведомление удалено.", mainMenu())
+	case subscriptionActionEdit:
+		setDraftSubscription(ctx.user, sub)
+		ctx.user.State = string(StateAwaitingTimeInput)
+		h.reply.Message(ctx, ctx.user, fmt.Sprintf("Сейчас: %s.\n%s", sub, enterNotificationTimeMessage()), cancelMenu())
+	default:
+		h.handleUnknownState(ctx)
+	}
+}
+
+func (h *Handler) handleTimeInput(ctx *Context) {
+	if ctx.text == "↩ Отмена" {
+		ctx.user.Draft = ""
+		ctx.user.State = string(StateNone)
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+		return
+	}
+
+	sub, err := draftSubscription(ctx.user)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("draft", ctx.user.Draft).Msg("Ошибка чтения черновика подписки")
+		h.handleUnknownState(ctx)
+		return
+	}
+
+	if !isValidTime(ctx.text) {
+		h.reply.Message(ctx, ctx.user, "⛔️ Неверный формат времени (часы:минуты). Попробуйте ввести еще раз.", cancelMenu())
+		return
+	}
+
+	sub.Time = ctx.text
+	setDraftSubscription(ctx.user, *sub)
+	ctx.user.State = string(StateAwaitingNotificationDays)
+	h.reply.Message(ctx, ctx.user, chooseDaysMessage(*sub), notificationDaysKeyboard(sub.Days))
+}
+
+// handleNotificationDays принимает дни недели, перечисленные текстом, основной способ - inline-кнопки (handleDaysCallback)
+func (h *Handler) handleNotificationDays(ctx *Context) {
+	if ctx.text == "↩ Отмена" {
+		ctx.user.Draft = ""
+		ctx.user.State = string(StateNone)
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+		return
+	}
+
+	sub, err := draftSubscription(ctx.user)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("draft", ctx.user.Draft).Msg("Ошибка чтения черновика подписки")
+		h.handleUnknownState(ctx)
+		return
+	}
+
+	days, err := models.ParseWeekdays(ctx.text)
+	if err != nil {
+		h.reply.Message(ctx, ctx.user, "⛔️ Не удалось разобрать дни недели. Отметьте их кнопками или перечислите через запятую: Пн, Вт, Ср, Чт, Пт, Сб, Вс", cancelMenu())
+		return
+	}
+	sub.Days = days
+	h.confirmDays(ctx, sub)
+}
+
+// handleDaysCallback переключает дни недели в inline-клавиатуре, не отправляя новых сообщений
+func (h *Handler) handleDaysCallback(ctx *Context) {
+	action := ctx.args
+	if UserState(ctx.user.State) != StateAwaitingNotificationDays {
+		h.answerCallback(ctx, "Это меню устарело, откройте /notifications заново")
+		return
+	}
+
+	sub, err := draftSubscription(ctx.user)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("draft", ctx.user.Draft).Msg("Ошибка чтения черновика подписки")
+		h.answerCallback(ctx, "")
+		h.handleUnknownState(ctx)
+		return
+	}
+
+	switch action {
+	case daysActionDone:
+		h.answerCallback(ctx, "")
+		h.editCallbackMessage(ctx, fmt.Sprintf("📅 Дни: %s", sub.Days), nil)
+		h.confirmDays(ctx, sub)
+		return
+	case daysActionWork:
+		sub.Days = models.WorkDays
+	case daysActionWeekend:
+		sub.Days = models.Weekend
+	case daysActionAll:
+		sub.Days = models.EveryDay
+	default:
+		day, err := strconv.Atoi(action)
+		if err != nil || day < int(time.Sunday) || day > int(time.Saturday) {
+			h.answerCallback(ctx, "")
+			return
+		}
+		sub.Days = sub.Days.Toggle(time.Weekday(day))
+	}
+
+	setDraftSubscription(ctx.user, *sub)
+	h.answerCallback(ctx, "")
+	keyboard := notificationDaysKeyboard(sub.Days)
+	h.editCallbackMessage(ctx, chooseDaysMessage(*sub), &keyboard)
+}
+
+// confirmDays спрашивает отдельное время для выходных, если уведомление приходит и в будни, и в выходные
+func (h *Handler) confirmDays(ctx *Context, sub *models.Subscription) {
+	if sub.Days&models.Weekend == 0 || sub.Days&models.WorkDays == 0 {
+		sub.WeekendTime = ""
+		h.saveDraftSubscription(ctx, sub)
+		return
+	}
+
+	setDraftSubscription(ctx.user, *sub)
+	ctx.user.State = string(StateAwaitingWeekendTimeInput)
+	h.reply.Message(ctx, ctx.user, fmt.Sprintf("❔ В выходные присылать тоже в %s? Или введите другое время (например: 10:00)", sub.Time), weekendTimeMenu())
+}
+
+func (h *Handler) handleWeekendTimeInput(ctx *Context) {
+	if ctx.text == "↩ Отмена" {
+		ctx.user.Draft = ""
+		ctx.user.State = string(StateNone)
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+		return
+	}
+
+	sub, err := draftSubscription(ctx.user)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("draft", ctx.user.Draft).Msg("Ошибка чтения черновика подписки")
+		h.handleUnknownState(ctx)
+		return
+	}
+
+	switch {
+	case ctx.text == sameWeekendTime:
+		sub.WeekendTime = ""
+	case isValidTime(ctx.text):
+		sub.WeekendTime = ctx.text
+	default:
+		h.reply.Message(ctx, ctx.user, "⛔️ Неверный формат времени (часы:минуты). Попробуйте ввести еще раз.", weekendTimeMenu())
+		return
+	}
+
+	h.saveDraftSubscription(ctx, sub)
+}
+
+func (h *Handler) saveDraftSubscription(ctx *Context, sub *models.Subscription) {
+	ctx.user.Draft = ""
+	ctx.user.State = string(StateNone)
+
+	if err := h.services.SaveSubscription(ctx, ctx.user.TgID, *sub); err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("subscription", sub.ID).Msg("Ошибка при сохранении подписки")
+		h.reply.Message(ctx, ctx.user, "❌ Не удалось сохранить уведомление. Попробуйте повторить позже.", mainMenu())
+		return
+	}
+	if err := h.scheduler.ScheduleUserUpdate(ctx, ctx.user.TgID, *sub); err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при добавлении уведомлений")
+	}
+
+	log.Info().Int64("user", ctx.user.TgID).Str("subscription", sub.ID).Msgf("Уведомление %s для юзера %s сохранено", sub, ctx.user.Name)
+	msg := fmt.Sprintf("🎉 Отлично! Уведомление сохранено: %s.", sub)
+	if next, err := jobs.NextNotificationTime(*sub, time.Now()); err == nil {
+		msg += fmt.Sprintf("\nБлижайший прогноз придёт %s.", next.Format("02.01 в 15:04"))
+	}
+	h.reply.Message(ctx, ctx.user, msg, mainMenu())
+}
+
+func draftSubscription(user *models.User) (*models.Subscription, error) {
+	var sub models.Subscription
+	if err := json.Unmarshal([]byte(user.Draft), &sub); err != nil {
+		return nil, err
+	}
+	// Пустой CityID - подписка на основной город
+	if sub.ID == "" {
+		return nil, fmt.Errorf("неполный черновик подписки")
+	}
+	return &sub, nil
+}
 
+func setDraftSubscription(user *models.User, sub models.Subscription) {
+	data, _ := json.Marshal(sub)
+	user.Draft = string(data)
 }
 
 func isValidTime(input string) bool {
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"weather-bot/internal/app/monitoring"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// UpdatePool обрабатывает обновления параллельно. Обновления одного пользователя
+// попадают в одну очередь и обрабатываются по порядку.
+type UpdatePool struct {
+	ctx    context.Context
+	handle func(context.Context, tgbotapi.Update)
+	queues []chan tgbotapi.Update
+	wg     sync.WaitGroup
+}
+
+// NewUpdatePool запускает workers обработчиков handle с очередями по queueSize обновлений.
+// Когда очередь пользователя заполнена, Submit ждёт. ctx передаётся обработчикам.
+func NewUpdatePool(ctx context.Context, workers, queueSize int, handle func(context.Context, tgbotapi.Update)) *UpdatePool {
+	p := &UpdatePool{ctx: ctx, handle: handle, queues: make([]chan tgbotapi.Update, workers)}
+	for i := range p.queues {
+		p.queues[i] = make(chan tgbotapi.Update, queueSize)
+		p.wg.Add(1)
+		go p.worker(p.queues[i])
+	}
+	return p
+}
+
+func (p *UpdatePool) Submit(update tgbotapi.Update) {
+	from := updateSender(update)
+	if from == nil {
+		return
+	}
+
+	monitoring.BotUpdatesQueued.Inc()
+	p.queues[uint64(from.ID)%uint64(len(p.queues))] <- update
+}
+
+// Close перестаёт принимать обновления и дожидается обработки уже принятых, но не дольше ctx
+func (p *UpdatePool) Close(ctx context.Context) error {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("не дождались обработки обновлений: %w", ctx.Err())
+	}
+}
+
+func (p *UpdatePool) worker(queue chan tgbotapi.Update) {
+	defer p.wg.Done()
+
+	for update := range queue {
+		monitoring.BotUpdatesQueued.Dec()
+		monitoring.BotUpdatesInFlight.Inc()
+		p.handle(p.ctx, update)
+		monitoring.BotUpdatesInFlight.Dec()
+	}
+}
+
+func updateSender(update tgbotapi.Update) *tgbotapi.User {
+	switch {
+	case update.Message != nil:
+		return update.Message.From
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From
+	}
+	return nil
+}
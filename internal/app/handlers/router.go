@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type HandlerFunc func(ctx *Context)
+
+// Middleware оборачивает обработчик: логирование, метрики, проверка прав
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Command - команда бота. Команду вызывают по имени ("/weather") или тексту кнопки из Aliases.
+// Команды с Description публикуются в меню команд Telegram.
+type Command struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Handler     HandlerFunc
+	Middleware  []Middleware // Только для этой команды, например adminOnly
+}
+
+type callbackRoute struct {
+	prefix  string
+	handler HandlerFunc
+}
+
+// Router выбирает обработчик сообщения: нажатие inline-кнопки - по префиксу данных,
+// сообщение в диалоге - по состоянию пользователя, вне диалога - по команде
+type Router struct {
+	middleware []Middleware
+	commands   map[string]*Command
+	ordered    []*Command
+	states     map[UserState]HandlerFunc
+	callbacks  []callbackRoute
+
+	unknownCommand  HandlerFunc
+	unknownState    HandlerFunc
+	unknownCallback HandlerFunc
+}
+
+func NewRouter() *Router {
+	return &Router{
+		commands:        make(map[string]*Command),
+		states:          make(map[UserState]HandlerFunc),
+		unknownCommand:  func(*Context) {},
+		unknownState:    func(*Context) {},
+		unknownCallback: func(*Context) {},
+	}
+}
+
+// Use добавляет middleware для всех сообщений, первое добавленное выполняется первым
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+func (r *Router) Command(cmd Command) {
+	handler := chain(cmd.Handler, cmd.Middleware)
+	cmd.Handler = handler
+
+	r.ordered = append(r.ordered, &cmd)
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		if _, exists := r.commands[name]; exists {
+			panic("handlers: команда зарегистрирована дважды: " + name)
+		}
+		r.commands[name] = &cmd
+	}
+}
+
+func (r *Router) State(state UserState, handler HandlerFunc) {
+	r.states[state] = handler
+}
+
+// Callback регистрирует обработчик inline-кнопок, данные которых начинаются с prefix.
+// В ctx.args передаются данные без префикса. Префиксы не должны продолжать друг друга,
+// иначе обработчик кнопки зависел бы от порядка проверки.
+func (r *Router) Callback(prefix string, handler HandlerFunc) {
+	if prefix == "" {
+		panic("handlers: пустой префикс inline-кнопок")
+	}
+	for _, route := range r.callbacks {
+		if strings.HasPrefix(prefix, route.prefix) || strings.HasPrefix(route.prefix, prefix) {
+			panic("handlers: префикс inline-кнопок " + prefix + " пересекается с " + route.prefix)
+		}
+	}
+	r.callbacks = append(r.callbacks, callbackRoute{prefix: prefix, handler: handler})
+}
+
+func (r *Router) UnknownCommand(handler HandlerFunc)  { r.unknownCommand = handler }
+func (r *Router) UnknownState(handler HandlerFunc)    { r.unknownState = handler }
+func (r *Router) UnknownCallback(handler HandlerFunc) { r.unknownCallback = handler }
+
+func (r *Router) Dispatch(ctx *Context) {
+	chain(r.route, r.middleware)(ctx)
+}
+
+func (r *Router) route(ctx *Context) {
+	if ctx.callback != nil {
+		for _, route := range r.callbacks {
+			if args, ok := strings.CutPrefix(ctx.callback.Data, route.prefix); ok {
+				ctx.route, ctx.args = "callback:"+route.prefix, args
+				route.handler(ctx)
+				return
+			}
+		}
+		ctx.route = "callback:unknown"
+		r.unknownCallback(ctx)
+		return
+	}
+
+	state := UserState(ctx.user.State)
+	if state != StateNone {
+		if handler, ok := r.states[state]; ok {
+			ctx.route = "state:" + string(state)
+			handler(ctx)
+			return
+		}
+		ctx.route = "state:unknown"
+		r.unknownState(ctx)
+		return
+	}
+
+	name, args := ctx.text, ""
+	if strings.HasPrefix(ctx.text, "/") {
+		name, args, _ = strings.Cut(ctx.text, " ")
+		// В группах команда приходит с именем бота: "/weather@MorningVlgBot"
+		name, _, _ = strings.Cut(name, "@")
+	}
+	if cmd, ok := r.commands[name]; ok {
+		ctx.route, ctx.args = cmd.Name, strings.TrimSpace(args)
+		cmd.Handler(ctx)
+		return
+	}
+	ctx.route = "command:unknown"
+	r.unknownCommand(ctx)
+}
+
+// BotCommands - команды для setMyCommands в порядке регистрации
+func (r *Router) BotCommands() []tgbotapi.BotCommand {
+	var commands []tgbotapi.BotCommand
+	for _, cmd := range r.ordered {
+		if cmd.Description == "" || !strings.HasPrefix(cmd.Name, "/") {
+			continue
+		}
+		commands = append(commands, tgbotapi.BotCommand{
+			Command:     strings.TrimPrefix(cmd.Name, "/"),
+			Description: cmd.Description,
+		})
+	}
+	return commands
+}
+
+func chain(handler HandlerFunc, middleware []Middleware) HandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
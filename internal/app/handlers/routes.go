@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// newRouter регистрирует команды, состояния диалогов и inline-кнопки бота.
+// Новая команда добавляется одной регистрацией, меню команд Telegram строится отсюда же.
+func (h *Handler) newRouter() *Router {
+	r := NewRouter()
+	r.Use(h.recoverMiddleware, metricsMiddleware, loggingMiddleware)
+
+	r.Command(Command{Name: "/start", Description: "Начать заново и выбрать город", Handler: h.handleStart})
+	r.Command(Command{Name: "/weather", Aliases: []string{"Узнать погоду"}, Description: "Погода на сегодня", Handler: h.handleWeather})
+	r.Command(Command{Name: "/weather5", Description: "Погода на 5 дней", Handler: h.handleFiveDayWeather})
+	r.Command(Command{Name: "/cities", Aliases: []string{"🏙 Мои города"}, Description: "Мои города", Handler: h.handleSavedCities})
+	r.Command(Command{Name: "/city", Description: "Изменить город", Handler: h.handleChangeCity})
+	r.Command(Command{Name: "/notifications", Description: "Уведомления", Handler: h.handleNotifications})
+	r.Command(Command{Name: "/stickers", Description: "Включить или выключить стикеры", Handler: h.handleStickers})
+	r.Command(Command{Name: "/diff_city_weather", Description: "Погода в другом городе", Handler: h.handleDiffCityWeather})
+	r.Command(Command{Name: "/dlq", Handler: h.handleDeadLetters, Middleware: []Middleware{h.adminOnly}})
+	r.UnknownCommand(h.handleUnknownCommand)
+
+	r.State(StateAwaitingCityInput, h.handleCityInput)
+	// Город выбирают inline-кнопками, введённый в это время текст - новый поиск
+	r.State(StateAwaitingCitySelection, h.handleCityInput)
+	r.State(StateAwaitingTimeInput, h.handleTimeInput)
+
+	r.State(StateAwaitingNotificationAction, h.handleNotificationAction)
+	r.State(StateAwaitingNotificationCity, h.handleNotificationCity)
+	r.State(StateAwaitingNotificationPick, h.handleNotificationPick)
+	r.State(StateAwaitingNotificationDays, h.handleNotificationDays)
+	r.State(StateAwaitingWeekendTimeInput, h.handleWeekendTimeInput)
+
+	r.State(StateAwaitingDiffCityInput, h.handleDiffCityInput)
+	r.State(StateAwaitingDiffCitySelection, h.handleDiffCityInput)
+
+	r.State(StateAwaitingSavedCityChoice, h.handleSavedCityChoice)
+	r.State(StateAwaitingSavedCityName, h.handleSavedCityName)
+	r.State(StateAwaitingSavedCityInput, h.handleSavedCityInput)
+	r.State(StateAwaitingSavedCitySelection, h.handleSavedCityInput)
+	r.State(StateAwaitingSavedCityRemoval, h.handleSavedCityRemoval)
+	r.UnknownState(h.handleUnknownState)
+
+	r.Callback(callbackDays, h.handleDaysCallback)
+	r.Callback(callbackCity, h.handleCityCallback)
+	r.UnknownCallback(func(ctx *Context) {
+		log.Warn().Int64("user", ctx.user.TgID).Str("data", ctx.callback.Data).Msg("Неизвестная inline-кнопка")
+		h.answerCallback(ctx, "")
+	})
+
+	return r
+}
+
+// BotCommands - меню команд для setMyCommands
+func (h *Handler) BotCommands() []tgbotapi.BotCommand {
+	return h.router.BotCommands()
+}
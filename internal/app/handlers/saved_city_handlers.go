@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"weather-bot/internal/app/weather"
+	"weather-bot/internal/models"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxSavedCities      = 5
+	maxSavedCityNameLen = 20
+
+	savedCityTodayPrefix    = "☀️ "
+	savedCityFiveDaysPrefix = "🗓 "
+)
+
+func (h *Handler) handleSavedCities(ctx *Context) {
+	cities, err := h.services.GetUserCities(ctx, ctx.user.TgID)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении сохранённых мест")
+		ctx.user.State = string(StateNone)
+		h.reply.Message(ctx, ctx.user, "😢 Сохранённые места сейчас недоступны. Попробуйте повторить позже.", mainMenu())
+		return
+	}
+
+	ctx.user.State = string(StateAwaitingSavedCityChoice)
+	h.reply.Message(ctx, ctx.user, savedCitiesMessage(cities), savedCitiesMenu(cities))
+}
+
+func (h *Handler) handleSavedCityChoice(ctx *Context) {
+	switch ctx.text {
+	case "↩ Отмена":
+		ctx.user.State = string(StateNone)
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+		return
+	case "➕ Добавить место":
+		cities, err := h.services.GetUserCities(ctx, ctx.user.TgID)
+		if err != nil {
+			log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении сохранённых мест")
+			h.reply.Message(ctx, ctx.user, "😢 Сохранённые места сейчас недоступны. Попробуйте повторить позже.", mainMenu())
+			ctx.user.State = string(StateNone)
+			return
+		}
+		if len(cities) >= maxSavedCities {
+			h.reply.Message(ctx, ctx.user, "⛔️ Можно сохранить не больше 5 мест. Удалите одно из них, чтобы добавить новое.", savedCitiesMenu(cities))
+			return
+		}
+		ctx.user.State = string(StateAwaitingSavedCityName)
+		h.reply.Message(ctx, ctx.user, enterSavedCityNameMessage(), cancelMenu())
+		return
+	case "❌ Удалить место":
+		cities, err := h.services.GetUserCities(ctx, ctx.user.TgID)
+		if err != nil {
+			log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении сохранённых мест")
+			h.reply.Message(ctx, ctx.user, "😢 Сохранённые места сейчас недоступны. Попробуйте повторить позже.", mainMenu())
+			ctx.user.State = string(StateNone)
+			return
+		}
+		ctx.user.State = string(StateAwaitingSavedCityRemoval)
+		h.reply.Message(ctx, ctx.user, "❔ Какое место удалить?", savedCitiesRemovalMenu(cities))
+		return
+	}
+
+	if name, ok := strings.CutPrefix(ctx.text, savedCityTodayPrefix); ok {
+		ctx.user.State = string(StateNone)
+		h.sendSavedCityWeather(ctx, name, false)
+		return
+	}
+	if name, ok := strings.CutPrefix(ctx.text, savedCityFiveDaysPrefix); ok {
+		ctx.user.State = string(StateNone)
+		h.sendSavedCityWeather(ctx, name, true)
+		return
+	}
+
+	h.reply.Message(ctx, ctx.user, "🤷‍♀️ Выберите место из меню.", nil)
+}
+
+func (h *Handler) handleSavedCityName(ctx *Context) {
+	if ctx.text == "↩ Отмена" {
+		ctx.user.State = string(StateNone)
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+		return
+	}
+
+	name := strings.TrimSpace(ctx.text)
+	// Название попадает в сообщения с HTML-разметкой
+	if name == "" || strings.HasPrefix(name, "/") || strings.ContainsAny(name, "<>&") || utf8.RuneCountInString(name) > maxSavedCityNameLen {
+		h.reply.Message(ctx, ctx.user, "⛔️ Такое название не подходит. "+enterSavedCityNameMessage(), cancelMenu())
+		return
+	}
+
+	existing, err := h.findSavedCity(ctx, ctx.user.TgID, name)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении сохранённых мест")
+		ctx.user.State = string(StateNone)
+		h.reply.Message(ctx, ctx.user, "😢 Сохранённые места сейчас недоступны. Попробуйте повторить позже.", mainMenu())
+		return
+	}
+	if existing != nil {
+		h.reply.Message(ctx, ctx.user, "⛔️ Место с таким названием уже есть. Придумайте другое:", cancelMenu())
+		return
+	}
+
+	ctx.user.Draft = name
+	ctx.user.State = string(StateAwaitingSavedCityInput)
+	h.reply.Message(ctx, ctx.user, enterSavedCityMessage(name), diffCityInputMenu())
+}
+
+func (h *Handler) handleSavedCityInput(ctx *Context) {
+	if ctx.text == "↩ Отмена" {
+		ctx.user.Draft = ""
+		ctx.user.State = string(StateNone)
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+		return
+	}
+	if ctx.location != nil {
+		h.handleLocation(ctx, StateAwaitingSavedCitySelection, diffCityInputMenu)
+		return
+	}
+	if !IsValidCity(ctx.text) {
+		h.reply.Message(ctx, ctx.user, "⛔️ Принимается название города только на кириллице. Попробуйте еще раз:", diffCityInputMenu())
+		return
+	}
+
+	cities, err := h.search.SearchCity(ctx, ctx.text)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("city", ctx.text).Msg("Ошибка при поиске города")
+		h.reply.Message(ctx, ctx.user, errorFindCityMessage(), diffCityInputMenu())
+		return
+	}
+
+	if len(cities) == 1 {
+		h.saveDraftCity(ctx, &cities[0])
+		return
+	}
+
+	if len(cities) > 1 {
+		ctx.user.State = string(StateAwaitingSavedCitySelection)
+		h.reply.Message(ctx, ctx.user, "🔍 Найдено несколько городов. Пожалуйста, выберите нужный:", makeCityKeyboard(cities))
+		return
+	}
+
+	h.reply.Message(ctx, ctx.user, errorFindCityMessage(), diffCityInputMenu())
+}
+
+// saveDraftCity сохраняет выбранный город под названием, введённым на предыдущем шаге (user.Draft)
+func (h *Handler) saveDraftCity(ctx *Context, city *models.City) {
+	name := ctx.user.Draft
+	ctx.user.Draft = ""
+	ctx.user.State = string(StateNone)
+
+	if name == "" {
+		h.handleUnknownState(ctx)
+		return
+	}
+
+	userCity := models.UserCity{
+		Name:   name,
+		City:   city.Name,
+		CityID: strconv.Itoa(city.ID),
+		Region: city.Region,
+	}
+	if err := h.services.SaveUserCity(ctx, ctx.user.TgID, userCity); err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("name", name).Msg("Ошибка при сохранении места")
+		h.reply.Message(ctx, ctx.user, "❌ Не удалось сохранить место. Попробуйте повторить позже.", mainMenu())
+		return
+	}
+
+	log.Info().Int64("user", ctx.user.TgID).Str("name", name).Str("city", city.Name).Msg("Пользователь сохранил место")
+	h.reply.Message(ctx, ctx.user, successSaveSavedCityMessage(name, city.Name), mainMenu())
+}
+
+func (h *Handler) handleSavedCityRemoval(ctx *Context) {
+	ctx.user.State = string(StateNone)
+	if ctx.text == "↩ Отмена" {
+		h.reply.Message(ctx, ctx.user, "Отменено.", mainMenu())
+		return
+	}
+
+	city, err := h.findSavedCity(ctx, ctx.user.TgID, ctx.text)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении сохранённых мест")
+		h.reply.Message(ctx, ctx.user, "❌ Ошибка при удалении места.", mainMenu())
+		return
+	}
+	if city == nil {
+		h.reply.Message(ctx, ctx.user, unknownSavedCityMessage(ctx.text), mainMenu())
+		return
+	}
+
+	if err := h.services.RemoveUserCity(ctx, ctx.user.TgID, city.Name); err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("name", city.Name).Msg("Ошибка при удалении места")
+		h.reply.Message(ctx, ctx.user, "❌ Ошибка при удалении места.", mainMenu())
+		return
+	}
+	h.reply.Message(ctx, ctx.user, "✅ Место «"+city.Name+"» удалено.", mainMenu())
+}
+
+// sendSavedCityWeather отправляет прогноз для сохранённого места на сегодня или на 5 дней
+func (h *Handler) sendSavedCityWeather(ctx *Context, name string, fiveDays bool) {
+	city, err := h.findSavedCity(ctx, ctx.user.TgID, name)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении сохранённых мест")
+		h.reply.Message(ctx, ctx.user, errorGetWeatherMessage(), mainMenu())
+		return
+	}
+	if city == nil {
+		h.reply.Message(ctx, ctx.user, unknownSavedCityMessage(name), mainMenu())
+		return
+	}
+
+	forecast, err := h.weather.Get(ctx, city.CityID)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("cityID", city.CityID).Msg("Ошибка при получении погоды")
+		h.reply.Message(ctx, ctx.user, errorGetWeatherMessage(), mainMenu())
+		return
+	}
+
+	// Прогноз отправляется в HTML-разметке, название места вводил пользователь
+	title := html.EscapeString(city.Name) + ", " + city.City
+	if fiveDays {
+		h.reply.Message(ctx, ctx.user, weather.FormatFiveDayForecast(title, forecast.ShortDays), mainMenu())
+		return
+	}
+	h.reply.SendDailyWeather(ctx, ctx.user, title, forecast)
+}
+
+// findSavedCity ищет сохранённое место пользователя по названию без учёта регистра
+func (h *Handler) findSavedCity(ctx context.Context, userID int64, name string) (*models.UserCity, error) {
+	cities, err := h.services.GetUserCities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	for _, city := range cities {
+		if strings.EqualFold(city.Name, name) {
+			return &city, nil
+		}
+	}
+	return nil, nil
+}
@@ -1,14 +1,8 @@
 package handlers
 
 import (
-	"fmt"
-	"strconv"
-	"time"
-	"weather-bot/internal/app/reply"
-	"weather-bot/internal/app/services"
 	"weather-bot/internal/app/weather"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/rs/zerolog/log"
 )
 
@@ -21,97 +15,87 @@ const (
 	StateAwaitingCitySelection UserState = "awaiting_city_selection"
 	StateAwaitingTimeInput     UserState = "awaiting_time_input"
 
+	StateAwaitingNotificationAction UserState = "awaiting_notification_action"
+	StateAwaitingNotificationCity   UserState = "awaiting_notification_city"
+	StateAwaitingNotificationPick   UserState = "awaiting_notification_pick"
+	StateAwaitingNotificationDays   UserState = "awaiting_notification_days"
+	StateAwaitingWeekendTimeInput   UserState = "awaiting_weekend_time_input"
+
 	StateAwaitingDiffCityInput     UserState = "awating_diff_city_input"
 	StateAwaitingDiffCitySelection UserState = "awaiting_diff_city_selection"
+
+	StateAwaitingSavedCityChoice    UserState = "awaiting_saved_city_choice"
+	StateAwaitingSavedCityName      UserState = "awaiting_saved_city_name"
+	StateAwaitingSavedCityInput     UserState = "awaiting_saved_city_input"
+	StateAwaitingSavedCitySelection UserState = "awaiting_saved_city_selection"
+	StateAwaitingSavedCityRemoval   UserState = "awaiting_saved_city_removal"
 )
 
-func processMessage(ctx *Context) {
-
-	// Обрабатываем сообщение в зависимости от текущего состояния пользователя
-	switch UserState(ctx.user.State) {
-	case StateNone:
-		handleDefaultState(ctx)
-	case StateAwaitingCityInput:
-		handleCityInput(ctx)
-	case StateAwaitingCitySelection:
-		handleCitySelection(ctx)
-	case StateAwaitingTimeInput:
-		handleTimeInput(ctx)
-
-	case StateAwaitingDiffCityInput:
-		handleDiffCityInput(ctx)
-	case StateAwaitingDiffCitySelection:
-		handleDiffCitySelection(ctx)
-
-	default:
-		handleUnknownState(ctx)
+func (h *Handler) processMessage(ctx *Context) {
+	h.router.Dispatch(ctx)
+}
+
+func (h *Handler) handleStart(ctx *Context) {
+	ctx.user.State = string(StateAwaitingCityInput)
+	h.reply.Message(ctx, ctx.user, startMessage(), cityInputMenu())
+}
+
+// handleWeather отправляет прогноз на сегодня, "/weather Дача" - для сохранённого места
+func (h *Handler) handleWeather(ctx *Context) {
+	if ctx.args != "" {
+		h.sendSavedCityWeather(ctx, ctx.args, false)
+		return
+	}
+	forecast, err := h.weather.Get(ctx, ctx.user.CityID)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("cityID", ctx.user.City).Msg("Ошибка при получении погоды")
+		h.reply.Message(ctx, ctx.user, errorGetWeatherMessage(), mainMenu())
+		return
 	}
+	h.reply.SendDailyWeather(ctx, ctx.user, ctx.user.City, forecast)
 }
 
-func handleDefaultState(ctx *Context) {
-	switch ctx.text {
-	case "/start":
-		ctx.user.State = string(StateAwaitingCityInput)
-		reply.Send().Message(ctx.user.ChatID, startMessage(), tgbotapi.NewRemoveKeyboard(true))
-	case "Узнать погоду", "/weather":
-		forecast, err := weather.Get(ctx.user.CityID)
-		if err != nil {
-			log.Error().Err(err).Int64("user", ctx.user.TgID).Str("cityID", ctx.user.City).Msg("Ошибка при получении погоды")
-			reply.Send().Message(ctx.user.ChatID, errorGetWeatherMessage(), mainMenu())
-			return
-		}
-		reply.SendDailyWeather(ctx.user, forecast)
-	case "/weather5":
-		forecast, err := weather.Get(ctx.user.CityID)
-		if err != nil {
-			log.Error().Err(err).Int64("user", ctx.user.TgID).Str("cityID", ctx.user.City).Msg("Ошибка при получении погоды")
-			reply.Send().Message(ctx.user.ChatID, errorGetWeatherMessage(), mainMenu())
-			return
-		}
-		msg := weather.FormatFiveDayForecast(ctx.user.City, forecast.ShortDays)
-		reply.Send().Message(ctx.user.ChatID, msg, mainMenu())
-	case "/city":
-		ctx.user.State = string(StateAwaitingCityInput)
-		reply.Send().Message(ctx.user.ChatID, enterNameCityMessage(), tgbotapi.NewRemoveKeyboard(true))
-	case "/notifications":
-		existingTime, err := services.Global().GetUserNotificationTime(ctx.user.TgID)
-		if err != nil {
-			log.Error().Err(err).Int64("user", ctx.user.TgID).Msg("Ошибка при получении уведомления")
-			reply.Send().Message(ctx.user.ChatID, "😢 Уведомления сейчас не работают. Попробуйте повторить позже.", mainMenu())
-			return
-		}
-
-		ctx.user.State = string(StateAwaitingTimeInput)
-		if existingTime != "" {
-			// Уведомление уже есть, предлагаем изменить или удалить
-			existingTime, err := strconv.ParseInt(existingTime, 10, 64)
-			if err != nil {
-				log.Error().Err(err).Int64("existing time", existingTime).Msg("Ошибка парсинга existingTime")
-			}
-			msg := fmt.Sprintf("❔ Вы уже получаете уведомления в %s.\nХотите изменить или удалить?", time.Unix(existingTime, 0).Format("15:04"))
-			reply.Send().Message(ctx.user.ChatID, msg, notificationMenu())
-		} else {
-			// Уведомления нет, запрашиваем новое время
-			reply.Send().Message(ctx.user.ChatID, enterNotificationTimeMessage(), tgbotapi.NewRemoveKeyboard(true))
-		}
-	case "/stickers":
-		if ctx.user.Sticker {
-			ctx.user.Sticker = false
-			reply.Send().Message(ctx.user.ChatID, "Стикеры выключены ❌", mainMenu())
-		} else {
-			ctx.user.Sticker = true
-			reply.Send().Message(ctx.user.ChatID, "Стикеры включены ✅", mainMenu())
-		}
-
-	case "/diff_city_weather":
-		ctx.user.State = string(StateAwaitingDiffCityInput)
-		reply.Send().Message(ctx.user.ChatID, enterNameDiffCityMessage(), cancelMenu())
-	default:
-		reply.Send().Message(ctx.user.ChatID, "🤷‍♀️ Я не понимаю такую команду, выберите из меню.", mainMenu())
+// handleFiveDayWeather отправляет прогноз на 5 дней, "/weather5 Дача" - для сохранённого места
+func (h *Handler) handleFiveDayWeather(ctx *Context) {
+	if ctx.args != "" {
+		h.sendSavedCityWeather(ctx, ctx.args, true)
+		return
 	}
+	forecast, err := h.weather.Get(ctx, ctx.user.CityID)
+	if err != nil {
+		log.Error().Err(err).Int64("user", ctx.user.TgID).Str("cityID", ctx.user.City).Msg("Ошибка при получении погоды")
+		h.reply.Message(ctx, ctx.user, errorGetWeatherMessage(), mainMenu())
+		return
+	}
+	msg := weather.FormatFiveDayForecast(ctx.user.City, forecast.ShortDays)
+	h.reply.Message(ctx, ctx.user, msg, mainMenu())
+}
+
+func (h *Handler) handleChangeCity(ctx *Context) {
+	ctx.user.State = string(StateAwaitingCityInput)
+	h.reply.Message(ctx, ctx.user, enterNameCityMessage(), cityInputMenu())
+}
+
+func (h *Handler) handleStickers(ctx *Context) {
+	if ctx.user.Sticker {
+		ctx.user.Sticker = false
+		h.reply.Message(ctx, ctx.user, "Стикеры выключены ❌", mainMenu())
+	} else {
+		ctx.user.Sticker = true
+		h.reply.Message(ctx, ctx.user, "Стикеры включены ✅", mainMenu())
+	}
+}
+
+func (h *Handler) handleDiffCityWeather(ctx *Context) {
+	ctx.user.State = string(StateAwaitingDiffCityInput)
+	h.reply.Message(ctx, ctx.user, enterNameDiffCityMessage(), diffCityInputMenu())
+}
+
+func (h *Handler) handleUnknownCommand(ctx *Context) {
+	h.reply.Message(ctx, ctx.user, "🤷‍♀️ Я не понимаю такую команду, выберите из меню.", mainMenu())
 }
 
-func handleUnknownState(ctx *Context) {
+func (h *Handler) handleUnknownState(ctx *Context) {
 	ctx.user.State = string(StateNone)
-	reply.Send().Message(ctx.user.ChatID, "🔄 Произошла ошибка. Начнем сначала.", startMenu())
+	h.reply.Message(ctx, ctx.user, "🔄 Произошла ошибка. Начнем сначала.", startMenu())
 }
@@ -1,49 +1,122 @@
 package handlers
 
 import (
+	"context"
+	"weather-bot/internal/app/jobs"
 	"weather-bot/internal/app/monitoring"
+	"weather-bot/internal/app/reply"
+	"weather-bot/internal/app/search"
 	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/weather"
 	"weather-bot/internal/models"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/rs/zerolog/log"
 )
 
+// Context - сообщение пользователя в обработке. Встроенный context.Context отменяется,
+// если бот останавливается, не дождавшись обработки.
 type Context struct {
-	bot  *tgbotapi.BotAPI
-	user *models.User
-	text string
+	context.Context
+
+	bot      *tgbotapi.BotAPI
+	user     *models.User
+	text     string
+	location *tgbotapi.Location
+	callback *tgbotapi.CallbackQuery // Нажатие inline-кнопки, text в этом случае пустой
+	username string
+
+	route string // Команда, состояние или inline-кнопка, выбранная роутером
+	args  string // Аргумент команды ("/weather Дача") или данные кнопки без префикса
 }
 
-func Update(update tgbotapi.Update) {
-	monitoring.BotRequestsTotal.Inc()
-	monitoring.UpdateUniqueUsers(update.Message.From.ID)
+// Deps - зависимости обработчиков сообщений
+type Deps struct {
+	Services  *services.ServiceContainer
+	Reply     *reply.Replier
+	Weather   *weather.Client
+	Search    *search.Searcher
+	Scheduler *jobs.Scheduler
+	// Telegram ID администраторов, им доступны служебные команды (/dlq)
+	AdminIDs []int64
+}
+
+// Handler обрабатывает обновления Telegram: находит пользователя, выбирает обработчик
+// через роутер и сохраняет изменения пользователя
+type Handler struct {
+	services  *services.ServiceContainer
+	reply     *reply.Replier
+	weather   *weather.Client
+	search    *search.Searcher
+	scheduler *jobs.Scheduler
+	admins    map[int64]bool
+	router    *Router
+}
+
+func NewHandler(deps Deps) *Handler {
+	h := &Handler{
+		services:  deps.Services,
+		reply:     deps.Reply,
+		weather:   deps.Weather,
+		search:    deps.Search,
+		scheduler: deps.Scheduler,
+		admins:    make(map[int64]bool, len(deps.AdminIDs)),
+	}
+	for _, id := range deps.AdminIDs {
+		h.admins[id] = true
+	}
+	h.router = h.newRouter()
+	return h
+}
+
+func (h *Handler) Update(parent context.Context, update tgbotapi.Update) {
+	// Нажатие inline-кнопки приходит без Message, отправитель и чат берутся из CallbackQuery
+	var from *tgbotapi.User
+	var chatID int64
+	switch {
+	case update.Message != nil:
+		from, chatID = update.Message.From, update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		from, chatID = update.CallbackQuery.From, update.CallbackQuery.Message.Chat.ID
+	default:
+		return
+	}
+
+	monitoring.UpdateUniqueUsers(from.ID)
 
 	// Получаем данные пользователя из хранилища
-	userService := services.Global()
-	user, err := userService.GetUser(update.Message.From.ID)
+	user, err := h.services.GetUser(parent, from.ID)
 	if err != nil {
-		log.Warn().Err(err).Int64("id", update.Message.From.ID).Str("user", update.Message.From.FirstName).Msg("Ошибка при получении данных пользователя из хранилища")
+		log.Warn().Err(err).Int64("id", from.ID).Str("user", from.FirstName).Msg("Ошибка при получении данных пользователя из хранилища")
 	}
 
 	// Если пользователь новый, инициализируем его
 	if user == nil {
-		user = models.NewUser(update.Message.From.ID, update.Message.Chat.ID, update.Message.From.FirstName, string(StateNone))
+		user = models.NewUser(from.ID, chatID, from.FirstName, string(StateNone))
 		log.Info().Int64("id", user.TgID).Msgf("Новый пользователь %s!", user.Name)
 	}
 
-	ctx := &Context{
-		user: user,
-		text: update.Message.Text,
+	// Пользователь снова пишет боту после блокировки - возвращаем его уведомления
+	if !user.Active {
+		user.Activate()
+		log.Info().Int64("id", user.TgID).Msg("Пользователь снова активен")
+		if err := h.scheduler.ScheduleUserSubscriptions(parent, user.TgID); err != nil {
+			log.Error().Err(err).Int64("id", user.TgID).Msg("Ошибка восстановления уведомлений пользователя")
+		}
 	}
 
-	log.Info().Int64("id", user.TgID).Str("user", user.Name).Str("username", update.Message.From.UserName).Str("city", user.City).Str("state", user.State).Bool("sticker", user.Sticker).
-		Msgf("Пользователь отправил сообщение: %s", update.Message.Text)
+	ctx := &Context{Context: parent, user: user, username: from.UserName}
+	if update.Message != nil {
+		ctx.text = update.Message.Text
+		ctx.location = update.Message.Location
+	} else {
+		ctx.callback = update.CallbackQuery
+	}
 
-	processMessage(ctx)
+	h.processMessage(ctx)
 
 	// Сохраняем обновленные данные пользователя
-	if err = userService.SaveUser(user); err != nil {
+	if err = h.services.SaveUser(ctx, user); err != nil {
 		monitoring.BotErrorsTotal.Inc()
 		log.Error().Err(err).Int64("id", user.TgID).Msg("Ошибка при сохранении пользователя в хранилище")
 	}
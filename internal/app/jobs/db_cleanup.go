@@ -1,19 +1,25 @@
 package jobs
 
 import (
+	"context"
 	"time"
 	"weather-bot/internal/app/monitoring"
-	"weather-bot/internal/app/services"
 
 	"github.com/rs/zerolog/log"
 )
 
-func StartCleanupTask() {
-	ticker := time.NewTicker(6 * time.Hour) // Очистка каждые 6 часов
+// cleanup удаляет устаревшие прогнозы из БД до отмены ctx
+func (r *Runner) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(r.deps.Config.CleanupInterval)
 	defer ticker.Stop()
 	for {
-		<-ticker.C
-		err := services.Global().CleanupOldWeatherData()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := r.deps.Services.CleanupOldWeatherData(ctx)
 		if err != nil {
 			monitoring.DBErrorsTotal.Inc()
 			log.Error().Err(err).Msg("Ошибка очистки данных")
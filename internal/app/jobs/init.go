@@ -1,26 +1,90 @@
 package jobs
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
 	"weather-bot/internal/app/monitoring"
+	"weather-bot/internal/app/reply"
+	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/weather"
+	"weather-bot/internal/config"
 
 	"github.com/rs/zerolog/log"
 )
 
-func Init() error {
-	log.Info().Msg("Инициализация фоновых задач...")
+// Deps - зависимости фоновых задач
+type Deps struct {
+	Services  *services.ServiceContainer
+	Weather   *weather.Client
+	Reply     *reply.Replier
+	Scheduler *Scheduler
+	// Интервалы и повторы фоновых задач
+	Config config.Jobs
+}
 
-	// Запуск HealthChecker
-	go StartRedisHealthChecker()
+// Runner запускает фоновые задачи и дожидается их остановки
+type Runner struct {
+	deps    Deps
+	workers sync.WaitGroup
+}
+
+func NewRunner(deps Deps) *Runner {
+	return &Runner{deps: deps}
+}
+
+// Start запускает фоновые задачи, они работают до отмены ctx
+func (r *Runner) Start(ctx context.Context) error {
+	log.Info().Msg("Инициализация фоновых задач...")
 
 	// Добавляем задачу обновления прогноза в Redis (если её нет)
-	if err := ScheduleWeatherUpdate(); err != nil {
+	if err := r.deps.Scheduler.ScheduleWeatherUpdate(ctx); err != nil {
 		monitoring.RedisErrorsTotal.Inc()
 		log.Error().Err(err).Msg("Ошибка при установке задачи обновления погоды")
 		return err
 	}
 
-	go StartWeatherWorker()
-	go StartUserWorker()
-	go StartCleanupTask()
+	r.start(ctx, r.healthCheck)
+	r.start(ctx, NewWeatherWorker(r.deps).Run)
+	r.start(ctx, NewUserWorker(r.deps).Run)
+	r.start(ctx, r.cleanup)
 	return nil
 }
+
+// Wait дожидается остановки фоновых задач после отмены ctx из Start, но не дольше ctx
+func (r *Runner) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("не дождались остановки фоновых задач: %w", ctx.Err())
+	}
+}
+
+func (r *Runner) start(ctx context.Context, task func(context.Context)) {
+	r.workers.Add(1)
+	go func() {
+		defer r.workers.Done()
+		task(ctx)
+	}()
+}
+
+// sleep ждёт d и возвращает false, если за это время ctx отменили
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
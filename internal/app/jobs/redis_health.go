@@ -1,13 +1,21 @@
 package jobs
 
 import (
+	"context"
 	"time"
-	"weather-bot/internal/app/services"
 )
 
-func StartRedisHealthChecker() {
-	ticker := time.NewTicker(1 * time.Minute)
-	for range ticker.C {
-		services.Global().HealthCheck()
+// healthCheck проверяет доступность Redis до отмены ctx
+func (r *Runner) healthCheck(ctx context.Context) {
+	ticker := time.NewTicker(r.deps.Config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.deps.Services.HealthCheck(ctx)
+		}
 	}
 }
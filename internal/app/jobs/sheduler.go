@@ -1,31 +1,43 @@
 package jobs
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"time"
 	"weather-bot/internal/app/monitoring"
 	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/config"
+	"weather-bot/internal/models"
 
 	"github.com/rs/zerolog/log"
 )
 
-func ScheduleWeatherUpdate() error {
-	notificationService := services.Global()
-	// Удаляем задачу
-	err := notificationService.RemoveWeatherUpdate()
+// consumerName - имя воркера в группе Redis. Реплики бота различаются хостом и pid.
+var consumerName = func() string {
+	host, err := os.Hostname()
 	if err != nil {
-		monitoring.RedisErrorsTotal.Inc()
-		log.Error().Err(err).Msg("Ошибка удаления задачи обновления погоды из Redis")
+		host = "weather-bot"
 	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}()
+
+// Scheduler ставит в очереди уведомления подписок и обновление погоды
+type Scheduler struct {
+	services               *services.ServiceContainer
+	weatherRefreshInterval time.Duration
+}
 
-	// Вычисляем `executeAt` (00:01 следующего дня)
-	now := time.Now()
-	//executeAt := time.Date(now.Year(), now.Month(), now.Day(), 0, 1, 0, 0, now.Location()).Add(24 * time.Hour).Unix()
+func NewScheduler(svc *services.ServiceContainer, cfg config.Jobs) *Scheduler {
+	return &Scheduler{services: svc, weatherRefreshInterval: cfg.WeatherRefreshInterval}
+}
 
-	// тест на обновление погоды каждые 4 часа
-	executeAt := now.Add(4 * time.Hour).Unix()
+func (s *Scheduler) ScheduleWeatherUpdate(ctx context.Context) error {
+	executeAt := time.Now().Add(s.weatherRefreshInterval).Unix()
 
-	err = notificationService.ScheduleWeatherUpdate(executeAt)
+	// Задача одна, повторное планирование переносит её на новое время
+	err := s.services.Schedule(ctx, storage.QueueWeatherUpdates, storage.WeatherUpdateJobID, executeAt)
 	if err != nil {
 		monitoring.RedisErrorsTotal.Inc()
 		return fmt.Errorf("не удалось сохранить executeAt в Redis: %w", err)
@@ -35,26 +47,66 @@ func ScheduleWeatherUpdate() error {
 	return nil
 }
 
-func ScheduleUserUpdate(userID int64, notificationTime time.Time) error {
-	notificationService := services.Global()
-	// Удаляем задачу
-	err := notificationService.RemoveUserNotification(userID)
+// ScheduleUserUpdate ставит в очередь следующее уведомление подписки
+func (s *Scheduler) ScheduleUserUpdate(ctx context.Context, userID int64, sub models.Subscription) error {
+	next, err := NextNotificationTime(sub, time.Now())
 	if err != nil {
-		monitoring.RedisErrorsTotal.Inc()
-		log.Error().Err(err).Int64("userID", userID).Msg("Ошибка удаления задачи обновления погоды из Redis")
+		return err
 	}
 
-	// Вычисляем время следующего уведомления
-	now := time.Now()
-	executeAt := time.Date(now.Year(), now.Month(), now.Day(), notificationTime.Hour(), notificationTime.Minute(), 0, 0, now.Location()).Add(24 * time.Hour).Unix()
-
-	// Сохраняем новую задачу
-	err = notificationService.ScheduleUserNotification(userID, executeAt)
+	// Задача подписки одна, повторное планирование переносит её на новое время
+	err = s.services.Schedule(ctx, storage.QueueUserNotifications, storage.NotificationJobID(userID, sub.ID), next.Unix())
 	if err != nil {
 		monitoring.RedisErrorsTotal.Inc()
 		return fmt.Errorf("не удалось сохранить executeAt в Redis: %w", err)
 	}
 
-	log.Info().Msgf("Задача на обновление погоды для юзера %d запланирована на %s", userID, time.Unix(executeAt, 0).Format("15:04"))
+	log.Info().Str("subscription", sub.ID).Msgf("Задача на обновление погоды для юзера %d запланирована на %s", userID, next.Format("02.01 15:04"))
+	return nil
+}
+
+// ScheduleUserSubscriptions заново ставит в очередь все подписки пользователя,
+// например когда он снова начал пользоваться ботом после блокировки
+func (s *Scheduler) ScheduleUserSubscriptions(ctx context.Context, userID int64) error {
+	subs, err := s.services.GetSubscriptions(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить подписки: %w", err)
+	}
+	for _, sub := range subs {
+		if err := s.ScheduleUserUpdate(ctx, userID, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NextNotificationTime возвращает ближайшее время отправки строго после now
+// с учётом выбранных дней недели и отдельного времени для выходных
+func NextNotificationTime(sub models.Subscription, now time.Time) (time.Time, error) {
+	for i := 0; i <= 7; i++ {
+		day := now.AddDate(0, 0, i)
+		if !sub.Days.Has(day.Weekday()) {
+			continue
+		}
+
+		notificationTime, err := time.Parse("15:04", sub.TimeOn(day.Weekday()))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("неверное время подписки %s: %w", sub.TimeOn(day.Weekday()), err)
+		}
+
+		next := time.Date(day.Year(), day.Month(), day.Day(), notificationTime.Hour(), notificationTime.Minute(), 0, 0, now.Location())
+		if next.After(now) {
+			return next, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("не найден день отправки для подписки %s", sub.ID)
+}
+
+// UnscheduleUserUpdate убирает из очереди уведомление подписки
+func (s *Scheduler) UnscheduleUserUpdate(ctx context.Context, userID int64, subscriptionID string) error {
+	if err := s.services.Cancel(ctx, storage.QueueUserNotifications, storage.NotificationJobID(userID, subscriptionID)); err != nil {
+		monitoring.RedisErrorsTotal.Inc()
+		return fmt.Errorf("не удалось удалить уведомление из Redis: %w", err)
+	}
 	return nil
 }
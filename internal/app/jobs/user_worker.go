@@ -1,95 +1,260 @@
 package jobs
 
 import (
-	"strconv"
+	"context"
+	"errors"
+	"fmt"
 	"time"
 	"weather-bot/internal/app/monitoring"
 	"weather-bot/internal/app/reply"
-	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/storage"
 	"weather-bot/internal/app/weather"
+	"weather-bot/internal/models"
+	"weather-bot/pkg/telegram"
 
 	"github.com/rs/zerolog/log"
 )
 
-func StartUserWorker() {
-	time.Sleep(2 * time.Minute)
+// UserWorker отправляет уведомления подписок из очереди user_notifications
+type UserWorker struct {
+	Deps
+}
+
+func NewUserWorker(deps Deps) *UserWorker {
+	return &UserWorker{Deps: deps}
+}
+
+// Run обрабатывает очередь до отмены ctx, перезапуская обработку после сбоев
+func (w *UserWorker) Run(ctx context.Context) {
+	if !sleep(ctx, w.Config.StartDelay) {
+		return
+	}
 	log.Info().Msg("Воркер ProcessUserUpdate запущен...")
 
-	for {
-		ProcessUserUpdate()
+	for ctx.Err() == nil {
+		w.Process(ctx)
+		if ctx.Err() != nil {
+			break
+		}
 		log.Warn().Msg("ProcessUserUpdate завершился, перезапуск через минуту...")
-		time.Sleep(1 * time.Minute)
+		sleep(ctx, 1*time.Minute)
 	}
+	log.Info().Msg("Воркер ProcessUserUpdate остановлен")
 }
 
-func ProcessUserUpdate() {
-	notificationService := services.Global()
-	for {
+// Сколько задач забираем из очереди за раз и как часто проверяем её, если задач нет
+const (
+	userJobsBatch    = 100
+	userPollInterval = time.Second
+)
+
+// Уведомление подписки отправляется не чаще раза в день, даже если задачу выполнили две реплики
+// или воркер упал между отправкой и подтверждением
+const sentKeyTTL = 48 * time.Hour
+
+// sentKey - ключ идемпотентности на день, на который задачу запланировали изначально, по местному
+// времени города. Повтор после полуночи остаётся тем же уведомлением.
+func sentKey(job storage.Job, forecast *models.ProcessedForecast) string {
+	scheduledFor := job.ScheduledFor
+	if scheduledFor == 0 {
+		scheduledFor = job.ExecuteAt
+	}
+	return "sent:" + job.ID + ":" + weather.LocalDate(forecast, time.Unix(scheduledFor, 0))
+}
+
+// Process обрабатывает очередь уведомлений до отмены ctx
+func (w *UserWorker) Process(ctx context.Context) {
+	notificationService := w.Services
+	for ctx.Err() == nil {
 		if !notificationService.IsHealthy() {
 			log.Warn().Msg("Redis недоступен, горутина ProcessUserUpdate уходит в спячку на час")
-			time.Sleep(1 * time.Hour)
+			sleep(ctx, 1*time.Hour)
 			continue
 		}
 
-		// Читаем задачу из `user_notifications`
-		streams, err := notificationService.GetScheduleUserNotifications()
-
+		// Забираем наступившие задачи из `user_notifications`
+		jobs, err := notificationService.Due(ctx, storage.QueueUserNotifications, consumerName, time.Now().Unix(), userJobsBatch)
 		if err != nil {
 			monitoring.RedisErrorsTotal.Inc()
-			log.Error().Err(err).Msg("Ошибка чтения уведомлений юзеров из Redis Stream")
-			time.Sleep(1 * time.Minute)
+			log.Error().Err(err).Msg("Ошибка чтения очереди уведомлений юзеров из Redis")
+			sleep(ctx, 1*time.Minute)
 			continue
 		}
 
-		monitoring.RedisQueueLength.Set(float64(len(streams[0].Messages)))
-
-		// Обрабатываем задачу
-		for _, stream := range streams {
-			for _, message := range stream.Messages {
-				userID, err := strconv.ParseInt(message.Values["user_id"].(string), 10, 64)
-				if err != nil {
-					monitoring.NotificationsFailedTotal.Inc()
-					log.Error().Err(err).Int64("userID", userID).Msg("Ошибка парсинга")
-					continue
-				}
-				notifTime, _ := message.Values["executeAt"].(string)
-				executeAt, err := strconv.ParseInt(notifTime, 10, 64)
-				if err != nil {
-					monitoring.NotificationsFailedTotal.Inc()
-					log.Error().Err(err).Str("time", notifTime).Msg("Ошибка парсинга executeAt")
-					continue
-				}
-				// Если пора отправлять уведомление
-				if time.Now().Unix() >= executeAt {
-
-					log.Info().Msgf("Отправляем уведомление пользователю %d...", userID)
-
-					user, err := services.Global().GetUser(userID)
-					if err != nil {
-						monitoring.NotificationsFailedTotal.Inc()
-						log.Error().Err(err).Int64("userID", user.TgID).Msg("Ошибка при получении данных пользователя")
-						continue
-					}
-					forecast, err := weather.Get(user.CityID)
-					if err != nil {
-						monitoring.NotificationsFailedTotal.Inc()
-						log.Error().Err(err).Str("cityID", user.CityID).Msg("Ошибка при получении погоды")
-						continue
-					}
-
-					if err := reply.SendDailyWeather(user, forecast); err != nil {
-						monitoring.NotificationsFailedTotal.Inc()
-						continue
-					}
-
-					monitoring.NotificationsSentTotal.Inc()
-
-					notifTime := time.Unix(executeAt, 0)
-					// Планируем задачу на следующий день
-					ScheduleUserUpdate(userID, notifTime)
-				}
+		if length, err := notificationService.QueueLength(ctx, storage.QueueUserNotifications); err == nil {
+			monitoring.RedisQueueLength.Set(float64(length))
+		}
+
+		if len(jobs) == 0 {
+			sleep(ctx, userPollInterval)
+			continue
+		}
+
+		// Обрабатываем задачи
+		for _, job := range jobs {
+			// Необработанные задачи остаются в группе, после перезапуска их заберёт XAUTOCLAIM
+			if ctx.Err() != nil {
+				return
+			}
+			// Начатое уведомление доводим до конца, даже если бот останавливается
+			jobCtx := context.WithoutCancel(ctx)
+			if err := w.processNotification(jobCtx, job); err != nil {
+				monitoring.NotificationsFailedTotal.Inc()
+				w.retryNotification(jobCtx, job, err)
+				continue
 			}
+			if err := notificationService.Ack(jobCtx, storage.QueueUserNotifications, job); err != nil {
+				monitoring.RedisErrorsTotal.Inc()
+				log.Error().Err(err).Str("job", job.ID).Msg("Ошибка подтверждения задачи уведомления")
+			}
+		}
+	}
+
+}
+
+// NotificationRetryDelay - задержка перед повтором после attempt неудачных попыток: base, 2*base, 4*base...
+// но не больше maxDelay
+func NotificationRetryDelay(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base << attempt
+	if attempt >= 16 || delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// Неудавшееся уведомление повторяем с экспоненциальной задержкой, после последней попытки
+// переносим в очередь недоставленных и планируем на следующий день
+func (w *UserWorker) retryNotification(ctx context.Context, job storage.Job, cause error) {
+	notificationService := w.Services
+
+	// Неверный запрос повтор не исправит
+	retry := job.Attempt+1 < w.Config.NotificationMaxAttempts && !errors.Is(cause, telegram.ErrBadRequest)
+
+	if retry {
+		delay := NotificationRetryDelay(job.Attempt, w.Config.NotificationRetryBase, w.Config.NotificationRetryMax)
+		var rateLimited *telegram.ErrRateLimited
+		if errors.As(cause, &rateLimited) && rateLimited.RetryAfter > delay {
+			delay = rateLimited.RetryAfter
+		}
+		if err := notificationService.Retry(ctx, storage.QueueUserNotifications, job, time.Now().Add(delay).Unix()); err != nil {
+			monitoring.RedisErrorsTotal.Inc()
+			log.Error().Err(err).Str("job", job.ID).Msg("Ошибка повторного планирования уведомления")
+			return
 		}
+		monitoring.NotificationsRetriedTotal.Inc()
+		log.Warn().Err(cause).Str("job", job.ID).Int("attempt", job.Attempt+1).Msgf("Уведомление будет отправлено повторно через %s", delay)
+		return
 	}
 
+	if err := notificationService.DeadLetter(ctx, storage.QueueUserNotifications, job, cause.Error()); err != nil {
+		monitoring.RedisErrorsTotal.Inc()
+		log.Error().Err(err).Str("job", job.ID).Msg("Ошибка переноса уведомления в очередь недоставленных")
+		return
+	}
+	monitoring.NotificationsDeadLetteredTotal.Inc()
+	log.Error().Err(cause).Str("job", job.ID).Msg("Уведомление перенесено в очередь недоставленных")
+
+	// Следующий день не должен пропасть из-за сегодняшней ошибки
+	userID, subscriptionID, err := storage.ParseNotificationJobID(job.ID)
+	if err != nil {
+		return
+	}
+	sub, err := notificationService.GetSubscription(ctx, userID, subscriptionID)
+	if err != nil || sub == nil {
+		log.Warn().Err(err).Str("job", job.ID).Msg("Не удалось запланировать следующее уведомление недоставленной задачи")
+		return
+	}
+	if err := w.Scheduler.ScheduleUserUpdate(ctx, userID, *sub); err != nil {
+		log.Error().Err(err).Str("job", job.ID).Msg("Ошибка планирования следующего уведомления")
+	}
+}
+
+// processNotification отправляет прогноз по задаче и планирует следующую.
+// Ошибка означает, что задачу нужно повторить.
+func (w *UserWorker) processNotification(ctx context.Context, job storage.Job) error {
+	userID, subscriptionID, err := storage.ParseNotificationJobID(job.ID)
+	if err != nil {
+		log.Error().Err(err).Str("job", job.ID).Msg("Ошибка парсинга задачи уведомления")
+		return nil
+	}
+
+	log.Info().Str("subscription", subscriptionID).Msgf("Отправляем уведомление пользователю %d...", userID)
+
+	user, err := w.Services.GetUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("получение пользователя: %w", err)
+	}
+	if user == nil {
+		log.Warn().Int64("userID", userID).Msg("Пользователь уведомления не найден")
+		return nil
+	}
+	if !user.Active {
+		// Уведомления вернутся в очередь, когда пользователь снова напишет боту
+		log.Info().Int64("userID", userID).Msg("Пользователь неактивен, уведомление снято")
+		return nil
+	}
+
+	sub, err := w.userSubscription(ctx, user, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("получение подписки: %w", err)
+	}
+	if sub == nil {
+		// Подписку удалили, а задача осталась в очереди
+		return nil
+	}
+	// Подписка на основной город следует за городом пользователя
+	city := sub.ForUser(user)
+	if city.CityID == "" {
+		log.Warn().Int64("userID", userID).Str("subscription", sub.ID).Msg("У пользователя не выбран основной город, уведомление пропущено")
+		return nil
+	}
+
+	forecast, err := w.Weather.Get(ctx, city.CityID)
+	if err != nil {
+		return fmt.Errorf("получение погоды для %s: %w", city.CityID, err)
+	}
+
+	claimed, err := w.Services.ClaimOnce(ctx, sentKey(job, forecast), sentKeyTTL)
+	if err != nil {
+		monitoring.RedisErrorsTotal.Inc()
+		return fmt.Errorf("проверка повторной отправки: %w", err)
+	}
+
+	if claimed {
+		if err := w.Reply.SendDailyWeather(ctx, user, city.Title(), forecast); err != nil {
+			if errors.Is(err, reply.ErrUserUnreachable) {
+				// Уведомления пользователя уже сняты, повторять нечего
+				return nil
+			}
+			// Отправка не удалась, повтор не должен считаться дублем
+			if err := w.Services.ReleaseOnce(ctx, sentKey(job, forecast)); err != nil {
+				log.Error().Err(err).Str("job", job.ID).Msg("Ошибка снятия ключа идемпотентности")
+			}
+			return fmt.Errorf("отправка прогноза: %w", err)
+		}
+		monitoring.NotificationsSentTotal.Inc()
+	} else {
+		log.Warn().Str("job", job.ID).Msg("Уведомление за этот день уже отправлено, пропускаем")
+	}
+
+	// Планируем задачу на следующий подходящий день
+	if err := w.Scheduler.ScheduleUserUpdate(ctx, userID, *sub); err != nil {
+		log.Error().Err(err).Int64("userID", userID).Str("subscription", sub.ID).Msg("Ошибка планирования следующего уведомления")
+	}
+	return nil
+}
+
+// userSubscription находит подписку задачи. Задачи, поставленные до появления подписок,
+// не содержат subscription_id: для них создаётся подписка на основной город пользователя.
+func (w *UserWorker) userSubscription(ctx context.Context, user *models.User, subscriptionID string) (*models.Subscription, error) {
+	if subscriptionID != "" {
+		return w.Services.GetSubscription(ctx, user.TgID, subscriptionID)
+	}
+
+	sub := models.NewMainCitySubscription(time.Now().Format("15:04"), models.EveryDay)
+	if err := w.Services.SaveSubscription(ctx, user.TgID, sub); err != nil {
+		return nil, err
+	}
+	log.Info().Int64("userID", user.TgID).Str("subscription", sub.ID).Msg("Уведомление старого формата перенесено в подписку")
+	return &sub, nil
 }
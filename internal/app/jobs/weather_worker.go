@@ -1,86 +1,108 @@
 package jobs
 
 import (
-	"strconv"
+	"context"
 	"time"
 	"weather-bot/internal/app/monitoring"
-	"weather-bot/internal/app/services"
-	"weather-bot/internal/app/weather"
+	"weather-bot/internal/app/storage"
 
 	"github.com/rs/zerolog/log"
 )
 
-const retrysCount = 42
+const weatherPollInterval = 30 * time.Second
 
-func StartWeatherWorker() {
-	time.Sleep(2 * time.Minute)
+// WeatherWorker обновляет прогноз всех городов по задаче из очереди weather_updates
+type WeatherWorker struct {
+	Deps
+}
+
+func NewWeatherWorker(deps Deps) *WeatherWorker {
+	return &WeatherWorker{Deps: deps}
+}
+
+// Run обрабатывает очередь до отмены ctx, перезапуская обработку после сбоев
+func (w *WeatherWorker) Run(ctx context.Context) {
+	if !sleep(ctx, w.Config.StartDelay) {
+		return
+	}
 	log.Info().Msg("Воркер ProcessWeatherUpdates запущен...")
 
-	for {
-		ProcessWeatherUpdates()
+	for ctx.Err() == nil {
+		w.Process(ctx)
+		if ctx.Err() != nil {
+			break
+		}
 		log.Warn().Msg("ProcessWeatherUpdates завершился, перезапуск через минуту...")
-		time.Sleep(1 * time.Minute)
+		sleep(ctx, 1*time.Minute)
 	}
+	log.Info().Msg("Воркер ProcessWeatherUpdates остановлен")
 }
 
-func ProcessWeatherUpdates() {
-	notificationService := services.Global()
-	for {
+// Process выполняет задачи обновления погоды до отмены ctx
+func (w *WeatherWorker) Process(ctx context.Context) {
+	notificationService := w.Services
+	for ctx.Err() == nil {
 		if !notificationService.IsHealthy() {
 			log.Warn().Msg("Redis недоступен, горутина ProcessWeatherUpdates уходит в спячку на час")
-			time.Sleep(1 * time.Hour)
+			sleep(ctx, 1*time.Hour)
 			continue
 		}
-		// Читаем задачу из `weather_updates`
-		streams, err := notificationService.GetScheduleWeatherUpdate()
-
+		// Забираем задачу из `weather_updates`, если время выполнения уже пришло
+		jobs, err := notificationService.Due(ctx, storage.QueueWeatherUpdates, consumerName, time.Now().Unix(), 1)
 		if err != nil {
 			monitoring.RedisErrorsTotal.Inc()
-			log.Error().Err(err).Msg("Ошибка чтения задачи обновления погоды из Redis Stream")
-			time.Sleep(1 * time.Minute)
+			log.Error().Err(err).Msg("Ошибка чтения задачи обновления погоды из Redis")
+			sleep(ctx, 1*time.Minute)
 			continue
 		}
 
-		// Обрабатываем задачу
-		for _, stream := range streams {
-			for _, message := range stream.Messages {
-				executeAt, err := strconv.ParseInt(message.Values["executeAt"].(string), 10, 64)
-				if err != nil {
-					monitoring.WeatherUpdateFailed.Inc()
-					log.Error().Err(err).Int64("executeAt", executeAt).Msg("Ошибка парсинга executeAt")
-					continue
-				}
+		if len(jobs) == 0 {
+			sleep(ctx, weatherPollInterval)
+			continue
+		}
 
-				// Если время выполнения уже пришло
-				if time.Now().Unix() >= executeAt {
-					log.Info().Msg("Запуск обновления погоды...")
+		log.Info().Msg("Запуск обновления погоды...")
 
-					cityIDs, err := services.Global().GetCitiesIds()
-					if err != nil {
-						monitoring.WeatherUpdateFailed.Inc()
-						log.Error().Err(err).Msg("Ошибка получения городов из хранилищ")
-						continue
-					}
+		cityIDs, err := w.Services.GetCitiesIds(ctx)
+		if err != nil {
+			monitoring.WeatherUpdateFailed.Inc()
+			log.Error().Err(err).Msg("Ошибка получения городов из хранилищ")
+			// Неподтверждённую задачу заберёт воркер группы позже
+			continue
+		}
 
-					for range retrysCount {
-						err = weather.Update(cityIDs)
-						if err != nil {
-							monitoring.WeatherUpdateFailed.Inc()
-							log.Error().Err(err).Msg("Ошибка при обновлении погоды")
+		// Обновление с повторами идёт дольше, чем задача может оставаться неподтверждённой,
+		// поэтому сразу планируем следующее обновление и подтверждаем задачу, иначе её заберёт
+		// и запустит второй раз другая реплика. Прерванное остановкой бота обновление
+		// выполнится при следующем плановом.
+		if err := w.Scheduler.ScheduleWeatherUpdate(ctx); err != nil {
+			log.Error().Err(err).Msg("Ошибка планирования следующего обновления погоды")
+			continue
+		}
+		if err := notificationService.Ack(ctx, storage.QueueWeatherUpdates, jobs[0]); err != nil {
+			monitoring.RedisErrorsTotal.Inc()
+			log.Error().Err(err).Msg("Ошибка подтверждения задачи обновления погоды")
+		}
 
-							time.Sleep(10 * time.Minute)
-						} else {
-							log.Info().Msg("Погода успешно обновлена")
-							monitoring.WeatherUpdateTotal.Inc()
-							break
-						}
-					}
+		// Поставщики погоды переключаются сами, поэтому при сбое обоих коротко повторяем только для
+		// неудавшихся городов, а не обновлённые за эти попытки подождут следующего планового обновления
+		for attempt := 1; ; attempt++ {
+			cityIDs, err = w.Weather.Update(ctx, cityIDs)
+			if err == nil {
+				log.Info().Msg("Погода успешно обновлена")
+				monitoring.WeatherUpdateTotal.Inc()
+				break
+			}
+			monitoring.WeatherUpdateFailed.Inc()
+			log.Error().Err(err).Int("attempt", attempt).Msg("Ошибка при обновлении погоды")
 
-					// Планируем задачу на следующий день
-					ScheduleWeatherUpdate()
-				}
+			if attempt >= w.Config.WeatherRefreshRetries {
+				log.Warn().Int("cities", len(cityIDs)).Msg("Попытки обновления погоды исчерпаны, города обновятся при следующем плановом обновлении")
+				break
+			}
+			if !sleep(ctx, w.Config.WeatherRefreshRetryDelay) {
+				break
 			}
 		}
 	}
-
 }
@@ -1,6 +1,7 @@
 package loader
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"weather-bot/internal/app/services"
@@ -9,7 +10,7 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func LoadCities(filePath string, service services.CityService) error {
+func LoadCities(ctx context.Context, filePath string, service services.CityService) error {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return err
@@ -23,7 +24,11 @@ func LoadCities(filePath string, service services.CityService) error {
 
 	log.Info().Msgf("Загружено %d городов из файла", len(cities))
 
-	if err = service.LoadCities(cities); err != nil {
+	for i := range cities {
+		cities[i].Timezone = Timezone(cities[i])
+	}
+
+	if err = service.LoadCities(ctx, cities); err != nil {
 		return err
 	}
 
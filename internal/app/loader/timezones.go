@@ -0,0 +1,70 @@
+package loader
+
+import "weather-bot/internal/models"
+
+const defaultTimezone = "Europe/Moscow"
+
+var countryTimezones = map[string]string{
+	"BG": "Europe/Sofia",
+}
+
+// Часовые пояса регионов России (region_with_type из базы городов)
+var regionTimezones = map[string]string{
+	"Калининградская обл": "Europe/Kaliningrad",
+
+	"Волгоградская обл": "Europe/Volgograd",
+	"Кировская обл":     "Europe/Kirov",
+	"Астраханская обл":  "Europe/Astrakhan",
+	"Самарская обл":     "Europe/Samara",
+	"Удмуртская Респ":   "Europe/Samara",
+	"Саратовская обл":   "Europe/Saratov",
+	"Ульяновская обл":   "Europe/Ulyanovsk",
+
+	"Респ Башкортостан": "Asia/Yekaterinburg",
+	"Курганская обл":    "Asia/Yekaterinburg",
+	"Оренбургская обл":  "Asia/Yekaterinburg",
+	"Пермский край":     "Asia/Yekaterinburg",
+	"Свердловская обл":  "Asia/Yekaterinburg",
+	"Тюменская обл":     "Asia/Yekaterinburg",
+	"Ханты-Мансийский Автономный округ - Югра": "Asia/Yekaterinburg",
+	"Челябинская обл":                          "Asia/Yekaterinburg",
+	"Ямало-Ненецкий АО":                        "Asia/Yekaterinburg",
+
+	"Омская обл": "Asia/Omsk",
+
+	"Новосибирская обл":             "Asia/Novosibirsk",
+	"Алтайский край":                "Asia/Barnaul",
+	"Респ Алтай":                    "Asia/Barnaul",
+	"Кемеровская область - Кузбасс": "Asia/Novokuznetsk",
+	"Томская обл":                   "Asia/Tomsk",
+	"Красноярский край":             "Asia/Krasnoyarsk",
+	"Респ Тыва":                     "Asia/Krasnoyarsk",
+	"Респ Хакасия":                  "Asia/Krasnoyarsk",
+
+	"Иркутская обл": "Asia/Irkutsk",
+	"Респ Бурятия":  "Asia/Irkutsk",
+
+	"Забайкальский край": "Asia/Chita",
+	"Амурская обл":       "Asia/Yakutsk",
+	"Респ Саха (Якутия)": "Asia/Yakutsk",
+
+	"Приморский край":  "Asia/Vladivostok",
+	"Хабаровский край": "Asia/Vladivostok",
+	"Еврейская Аобл":   "Asia/Vladivostok",
+	"Магаданская обл":  "Asia/Magadan",
+	"Сахалинская обл":  "Asia/Sakhalin",
+	"Камчатский край":  "Asia/Kamchatka",
+	"Чукотский АО":     "Asia/Anadyr",
+}
+
+// Timezone определяет часовой пояс города по стране и региону.
+// Регионы, которых нет в таблице, живут по московскому времени.
+func Timezone(city models.City) string {
+	if tz, ok := countryTimezones[city.Country]; ok {
+		return tz
+	}
+	if tz, ok := regionTimezones[city.Region]; ok {
+		return tz
+	}
+	return defaultTimezone
+}
@@ -19,6 +19,22 @@ var (
 		Help: "Общее количество ошибок при обработке запросов от пользователей",
 	})
 
+	BotHandlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bot_handler_duration_seconds",
+		Help:    "Время обработки сообщения по команде, состоянию диалога или inline-кнопке",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route"})
+
+	BotUpdatesQueued = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "bot_updates_queued",
+		Help: "Сколько обновлений от пользователей ждут обработки",
+	})
+
+	BotUpdatesInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "bot_updates_in_flight",
+		Help: "Сколько обновлений от пользователей обрабатывается сейчас",
+	})
+
 	BotUniqueUsers = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "bot_active_users",
 		Help: "Количество уникальных пользователей",
@@ -35,6 +51,26 @@ var (
 		Help: "Количество ошибок при запросах к OpenWeather API",
 	})
 
+	WeatherProviderRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "weather_provider_requests_total",
+		Help: "Количество запросов к поставщикам погоды по результату",
+	}, []string{"provider", "status"})
+
+	WeatherProviderScore = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "weather_provider_success_score",
+		Help: "Скользящая доля успешных запросов к поставщику погоды",
+	}, []string{"provider"})
+
+	WeatherProviderLatency = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "weather_provider_latency_seconds",
+		Help: "Скользящее среднее время ответа поставщика погоды",
+	}, []string{"provider"})
+
+	WeatherProviderFailoverTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "weather_provider_failover_total",
+		Help: "Сколько раз пришлось обратиться к запасному поставщику погоды",
+	})
+
 	WeatherCacheHitsTotal = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "weather_cache_hits_total",
 		Help: "Количество успешных запросов погоды из кэша",
@@ -66,6 +102,16 @@ var (
 		Help: "Сколько уведомлений не удалось отправить",
 	})
 
+	NotificationsRetriedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notifications_retried_total",
+		Help: "Сколько раз уведомление было отложено для повторной отправки",
+	})
+
+	NotificationsDeadLetteredTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notifications_dead_lettered_total",
+		Help: "Сколько уведомлений перенесено в очередь недоставленных после всех попыток",
+	})
+
 	// Метрики Redis
 	RedisConnectionErrors = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "redis_connection_errors_total",
@@ -99,6 +145,25 @@ var (
 	})
 )
 
+// TelegramDispatcher - очередь исходящих сообщений в Telegram
+type TelegramDispatcher interface {
+	QueueDepth() int64
+	RateLimited() int64
+}
+
+// RegisterTelegramDispatcher экспортирует размер очереди исходящих сообщений и число ответов 429
+func RegisterTelegramDispatcher(d TelegramDispatcher) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "telegram_outbound_queue_depth",
+		Help: "Сколько сообщений ждут отправки в Telegram",
+	}, func() float64 { return float64(d.QueueDepth()) })
+
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "telegram_rate_limited_total",
+		Help: "Сколько раз Telegram ответил 429 Too Many Requests",
+	}, func() float64 { return float64(d.RateLimited()) })
+}
+
 var (
 	activeUsers = make(map[int64]struct{})
 	mu          sync.Mutex
@@ -1,52 +1,117 @@
 package reply
 
 import (
-	"strings"
+	"context"
+	"errors"
+	"fmt"
 	"time"
-	"weather-bot/internal/app/services"
 	"weather-bot/internal/app/weather"
 	"weather-bot/internal/models"
+	"weather-bot/pkg/telegram"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/rs/zerolog/log"
 )
 
 type Sender interface {
-	Message(chatID int64, text string, keyboard any) error
-	Sticker(chatID int64, stickerID string) error
+	Message(ctx context.Context, chatID int64, text string, keyboard any) error
+	Sticker(ctx context.Context, chatID int64, stickerID string) error
+	Edit(ctx context.Context, chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error
+	AnswerCallback(ctx context.Context, callbackID string, text string) error
 }
 
-var sender Sender
+// ErrUserUnreachable - пользователь заблокировал бота или удалил аккаунт, его уведомления уже сняты с очереди
+var ErrUserUnreachable = errors.New("пользователь недоступен")
 
-func Init(s Sender) {
-	sender = s
+// Users сохраняет изменения пользователя, которые выясняются при отправке: новый chat ID или блокировку бота
+type Users interface {
+	SaveUser(ctx context.Context, user *models.User) error
+	CancelUserNotifications(ctx context.Context, userID int64) error
 }
 
-func Send() Sender {
-	return sender
+// Replier отправляет сообщения пользователям. Если пользователь заблокировал бота или удалил
+// аккаунт, он помечается неактивным и его уведомления снимаются, какое бы сообщение ни не дошло.
+type Replier struct {
+	sender Sender
+	users  Users
 }
 
-func SendDailyWeather(user *models.User, forecast *models.ProcessedForecast) error {
-	today := time.Now().UTC().Format("2006-01-02")
+func New(sender Sender, users Users) *Replier {
+	return &Replier{sender: sender, users: users}
+}
 
-	msg := weather.FormatDailyForecast(user.City, forecast.FullDay[today])
-	err := Send().Message(user.ChatID, msg, nil)
-	if err != nil {
-		if strings.Contains(err.Error(), "Forbidden: bot was blocked by the user") {
-			log.Warn().Err(err).Msgf("reply - SendDailyWeather - Пользователь %d заблокировал бота", user.TgID)
-			if err := services.Global().NotificationService.RemoveUserNotification(user.TgID); err != nil {
-				log.Error().Err(err).Int64("user", user.TgID).Msg("reply - SendDailyWeather - Ошибка при удалении уведомления")
-			}
+func (r *Replier) Message(ctx context.Context, user *models.User, text string, keyboard any) error {
+	return r.deliver(ctx, user, func(chatID int64) error {
+		return r.sender.Message(ctx, chatID, text, keyboard)
+	})
+}
 
-		} else {
-			log.Error().Err(err).Int64("user", user.TgID).Msg("reply - SendDailyWeather - Ошибка при отправке сообщения")
+func (r *Replier) Sticker(ctx context.Context, user *models.User, stickerID string) error {
+	return r.deliver(ctx, user, func(chatID int64) error {
+		return r.sender.Sticker(ctx, chatID, stickerID)
+	})
+}
+
+func (r *Replier) Edit(ctx context.Context, user *models.User, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	return r.deliver(ctx, user, func(chatID int64) error {
+		return r.sender.Edit(ctx, chatID, messageID, text, keyboard)
+	})
+}
+
+// AnswerCallback не пишет в чат, поэтому статус пользователя по нему не меняется
+func (r *Replier) AnswerCallback(ctx context.Context, callbackID string, text string) error {
+	return r.sender.AnswerCallback(ctx, callbackID, text)
+}
+
+// deliver отправляет сообщение в чат пользователя. Перенесённый чат сохраняется и отправка повторяется,
+// недоступный пользователь помечается заблокированным, а ошибка оборачивается в ErrUserUnreachable.
+func (r *Replier) deliver(ctx context.Context, user *models.User, send func(chatID int64) error) error {
+	if !user.Active {
+		// Уже выяснили, что пользователь недоступен, например на предыдущем сообщении того же ответа
+		return ErrUserUnreachable
+	}
+
+	err := send(user.ChatID)
+
+	var migrated *telegram.ErrMigrated
+	if errors.As(err, &migrated) {
+		log.Info().Int64("user", user.TgID).Int64("chat", migrated.NewChatID).Msg("reply - deliver - Чат перенесён, обновляем chat ID")
+		user.ChatID = migrated.NewChatID
+		if err := r.users.SaveUser(ctx, user); err != nil {
+			log.Error().Err(err).Int64("user", user.TgID).Msg("reply - deliver - Ошибка при сохранении нового chat ID")
+		}
+		err = send(user.ChatID)
+	}
+
+	if errors.Is(err, telegram.ErrBlocked) || errors.Is(err, telegram.ErrUserDeactivated) || errors.Is(err, telegram.ErrChatNotFound) {
+		log.Warn().Err(err).Msgf("reply - deliver - Пользователь %d недоступен", user.TgID)
+		user.Block(time.Now())
+		if err := r.users.SaveUser(ctx, user); err != nil {
+			log.Error().Err(err).Int64("user", user.TgID).Msg("reply - deliver - Ошибка при сохранении статуса пользователя")
+		}
+		if err := r.users.CancelUserNotifications(ctx, user.TgID); err != nil {
+			log.Error().Err(err).Int64("user", user.TgID).Msg("reply - deliver - Ошибка при удалении уведомления")
 		}
+		return fmt.Errorf("%w: %w", ErrUserUnreachable, err)
+	}
+	return err
+}
+
+// SendDailyWeather отправляет прогноз на сегодня, city - подпись города в сообщении
+func (r *Replier) SendDailyWeather(ctx context.Context, user *models.User, city string, forecast *models.ProcessedForecast) error {
+	today := weather.Today(forecast)
 
+	msg := weather.FormatDailyForecast(city, forecast.FullDay[today])
+	if err := r.Message(ctx, user, msg, nil); err != nil {
+		if !errors.Is(err, ErrUserUnreachable) {
+			log.Error().Err(err).Int64("user", user.TgID).Msg("reply - SendDailyWeather - Ошибка при отправке сообщения")
+		}
 		return err
 	}
 
 	if user.Sticker {
 		sticker := weather.Sticker(forecast.FullDay[today])
-		err := Send().Sticker(user.ChatID, sticker)
+		err := r.Sticker(ctx, user, sticker)
 		if err != nil {
 			log.Error().Err(err).Int64("user", user.TgID).Str("sticker", sticker).Msg("reply - SendDailyWeather - Ошибка при отправке стикера")
 		}
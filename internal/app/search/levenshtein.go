@@ -1,9 +1,9 @@
 package search
 
 import (
+	"context"
 	"fmt"
 	"sort"
-	"weather-bot/internal/app/services"
 	"weather-bot/internal/models"
 
 	"github.com/rs/zerolog/log"
@@ -11,7 +11,7 @@ import (
 )
 
 // FindTop3ClosestCities находит 3 похожих города
-func findTop3ClosestCities(input string) ([]models.City, error) {
+func (s *Searcher) findTop3ClosestCities(ctx context.Context, input string) ([]models.City, error) {
 
 	type cityDistance struct {
 		city     string
@@ -20,7 +20,7 @@ func findTop3ClosestCities(input string) ([]models.City, error) {
 
 	var distances []cityDistance
 
-	cityNames, err := services.Global().GetCitiesNames()
+	cityNames, err := s.cities.GetCitiesNames(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения имен городов: %w", err)
 	}
@@ -46,7 +46,7 @@ func findTop3ClosestCities(input string) ([]models.City, error) {
 	var closestCities []models.City
 
 	for i := 0; i < 3 && i < len(distances); i++ {
-		citiesClose, err := services.Global().GetCities(distances[i].city)
+		citiesClose, err := s.cities.GetCities(ctx, distances[i].city)
 		if err != nil {
 			log.Debug().Err(err).Msgf("Ошибка при получении города %s", distances[i].city)
 			continue
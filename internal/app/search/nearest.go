@@ -0,0 +1,16 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"weather-bot/internal/models"
+)
+
+// NearestCity находит ближайший к точке город и расстояние до него в километрах
+func (s *Searcher) NearestCity(ctx context.Context, lat, lon float64) (*models.City, float64, error) {
+	city, distance, err := s.cities.GetNearestCity(ctx, lat, lon)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка поиска ближайшего города: %w", err)
+	}
+	return city, distance, nil
+}
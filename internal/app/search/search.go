@@ -1,25 +1,41 @@
 package search
 
 import (
+	"context"
 	"fmt"
-	"weather-bot/internal/app/services"
 	"weather-bot/internal/models"
 	"weather-bot/pkg/utils"
 
 	"github.com/rs/zerolog/log"
 )
 
+// Cities - справочник городов, в котором ищет Searcher
+type Cities interface {
+	GetCities(ctx context.Context, name string) ([]models.City, error)
+	GetCitiesNames(ctx context.Context) ([]string, error)
+	GetNearestCity(ctx context.Context, lat, lon float64) (*models.City, float64, error)
+}
+
+// Searcher ищет города по названию (с опечатками) и координатам
+type Searcher struct {
+	cities Cities
+}
+
+func NewSearcher(cities Cities) *Searcher {
+	return &Searcher{cities: cities}
+}
+
 // SearchCity ищет город в хранилищах и похожие на ввод
-func SearchCity(cityName string) ([]models.City, error) {
+func (s *Searcher) SearchCity(ctx context.Context, cityName string) ([]models.City, error) {
 	cityName = utils.NormalizeCityName(cityName)
 
-	cities, err := services.Global().GetCities(cityName)
+	cities, err := s.cities.GetCities(ctx, cityName)
 	if err != nil {
 		log.Debug().Err(err).Msg("Ошибка получения городов из хранилищ")
 	}
 
 	if cities == nil || len(cities) == 0 {
-		closestMatch, err := findTop3ClosestCities(cityName)
+		closestMatch, err := s.findTop3ClosestCities(ctx, cityName)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка получения похожих городов: %w", err)
 		}
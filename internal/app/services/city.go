@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"weather-bot/internal/app/monitoring"
 	"weather-bot/internal/app/storage"
@@ -14,15 +15,15 @@ type CityService struct {
 	Secondary storage.CityStorage
 }
 
-func (s *CityService) SaveCity(city models.City) error {
+func (s *CityService) SaveCity(ctx context.Context, city models.City) error {
 	var errP, errS error
-	errP = s.Primary.SaveCity(city)
+	errP = s.Primary.SaveCity(ctx, city)
 	if errP != nil {
 		monitoring.RedisErrorsTotal.Inc()
 		log.Warn().Err(errP).Msg("Ошибка записи города в Primary хранилище")
 	}
 
-	if errS = s.Secondary.SaveCity(city); errS != nil {
+	if errS = s.Secondary.SaveCity(ctx, city); errS != nil {
 		monitoring.DBErrorsTotal.Inc()
 		log.Warn().Err(errS).Msg("Ошибка записи города в Secondary хранилище")
 	}
@@ -33,8 +34,8 @@ func (s *CityService) SaveCity(city models.City) error {
 	return nil
 }
 
-func (s *CityService) GetCities(name string) ([]models.City, error) {
-	cities, errP := s.Primary.GetCities(name)
+func (s *CityService) GetCities(ctx context.Context, name string) ([]models.City, error) {
+	cities, errP := s.Primary.GetCities(ctx, name)
 	if errP == nil {
 		monitoring.RedisCacheHits.Inc()
 		return cities, nil
@@ -43,7 +44,7 @@ func (s *CityService) GetCities(name string) ([]models.City, error) {
 	monitoring.RedisErrorsTotal.Inc()
 	log.Error().Err(errP).Msg("Ошибка получения городов из Primary хранилища")
 
-	cities, errS := s.Secondary.GetCities(name)
+	cities, errS := s.Secondary.GetCities(ctx, name)
 	if errS == nil {
 		return cities, nil
 	}
@@ -52,9 +53,47 @@ func (s *CityService) GetCities(name string) ([]models.City, error) {
 	return nil, &DualStorageError{Primary: errP, Secondary: errS}
 }
 
-func (s *CityService) LoadCities(cities []models.City) error {
+func (s *CityService) GetCity(ctx context.Context, id int) (*models.City, error) {
+	city, errP := s.Primary.GetCity(ctx, id)
+	if errP == nil {
+		monitoring.RedisCacheHits.Inc()
+		return city, nil
+	}
+	monitoring.RedisCacheMisses.Inc()
+	monitoring.RedisErrorsTotal.Inc()
+	log.Warn().Err(errP).Int("cityID", id).Msg("Ошибка получения города из Primary хранилища")
+
+	city, errS := s.Secondary.GetCity(ctx, id)
+	if errS == nil {
+		return city, nil
+	}
+	monitoring.DBErrorsTotal.Inc()
+	log.Error().Err(errS).Int("cityID", id).Msg("Ошибка получения города из Secondary хранилища")
+	return nil, &DualStorageError{Primary: errP, Secondary: errS}
+}
+
+func (s *CityService) GetNearestCity(ctx context.Context, lat, lon float64) (*models.City, float64, error) {
+	city, distance, errP := s.Primary.GetNearestCity(ctx, lat, lon)
+	if errP == nil {
+		monitoring.RedisCacheHits.Inc()
+		return city, distance, nil
+	}
+	monitoring.RedisCacheMisses.Inc()
+	monitoring.RedisErrorsTotal.Inc()
+	log.Warn().Err(errP).Msg("Ошибка поиска ближайшего города в Primary хранилище")
+
+	city, distance, errS := s.Secondary.GetNearestCity(ctx, lat, lon)
+	if errS == nil {
+		return city, distance, nil
+	}
+	monitoring.DBErrorsTotal.Inc()
+	log.Error().Err(errS).Msg("Ошибка поиска ближайшего города в Secondary хранилище")
+	return nil, 0, &DualStorageError{Primary: errP, Secondary: errS}
+}
+
+func (s *CityService) LoadCities(ctx context.Context, cities []models.City) error {
 	for _, city := range cities {
-		if err := s.SaveCity(city); err != nil {
+		if err := s.SaveCity(ctx, city); err != nil {
 			return fmt.Errorf("failed to save city %s: %w", city.Name, err)
 		}
 
@@ -62,16 +101,16 @@ func (s *CityService) LoadCities(cities []models.City) error {
 	return nil
 }
 
-func (s *CityService) GetCitiesNames() ([]string, error) {
+func (s *CityService) GetCitiesNames(ctx context.Context) ([]string, error) {
 	return s.getFromStorage(func(storage storage.CityStorage) ([]string, error) {
-		return storage.GetCitiesNames()
+		return storage.GetCitiesNames(ctx)
 	}, "имён городов")
 
 }
 
-func (s *CityService) GetCitiesIds() ([]string, error) {
+func (s *CityService) GetCitiesIds(ctx context.Context) ([]string, error) {
 	return s.getFromStorage(func(storage storage.CityStorage) ([]string, error) {
-		return storage.GetCitiesIds()
+		return storage.GetCitiesIds(ctx)
 	}, "id городов")
 }
 
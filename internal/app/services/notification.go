@@ -1,38 +1,67 @@
 package services
 
 import (
+	"context"
+	"time"
 	"weather-bot/internal/app/storage"
-
-	"github.com/redis/go-redis/v9"
 )
 
 type NotificationService struct {
 	Primary storage.NotificationStorage
 }
 
-func (s *NotificationService) GetUserNotificationTime(userID int64) (string, error) {
-	return s.Primary.GetUserNotificationTime(userID)
+func (s *NotificationService) Schedule(ctx context.Context, queue, jobID string, executeAt int64) error {
+	return s.Primary.Schedule(ctx, queue, jobID, executeAt)
+}
+
+func (s *NotificationService) Due(ctx context.Context, queue, consumer string, now int64, limit int) ([]storage.Job, error) {
+	return s.Primary.Due(ctx, queue, consumer, now, limit)
+}
+
+func (s *NotificationService) Ack(ctx context.Context, queue string, job storage.Job) error {
+	return s.Primary.Ack(ctx, queue, job)
+}
+
+func (s *NotificationService) Cancel(ctx context.Context, queue, jobID string) error {
+	return s.Primary.Cancel(ctx, queue, jobID)
 }
 
-func (s *NotificationService) RemoveUserNotification(userID int64) error {
-	return s.Primary.RemoveUserNotification(userID)
+func (s *NotificationService) ScheduledAt(ctx context.Context, queue, jobID string) (int64, error) {
+	return s.Primary.ScheduledAt(ctx, queue, jobID)
 }
 
-func (s *NotificationService) ScheduleUserNotification(userID int64, executeAt int64) error {
-	return s.Primary.ScheduleUserNotification(userID, executeAt)
+func (s *NotificationService) QueueLength(ctx context.Context, queue string) (int64, error) {
+	return s.Primary.QueueLength(ctx, queue)
 }
 
-func (s *NotificationService) ScheduleWeatherUpdate(executeAt int64) error {
-	return s.Primary.ScheduleWeatherUpdate(executeAt)
+func (s *NotificationService) ClaimOnce(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.Primary.ClaimOnce(ctx, key, ttl)
 }
 
-func (s *NotificationService) RemoveWeatherUpdate() error {
-	return s.Primary.RemoveWeatherUpdate()
+func (s *NotificationService) ReleaseOnce(ctx context.Context, key string) error {
+	return s.Primary.ReleaseOnce(ctx, key)
 }
 
-func (s *NotificationService) GetScheduleWeatherUpdate() ([]redis.XStream, error) {
-	return s.Primary.GetScheduleWeatherUpdate()
+func (s *NotificationService) Retry(ctx context.Context, queue string, job storage.Job, executeAt int64) error {
+	return s.Primary.Retry(ctx, queue, job, executeAt)
 }
-func (s *NotificationService) GetScheduleUserNotifications() ([]redis.XStream, error) {
-	return s.Primary.GetScheduleUserNotifications()
+
+func (s *NotificationService) DeadLetter(ctx context.Context, queue string, job storage.Job, reason string) error {
+	return s.Primary.DeadLetter(ctx, queue, job, reason)
+}
+
+func (s *NotificationService) DeadLetters(ctx context.Context, before string, limit int) ([]storage.DeadLetter, error) {
+	return s.Primary.DeadLetters(ctx, before, limit)
+}
+
+func (s *NotificationService) GetDeadLetter(ctx context.Context, id string) (*storage.DeadLetter, error) {
+	return s.Primary.GetDeadLetter(ctx, id)
+}
+
+// ReplayDeadLetter возвращает недоставленную задачу в её очередь на немедленное выполнение
+func (s *NotificationService) ReplayDeadLetter(ctx context.Context, letter storage.DeadLetter, now int64) error {
+	if err := s.Primary.Schedule(ctx, letter.Queue, letter.JobID, now); err != nil {
+		return err
+	}
+	return s.Primary.RemoveDeadLetter(ctx, letter.ID)
 }
@@ -28,6 +28,13 @@ func InitUserService(primary storage.UserStorage, secondary storage.UserStorage)
 	}
 }
 
+func InitSubscriptionService(primary storage.SubscriptionStorage, secondary storage.SubscriptionStorage) SubscriptionService {
+	return SubscriptionService{
+		Primary:   primary,
+		Secondary: secondary,
+	}
+}
+
 func InitWeatherService(primary storage.WeatherStorage, secondary storage.WeatherStorage) WeatherService {
 	return WeatherService{
 		Primary:   primary,
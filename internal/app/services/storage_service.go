@@ -1,27 +1,28 @@
 package services
 
 import (
+	"context"
+	"time"
 	"weather-bot/internal/app/storage"
 	"weather-bot/internal/models"
-
-	"github.com/redis/go-redis/v9"
 )
 
-var globalStorage *ServiceContainer
-
+// ServiceContainer объединяет сервисы поверх основного (Redis) и резервного (Postgres) хранилищ
 type ServiceContainer struct {
 	CityService         CityService
 	UserService         UserService
+	SubscriptionService SubscriptionService
 	WeatherService      WeatherService
 	NotificationService NotificationService
 	Cache               storage.Cache
 	DB                  storage.Database
 }
 
-func Init(primary storage.Cache, secondary storage.Database) {
-	globalStorage = &ServiceContainer{
+func NewServiceContainer(primary storage.Cache, secondary storage.Database) *ServiceContainer {
+	return &ServiceContainer{
 		CityService:         InitCityService(primary, secondary),
 		UserService:         InitUserService(primary, secondary),
+		SubscriptionService: InitSubscriptionService(primary, secondary),
 		WeatherService:      InitWeatherService(primary, secondary),
 		NotificationService: InitNotificationService(primary),
 		Cache:               primary,
@@ -29,81 +30,159 @@ func Init(primary storage.Cache, secondary storage.Database) {
 	}
 }
 
-func Global() *ServiceContainer {
-	return globalStorage
-}
-
-func (s *ServiceContainer) HealthCheck() {
-	s.Cache.HealthCheck()
+func (s *ServiceContainer) HealthCheck(ctx context.Context) {
+	s.Cache.HealthCheck(ctx)
 }
 
 func (s *ServiceContainer) IsHealthy() bool {
 	return s.Cache.IsHealthy()
 }
 
-func (s *ServiceContainer) CleanupOldWeatherData() error {
-	return s.DB.CleanupOldWeatherData()
+func (s *ServiceContainer) CleanupOldWeatherData(ctx context.Context) error {
+	return s.DB.CleanupOldWeatherData(ctx)
+}
+
+func (s *ServiceContainer) SaveCity(ctx context.Context, city models.City) error {
+	return s.CityService.SaveCity(ctx, city)
+}
+
+func (s *ServiceContainer) GetCities(ctx context.Context, city string) ([]models.City, error) {
+	return s.CityService.GetCities(ctx, city)
+}
+
+func (s *ServiceContainer) GetCity(ctx context.Context, id int) (*models.City, error) {
+	return s.CityService.GetCity(ctx, id)
+}
+
+func (s *ServiceContainer) GetNearestCity(ctx context.Context, lat, lon float64) (*models.City, float64, error) {
+	return s.CityService.GetNearestCity(ctx, lat, lon)
+}
+
+func (s *ServiceContainer) GetCitiesNames(ctx context.Context) ([]string, error) {
+	return s.CityService.GetCitiesNames(ctx)
+
+}
+
+func (s *ServiceContainer) GetCitiesIds(ctx context.Context) ([]string, error) {
+	return s.CityService.GetCitiesIds(ctx)
+}
+
+func (s *ServiceContainer) LoadCities(ctx context.Context, cities []models.City) {
+	s.CityService.LoadCities(ctx, cities)
+}
+
+func (s *ServiceContainer) Schedule(ctx context.Context, queue, jobID string, executeAt int64) error {
+	return s.NotificationService.Schedule(ctx, queue, jobID, executeAt)
 }
 
-func (s *ServiceContainer) SaveCity(city models.City) error {
-	return s.CityService.SaveCity(city)
+func (s *ServiceContainer) Due(ctx context.Context, queue, consumer string, now int64, limit int) ([]storage.Job, error) {
+	return s.NotificationService.Due(ctx, queue, consumer, now, limit)
 }
 
-func (s *ServiceContainer) GetCities(city string) ([]models.City, error) {
-	return s.CityService.GetCities(city)
+func (s *ServiceContainer) Ack(ctx context.Context, queue string, job storage.Job) error {
+	return s.NotificationService.Ack(ctx, queue, job)
 }
 
-func (s *ServiceContainer) GetCitiesNames() ([]string, error) {
-	return s.CityService.GetCitiesNames()
+func (s *ServiceContainer) Cancel(ctx context.Context, queue, jobID string) error {
+	return s.NotificationService.Cancel(ctx, queue, jobID)
+}
 
+func (s *ServiceContainer) ScheduledAt(ctx context.Context, queue, jobID string) (int64, error) {
+	return s.NotificationService.ScheduledAt(ctx, queue, jobID)
 }
 
-func (s *ServiceContainer) GetCitiesIds() ([]string, error) {
-	return s.CityService.GetCitiesIds()
+func (s *ServiceContainer) QueueLength(ctx context.Context, queue string) (int64, error) {
+	return s.NotificationService.QueueLength(ctx, queue)
 }
 
-func (s *ServiceContainer) LoadCities(cities []models.City) {
-	s.CityService.LoadCities(cities)
+func (s *ServiceContainer) ClaimOnce(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.NotificationService.ClaimOnce(ctx, key, ttl)
 }
 
-func (s *ServiceContainer) GetUserNotificationTime(userID int64) (string, error) {
-	return s.NotificationService.GetUserNotificationTime(userID)
+func (s *ServiceContainer) ReleaseOnce(ctx context.Context, key string) error {
+	return s.NotificationService.ReleaseOnce(ctx, key)
 }
-func (s *ServiceContainer) RemoveUserNotification(userID int64) error {
-	return s.NotificationService.RemoveUserNotification(userID)
+
+func (s *ServiceContainer) Retry(ctx context.Context, queue string, job storage.Job, executeAt int64) error {
+	return s.NotificationService.Retry(ctx, queue, job, executeAt)
 }
 
-func (s *ServiceContainer) ScheduleUserNotification(userID int64, executeAt int64) error {
-	return s.NotificationService.ScheduleUserNotification(userID, executeAt)
+func (s *ServiceContainer) DeadLetter(ctx context.Context, queue string, job storage.Job, reason string) error {
+	return s.NotificationService.DeadLetter(ctx, queue, job, reason)
 }
 
-func (s *ServiceContainer) ScheduleWeatherUpdate(executeAt int64) error {
-	return s.NotificationService.ScheduleWeatherUpdate(executeAt)
+func (s *ServiceContainer) DeadLetters(ctx context.Context, before string, limit int) ([]storage.DeadLetter, error) {
+	return s.NotificationService.DeadLetters(ctx, before, limit)
 }
 
-func (s *ServiceContainer) RemoveWeatherUpdate() error {
-	return s.NotificationService.RemoveWeatherUpdate()
+func (s *ServiceContainer) GetDeadLetter(ctx context.Context, id string) (*storage.DeadLetter, error) {
+	return s.NotificationService.GetDeadLetter(ctx, id)
 }
 
-func (s *ServiceContainer) GetScheduleWeatherUpdate() ([]redis.XStream, error) {
-	return s.NotificationService.GetScheduleWeatherUpdate()
+func (s *ServiceContainer) ReplayDeadLetter(ctx context.Context, letter storage.DeadLetter, now int64) error {
+	return s.NotificationService.ReplayDeadLetter(ctx, letter, now)
 }
-func (s *ServiceContainer) GetScheduleUserNotifications() ([]redis.XStream, error) {
-	return s.NotificationService.GetScheduleUserNotifications()
+
+// CancelUserNotifications снимает с очереди уведомления всех подписок пользователя
+func (s *ServiceContainer) CancelUserNotifications(ctx context.Context, userID int64) error {
+	subs, err := s.SubscriptionService.GetSubscriptions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Пустой ID - уведомление, заведённое до появления подписок
+	ids := []string{""}
+	for _, sub := range subs {
+		ids = append(ids, sub.ID)
+	}
+	for _, id := range ids {
+		if err := s.NotificationService.Cancel(ctx, storage.QueueUserNotifications, storage.NotificationJobID(userID, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ServiceContainer) SaveUser(ctx context.Context, user *models.User) error {
+	return s.UserService.SaveUser(ctx, user)
+}
+
+func (s *ServiceContainer) GetUser(ctx context.Context, id int64) (*models.User, error) {
+	return s.UserService.GetUser(ctx, id)
+}
+
+func (s *ServiceContainer) SaveUserCity(ctx context.Context, userID int64, city models.UserCity) error {
+	return s.UserService.SaveUserCity(ctx, userID, city)
+}
+
+func (s *ServiceContainer) GetUserCities(ctx context.Context, userID int64) ([]models.UserCity, error) {
+	return s.UserService.GetUserCities(ctx, userID)
+}
+
+func (s *ServiceContainer) RemoveUserCity(ctx context.Context, userID int64, name string) error {
+	return s.UserService.RemoveUserCity(ctx, userID, name)
+}
+
+func (s *ServiceContainer) SaveWeather(ctx context.Context, id int, forecast *models.ProcessedForecast) error {
+	return s.WeatherService.SaveWeather(ctx, id, forecast)
+}
+
+func (s *ServiceContainer) GetWeather(ctx context.Context, id int) (*models.ProcessedForecast, error) {
+	return s.WeatherService.GetWeather(ctx, id)
 }
 
-func (s *ServiceContainer) SaveUser(user *models.User) error {
-	return s.UserService.SaveUser(user)
+func (s *ServiceContainer) SaveSubscription(ctx context.Context, userID int64, sub models.Subscription) error {
+	return s.SubscriptionService.SaveSubscription(ctx, userID, sub)
 }
 
-func (s *ServiceContainer) GetUser(id int64) (*models.User, error) {
-	return s.UserService.GetUser(id)
+func (s *ServiceContainer) GetSubscriptions(ctx context.Context, userID int64) ([]models.Subscription, error) {
+	return s.SubscriptionService.GetSubscriptions(ctx, userID)
 }
 
-func (s *ServiceContainer) SaveWeather(id int, forecast *models.ProcessedForecast) error {
-	return s.WeatherService.SaveWeather(id, forecast)
+func (s *ServiceContainer) GetSubscription(ctx context.Context, userID int64, id string) (*models.Subscription, error) {
+	return s.SubscriptionService.GetSubscription(ctx, userID, id)
 }
 
-func (s *ServiceContainer) GetWeather(id int) (*models.ProcessedForecast, error) {
-	return s.WeatherService.GetWeather(id)
+func (s *ServiceContainer) RemoveSubscription(ctx context.Context, userID int64, id string) error {
+	return s.SubscriptionService.RemoveSubscription(ctx, userID, id)
 }
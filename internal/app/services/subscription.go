@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"weather-bot/internal/app/monitoring"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/models"
+
+	"github.com/rs/zerolog/log"
+)
+
+type SubscriptionService struct {
+	Primary   storage.SubscriptionStorage
+	Secondary storage.SubscriptionStorage
+}
+
+func (s *SubscriptionService) SaveSubscription(ctx context.Context, userID int64, sub models.Subscription) error {
+	var errP, errS error
+	errP = s.Primary.SaveSubscription(ctx, userID, sub)
+	if errP != nil {
+		monitoring.RedisErrorsTotal.Inc()
+		log.Warn().Err(errP).Msg("Ошибка записи подписки в Primary хранилище")
+	}
+
+	if errS = s.Secondary.SaveSubscription(ctx, userID, sub); errS != nil {
+		monitoring.DBErrorsTotal.Inc()
+		log.Warn().Err(errS).Msg("Ошибка записи подписки в Secondary хранилище")
+	}
+
+	if errP != nil && errS != nil {
+		return &DualStorageError{Primary: errP, Secondary: errS}
+	}
+
+	return nil
+}
+
+func (s *SubscriptionService) GetSubscriptions(ctx context.Context, userID int64) ([]models.Subscription, error) {
+	subs, errP := s.Primary.GetSubscriptions(ctx, userID)
+	if errP == nil {
+		monitoring.RedisCacheHits.Inc()
+		return subs, nil
+	}
+	monitoring.RedisCacheMisses.Inc()
+	monitoring.RedisErrorsTotal.Inc()
+	log.Warn().Err(errP).Msg("Ошибка чтения подписок из Primary хранилища")
+
+	subs, errS := s.Secondary.GetSubscriptions(ctx, userID)
+	if errS == nil {
+		return subs, nil
+	}
+	monitoring.DBErrorsTotal.Inc()
+	log.Warn().Err(errS).Msg("Ошибка чтения подписок из Secondary хранилища")
+
+	return nil, &DualStorageError{Primary: errP, Secondary: errS}
+}
+
+// GetSubscription возвращает подписку по ID или nil, если её уже удалили
+func (s *SubscriptionService) GetSubscription(ctx context.Context, userID int64, id string) (*models.Subscription, error) {
+	subs, err := s.GetSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		if sub.ID == id {
+			return &sub, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *SubscriptionService) RemoveSubscription(ctx context.Context, userID int64, id string) error {
+	var errP, errS error
+	errP = s.Primary.RemoveSubscription(ctx, userID, id)
+	if errP != nil {
+		monitoring.RedisErrorsTotal.Inc()
+		log.Warn().Err(errP).Msg("Ошибка удаления подписки из Primary хранилища")
+	}
+
+	if errS = s.Secondary.RemoveSubscription(ctx, userID, id); errS != nil {
+		monitoring.DBErrorsTotal.Inc()
+		log.Warn().Err(errS).Msg("Ошибка удаления подписки из Secondary хранилища")
+	}
+
+	if errP != nil && errS != nil {
+		return &DualStorageError{Primary: errP, Secondary: errS}
+	}
+
+	return nil
+}
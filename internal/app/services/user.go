@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"weather-bot/internal/app/monitoring"
 	"weather-bot/internal/app/storage"
 	"weather-bot/internal/models"
@@ -13,15 +14,15 @@ type UserService struct {
 	Secondary storage.UserStorage
 }
 
-func (s *UserService) SaveUser(user *models.User) error {
+func (s *UserService) SaveUser(ctx context.Context, user *models.User) error {
 	var errP, errS error
-	errP = s.Primary.SaveUser(user)
+	errP = s.Primary.SaveUser(ctx, user)
 	if errP != nil {
 		monitoring.RedisErrorsTotal.Inc()
 		log.Warn().Err(errP).Msg("Ошибка записи юзера в Primary хранилище")
 	}
 
-	if errS = s.Secondary.SaveUser(user); errS != nil {
+	if errS = s.Secondary.SaveUser(ctx, user); errS != nil {
 		monitoring.DBErrorsTotal.Inc()
 		log.Warn().Err(errS).Msg("Ошибка записи юзера в Secondary хранилище")
 
@@ -34,8 +35,8 @@ func (s *UserService) SaveUser(user *models.User) error {
 	return nil
 }
 
-func (s *UserService) GetUser(id int64) (*models.User, error) {
-	user, errP := s.Primary.GetUser(id)
+func (s *UserService) GetUser(ctx context.Context, id int64) (*models.User, error) {
+	user, errP := s.Primary.GetUser(ctx, id)
 	if errP == nil {
 		monitoring.RedisCacheHits.Inc()
 		return user, nil
@@ -44,7 +45,7 @@ func (s *UserService) GetUser(id int64) (*models.User, error) {
 	monitoring.RedisErrorsTotal.Inc()
 	log.Warn().Err(errP).Msg("Ошибка чтения юзера из Primary хранилища")
 
-	user, errS := s.Secondary.GetUser(id)
+	user, errS := s.Secondary.GetUser(ctx, id)
 	if errS == nil {
 		return user, nil
 	}
@@ -53,3 +54,63 @@ func (s *UserService) GetUser(id int64) (*models.User, error) {
 
 	return nil, &DualStorageError{Primary: errP, Secondary: errS}
 }
+
+func (s *UserService) SaveUserCity(ctx context.Context, userID int64, city models.UserCity) error {
+	var errP, errS error
+	errP = s.Primary.SaveUserCity(ctx, userID, city)
+	if errP != nil {
+		monitoring.RedisErrorsTotal.Inc()
+		log.Warn().Err(errP).Msg("Ошибка записи места юзера в Primary хранилище")
+	}
+
+	if errS = s.Secondary.SaveUserCity(ctx, userID, city); errS != nil {
+		monitoring.DBErrorsTotal.Inc()
+		log.Warn().Err(errS).Msg("Ошибка записи места юзера в Secondary хранилище")
+	}
+
+	if errP != nil && errS != nil {
+		return &DualStorageError{Primary: errP, Secondary: errS}
+	}
+
+	return nil
+}
+
+func (s *UserService) GetUserCities(ctx context.Context, userID int64) ([]models.UserCity, error) {
+	cities, errP := s.Primary.GetUserCities(ctx, userID)
+	if errP == nil {
+		monitoring.RedisCacheHits.Inc()
+		return cities, nil
+	}
+	monitoring.RedisCacheMisses.Inc()
+	monitoring.RedisErrorsTotal.Inc()
+	log.Warn().Err(errP).Msg("Ошибка чтения мест юзера из Primary хранилища")
+
+	cities, errS := s.Secondary.GetUserCities(ctx, userID)
+	if errS == nil {
+		return cities, nil
+	}
+	monitoring.DBErrorsTotal.Inc()
+	log.Warn().Err(errS).Msg("Ошибка чтения мест юзера из Secondary хранилища")
+
+	return nil, &DualStorageError{Primary: errP, Secondary: errS}
+}
+
+func (s *UserService) RemoveUserCity(ctx context.Context, userID int64, name string) error {
+	var errP, errS error
+	errP = s.Primary.RemoveUserCity(ctx, userID, name)
+	if errP != nil {
+		monitoring.RedisErrorsTotal.Inc()
+		log.Warn().Err(errP).Msg("Ошибка удаления места юзера из Primary хранилища")
+	}
+
+	if errS = s.Secondary.RemoveUserCity(ctx, userID, name); errS != nil {
+		monitoring.DBErrorsTotal.Inc()
+		log.Warn().Err(errS).Msg("Ошибка удаления места юзера из Secondary хранилища")
+	}
+
+	if errP != nil && errS != nil {
+		return &DualStorageError{Primary: errP, Secondary: errS}
+	}
+
+	return nil
+}
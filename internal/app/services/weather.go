@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"weather-bot/internal/app/monitoring"
 	"weather-bot/internal/app/storage"
 	"weather-bot/internal/models"
@@ -13,18 +14,18 @@ type WeatherService struct {
 	Secondary storage.WeatherStorage
 }
 
-func (s *WeatherService) SaveWeather(id int, forecast *models.ProcessedForecast) error {
+func (s *WeatherService) SaveWeather(ctx context.Context, id int, forecast *models.ProcessedForecast) error {
 	var errP, errS error
 
 	// Пытаемся сохранить в Primary хранилище
-	errP = s.Primary.SaveWeather(id, forecast)
+	errP = s.Primary.SaveWeather(ctx, id, forecast)
 	if errP != nil {
 		monitoring.RedisErrorsTotal.Inc()
 		log.Warn().Err(errP).Msg("Ошибка записи погоды в Primary хранилище")
 	}
 
 	// Пытаемся сохранить во Secondary хранилище
-	errS = s.Secondary.SaveWeather(id, forecast)
+	errS = s.Secondary.SaveWeather(ctx, id, forecast)
 	if errS != nil {
 		monitoring.DBErrorsTotal.Inc()
 		log.Warn().Err(errS).Msg("Ошибка записи погоды в Secondary хранилище")
@@ -39,8 +40,8 @@ func (s *WeatherService) SaveWeather(id int, forecast *models.ProcessedForecast)
 	return nil
 }
 
-func (s *WeatherService) GetWeather(id int) (*models.ProcessedForecast, error) {
-	weather, errP := s.Primary.GetWeather(id)
+func (s *WeatherService) GetWeather(ctx context.Context, id int) (*models.ProcessedForecast, error) {
+	weather, errP := s.Primary.GetWeather(ctx, id)
 	if errP == nil {
 		monitoring.RedisCacheHits.Inc()
 		return weather, nil
@@ -49,7 +50,7 @@ func (s *WeatherService) GetWeather(id int) (*models.ProcessedForecast, error) {
 	monitoring.RedisErrorsTotal.Inc()
 	log.Warn().Err(errP).Msg("Ошибка чтения погоды из Primary хранилища")
 
-	weather, errS := s.Secondary.GetWeather(id)
+	weather, errS := s.Secondary.GetWeather(ctx, id)
 	if errS == nil {
 		return weather, nil
 	}
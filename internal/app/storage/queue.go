@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Очереди отложенных задач
+const (
+	QueueUserNotifications = "user_notifications"
+	QueueWeatherUpdates    = "weather_updates"
+
+	// Уведомления, которые не удалось отправить за все попытки
+	QueueNotificationsDLQ = "notifications_dlq"
+)
+
+// Job - задача, выданная воркеру
+type Job struct {
+	ID        string // ID задачи, например NotificationJobID
+	ExecuteAt int64
+	// Время, на которое задачу запланировали изначально, повторы его не меняют
+	ScheduledFor int64
+	Delivery     string // ID доставки, по нему задача подтверждается
+	Attempt      int    // Сколько раз задача уже завершилась ошибкой
+}
+
+// DeadLetter - задача из очереди недоставленных
+type DeadLetter struct {
+	ID       string // ID записи в очереди недоставленных
+	Queue    string // Очередь, из которой пришла задача
+	JobID    string
+	Reason   string
+	Attempts int
+	FailedAt int64
+}
+
+// Обновление погоды всегда одно, повторное планирование переносит его
+const WeatherUpdateJobID = "weather_update"
+
+// NotificationJobID - ID задачи уведомления "<userID>:<subscriptionID>",
+// у каждой подписки в очереди не больше одной задачи
+func NotificationJobID(userID int64, subscriptionID string) string {
+	return fmt.Sprintf("%d:%s", userID, subscriptionID)
+}
+
+func ParseNotificationJobID(jobID string) (int64, string, error) {
+	user, subscriptionID, found := strings.Cut(jobID, ":")
+	if !found {
+		return 0, "", fmt.Errorf("неверный ID задачи уведомления: %s", jobID)
+	}
+	userID, err := strconv.ParseInt(user, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("неверный ID пользователя в задаче %s: %w", jobID, err)
+	}
+	return userID, subscriptionID, nil
+}
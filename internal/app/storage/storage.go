@@ -1,14 +1,15 @@
 package storage
 
 import (
+	"context"
+	"time"
 	"weather-bot/internal/models"
-
-	"github.com/redis/go-redis/v9"
 )
 
 type Cache interface {
 	CityStorage
 	UserStorage
+	SubscriptionStorage
 	WeatherStorage
 	NotificationStorage
 	HealthChecker
@@ -16,42 +17,70 @@ type Cache interface {
 type Database interface {
 	CityStorage
 	UserStorage
+	SubscriptionStorage
 	WeatherStorage
 	CleanupData
 }
 
+// Дальше этого расстояния ближайший город не ищем
+const NearestCityRadiusKm = 300
+
 type CityStorage interface {
-	SaveCity(models.City) error
-	GetCities(string) ([]models.City, error)
-	GetCitiesNames() ([]string, error)
-	GetCitiesIds() ([]string, error)
+	SaveCity(ctx context.Context, city models.City) error
+	GetCities(ctx context.Context, name string) ([]models.City, error)
+	GetCity(ctx context.Context, id int) (*models.City, error)
+	GetNearestCity(ctx context.Context, lat, lon float64) (*models.City, float64, error)
+	GetCitiesNames(ctx context.Context) ([]string, error)
+	GetCitiesIds(ctx context.Context) ([]string, error)
 }
 
 type UserStorage interface {
-	SaveUser(*models.User) error
-	GetUser(int64) (*models.User, error)
+	SaveUser(ctx context.Context, user *models.User) error
+	GetUser(ctx context.Context, userID int64) (*models.User, error)
+	SaveUserCity(ctx context.Context, userID int64, city models.UserCity) error
+	GetUserCities(ctx context.Context, userID int64) ([]models.UserCity, error)
+	RemoveUserCity(ctx context.Context, userID int64, name string) error
+}
+
+type SubscriptionStorage interface {
+	SaveSubscription(ctx context.Context, userID int64, sub models.Subscription) error
+	GetSubscriptions(ctx context.Context, userID int64) ([]models.Subscription, error)
+	RemoveSubscription(ctx context.Context, userID int64, id string) error
 }
 
 type WeatherStorage interface {
-	SaveWeather(int, *models.ProcessedForecast) error
-	GetWeather(int) (*models.ProcessedForecast, error)
+	SaveWeather(ctx context.Context, cityID int, forecast *models.ProcessedForecast) error
+	GetWeather(ctx context.Context, cityID int) (*models.ProcessedForecast, error)
 }
 
+// NotificationStorage - очередь отложенных задач: задача выполняется не раньше executeAt.
+// Due выдаёт наступившие задачи группе воркеров (несколько реплик бота), каждую - одному воркеру.
+// Задача, которую не подтвердили через Ack, повторно выдаётся другому воркеру.
+// ClaimOnce защищает от повторной отправки при такой повторной выдаче.
+// Retry откладывает неудавшуюся задачу и увеличивает Job.Attempt, DeadLetter переносит её
+// в очередь недоставленных (QueueNotificationsDLQ) с причиной ошибки.
 type NotificationStorage interface {
-	GetUserNotificationTime(int64) (string, error)
-	RemoveUserNotification(int64) error
-	ScheduleUserNotification(int64, int64) error
-	ScheduleWeatherUpdate(int64) error
-	RemoveWeatherUpdate() error
-	GetScheduleWeatherUpdate() ([]redis.XStream, error)
-	GetScheduleUserNotifications() ([]redis.XStream, error)
+	Schedule(ctx context.Context, queue, jobID string, executeAt int64) error
+	Due(ctx context.Context, queue, consumer string, now int64, limit int) ([]Job, error)
+	Ack(ctx context.Context, queue string, job Job) error
+	Cancel(ctx context.Context, queue, jobID string) error
+	ScheduledAt(ctx context.Context, queue, jobID string) (int64, error) // 0, если задача не запланирована
+	QueueLength(ctx context.Context, queue string) (int64, error)
+	ClaimOnce(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	ReleaseOnce(ctx context.Context, key string) error
+
+	Retry(ctx context.Context, queue string, job Job, executeAt int64) error
+	DeadLetter(ctx context.Context, queue string, job Job, reason string) error
+	DeadLetters(ctx context.Context, before string, limit int) ([]DeadLetter, error) // Сначала новые, старше записи before, если она задана
+	GetDeadLetter(ctx context.Context, id string) (*DeadLetter, error)               // nil, если записи нет
+	RemoveDeadLetter(ctx context.Context, id string) error
 }
 
 type HealthChecker interface {
-	HealthCheck()
+	HealthCheck(ctx context.Context)
 	IsHealthy() bool
 }
 
 type CleanupData interface {
-	CleanupOldWeatherData() error
+	CleanupOldWeatherData(ctx context.Context) error
 }
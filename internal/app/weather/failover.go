@@ -0,0 +1,149 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"weather-bot/internal/app/monitoring"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	scoreAlpha       = 0.2             // Вес последнего запроса в скользящей оценке
+	unhealthyScore   = 0.5             // Ниже этой доли успешных запросов поставщик считается нездоровым
+	unhealthyRetryIn = 5 * time.Minute // Как часто пробовать нездорового поставщика первым
+)
+
+// Failover опрашивает поставщиков по очереди, пока один из них не вернёт прогноз.
+// Нездоровые поставщики опускаются в конец очереди, пока не пройдёт unhealthyRetryIn.
+type Failover struct {
+	providers []*scoredProvider
+}
+
+// ProviderHealth - скользящая оценка поставщика
+type ProviderHealth struct {
+	Name        string
+	SuccessRate float64
+	Latency     time.Duration
+}
+
+type scoredProvider struct {
+	Provider
+
+	mu          sync.Mutex
+	successRate float64
+	latency     float64 // секунды
+	lastTry     time.Time
+}
+
+func NewFailover(providers ...Provider) *Failover {
+	f := &Failover{}
+	for _, p := range providers {
+		sp := &scoredProvider{Provider: p, successRate: 1}
+		f.providers = append(f.providers, sp)
+		monitoring.WeatherProviderScore.WithLabelValues(p.Name()).Set(1)
+	}
+	return f
+}
+
+func (f *Failover) Name() string {
+	names := make([]string, 0, len(f.providers))
+	for _, p := range f.providers {
+		names = append(names, p.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func (f *Failover) Forecast(ctx context.Context, loc Location) (*Forecast, error) {
+	var errs []error
+
+	for i, p := range f.ordered() {
+		if i > 0 {
+			monitoring.WeatherProviderFailoverTotal.Inc()
+			log.Warn().Str("provider", p.Name()).Int("cityID", loc.CityID).Msg("Переключаемся на запасного поставщика погоды")
+		}
+
+		start := time.Now()
+		forecast, err := p.Forecast(ctx, loc)
+		if ctx.Err() != nil {
+			// Запрос отменён (остановка бота), поставщик не виноват
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, ErrNoCoordinates) {
+			// Поставщик не умеет работать с этим городом, это не его сбой
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		p.record(err == nil, time.Since(start))
+
+		if err == nil {
+			return forecast, nil
+		}
+		log.Error().Err(err).Str("provider", p.Name()).Int("cityID", loc.CityID).Msg("Ошибка поставщика погоды")
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// Health возвращает текущие оценки поставщиков в порядке конфигурации
+func (f *Failover) Health() []ProviderHealth {
+	result := make([]ProviderHealth, 0, len(f.providers))
+	for _, p := range f.providers {
+		p.mu.Lock()
+		result = append(result, ProviderHealth{
+			Name:        p.Name(),
+			SuccessRate: p.successRate,
+			Latency:     time.Duration(p.latency * float64(time.Second)),
+		})
+		p.mu.Unlock()
+	}
+	return result
+}
+
+// ordered возвращает поставщиков: сначала здоровые (и те, кого пора перепроверить), затем остальные
+func (f *Failover) ordered() []*scoredProvider {
+	var healthy, unhealthy []*scoredProvider
+	for _, p := range f.providers {
+		if p.available() {
+			healthy = append(healthy, p)
+		} else {
+			unhealthy = append(unhealthy, p)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (p *scoredProvider) available() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.successRate >= unhealthyScore || time.Since(p.lastTry) >= unhealthyRetryIn
+}
+
+func (p *scoredProvider) record(success bool, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var value float64
+	status := "error"
+	if success {
+		value = 1
+		status = "success"
+	}
+
+	p.successRate = scoreAlpha*value + (1-scoreAlpha)*p.successRate
+	if p.latency == 0 {
+		p.latency = latency.Seconds()
+	} else {
+		p.latency = scoreAlpha*latency.Seconds() + (1-scoreAlpha)*p.latency
+	}
+	p.lastTry = time.Now()
+
+	monitoring.WeatherProviderRequestsTotal.WithLabelValues(p.Name(), status).Inc()
+	monitoring.WeatherProviderScore.WithLabelValues(p.Name()).Set(p.successRate)
+	monitoring.WeatherProviderLatency.WithLabelValues(p.Name()).Set(p.latency)
+}
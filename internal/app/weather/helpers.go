@@ -3,8 +3,6 @@ package weather
 import (
 	"time"
 	"weather-bot/internal/models"
-
-	"github.com/briandowns/openweathermap"
 )
 
 var weatherMapping = map[int]string{
@@ -66,23 +64,31 @@ func getDominantCondition(weatherList map[int]int) (string, int) {
 	return "Неизвестная погода", 0
 }
 
+// Часть дня: интервал часов по местному времени, границы включительно
+type dayPart struct {
+	from, to int
+}
+
+func (p dayPart) contains(hour int) bool {
+	return hour >= p.from && hour <= p.to
+}
+
 // Функция для вычисления средних значений
-func calculateSummary(data []openweathermap.Forecast5WeatherList, hours []int) models.WeatherSummary {
+func calculateSummary(data []ForecastItem, part dayPart, loc *time.Location) models.WeatherSummary {
 	var tempSum, feelsLikeSum, windSum float64
 	var count int
 	weatherCount := make(map[int]int)
 
 	for _, item := range data {
-		hour := time.Unix(int64(item.Dt), 0).UTC().Hour()
-		if contains(hours, hour) {
-			tempSum += item.Main.Temp
-			feelsLikeSum += item.Main.FeelsLike
-			windSum += item.Wind.Speed
+		hour := time.Unix(item.Time, 0).In(loc).Hour()
+		if part.contains(hour) {
+			tempSum += item.Temperature
+			feelsLikeSum += item.FeelsLike
+			windSum += item.WindSpeed
 			count++
 
 			// Подсчёт доминирующей погоды
-			weatherCondition := item.Weather[0].ID
-			weatherCount[weatherCondition]++
+			weatherCount[item.ConditionId]++
 		}
 	}
 
@@ -101,13 +107,3 @@ func calculateSummary(data []openweathermap.Forecast5WeatherList, hours []int) m
 		ConditionId: idCondition,
 	}
 }
-
-// Вспомогательная функция для проверки наличия элемента в слайсе
-func contains(slice []int, value int) bool {
-	for _, v := range slice {
-		if v == value {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,108 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const openMeteoURL = "https://api.open-meteo.com/v1/forecast"
+
+// Коды погоды WMO -> коды OpenWeatherMap, чтобы описания, эмодзи и стикеры работали одинаково
+var wmoToOWM = map[int]int{
+	0: 800, 1: 801, 2: 802, 3: 804,
+	45: 741, 48: 741,
+	51: 300, 53: 301, 55: 302, 56: 511, 57: 511,
+	61: 500, 63: 501, 65: 502, 66: 511, 67: 511,
+	71: 600, 73: 601, 75: 602, 77: 600,
+	80: 520, 81: 521, 82: 522,
+	85: 620, 86: 622,
+	95: 211, 96: 201, 99: 202,
+}
+
+// OpenMeteo получает почасовой прогноз по координатам и приводит его к шагу 3 часа
+type OpenMeteo struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewOpenMeteo() *OpenMeteo {
+	return &OpenMeteo{
+		baseURL: openMeteoURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *OpenMeteo) Name() string {
+	return ProviderOpenMeteo
+}
+
+type openMeteoResponse struct {
+	Hourly struct {
+		Time        []int64   `json:"time"`
+		Temperature []float64 `json:"temperature_2m"`
+		FeelsLike   []float64 `json:"apparent_temperature"`
+		WindSpeed   []float64 `json:"wind_speed_10m"`
+		WeatherCode []int     `json:"weather_code"`
+	} `json:"hourly"`
+}
+
+func (p *OpenMeteo) Forecast(ctx context.Context, loc Location) (*Forecast, error) {
+	if !loc.HasCoordinates() {
+		return nil, ErrNoCoordinates
+	}
+
+	params := url.Values{}
+	params.Set("latitude", strconv.FormatFloat(loc.Lat, 'f', 6, 64))
+	params.Set("longitude", strconv.FormatFloat(loc.Lon, 'f', 6, 64))
+	params.Set("hourly", "temperature_2m,apparent_temperature,wind_speed_10m,weather_code")
+	params.Set("wind_speed_unit", "ms")
+	params.Set("timeformat", "unixtime")
+	params.Set("forecast_days", "5")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса погоды: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса погоды: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Open-Meteo вернул статус %d", resp.StatusCode)
+	}
+
+	var data openMeteoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("ошибка разбора ответа Open-Meteo: %w", err)
+	}
+
+	h := data.Hourly
+	n := len(h.Time)
+	if n == 0 || len(h.Temperature) < n || len(h.FeelsLike) < n || len(h.WindSpeed) < n || len(h.WeatherCode) < n {
+		return nil, fmt.Errorf("Open-Meteo вернул неполный прогноз")
+	}
+
+	forecast := &Forecast{}
+	for i, ts := range h.Time {
+		// Оставляем шаг 3 часа, как у OpenWeatherMap
+		if time.Unix(ts, 0).UTC().Hour()%3 != 0 {
+			continue
+		}
+		forecast.Items = append(forecast.Items, ForecastItem{
+			Time:        ts,
+			Temperature: h.Temperature[i],
+			FeelsLike:   h.FeelsLike[i],
+			WindSpeed:   h.WindSpeed[i],
+			ConditionId: wmoToOWM[h.WeatherCode[i]],
+		})
+	}
+
+	return forecast, nil
+}
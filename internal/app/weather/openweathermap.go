@@ -0,0 +1,75 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/briandowns/openweathermap"
+)
+
+// OpenWeatherMap получает 5-дневный прогноз с шагом 3 часа
+type OpenWeatherMap struct {
+	apiKey string
+	client *http.Client
+}
+
+func NewOpenWeatherMap(apiKey string) *OpenWeatherMap {
+	return &OpenWeatherMap{
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *OpenWeatherMap) Name() string {
+	return ProviderOpenWeatherMap
+}
+
+// Библиотека OpenWeatherMap не принимает context, поэтому проверяем его только перед запросом
+func (p *OpenWeatherMap) Forecast(ctx context.Context, loc Location) (*Forecast, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Инициализируем клиент OpenWeather
+	owm, err := openweathermap.NewForecast("5", "C", "ru", p.apiKey, openweathermap.WithHttpClient(p.client))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка инициализации OpenWeather API: %w", err)
+	}
+
+	// Запрашиваем 5-дневный прогноз по координатам, если они известны, иначе по ID города
+	if loc.HasCoordinates() {
+		err = owm.DailyByCoordinates(&openweathermap.Coordinates{Latitude: loc.Lat, Longitude: loc.Lon}, 60)
+	} else {
+		err = owm.DailyByID(loc.CityID, 60)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса погоды: %w", err)
+	}
+
+	data, ok := owm.ForecastWeatherJson.(*openweathermap.Forecast5WeatherData)
+	if !ok {
+		return nil, fmt.Errorf("не удалось преобразовать ForecastWeatherJson в Forecast5WeatherData")
+	}
+	if len(data.List) == 0 {
+		return nil, fmt.Errorf("OpenWeather вернул пустой прогноз для города %d", loc.CityID)
+	}
+
+	forecast := &Forecast{Items: make([]ForecastItem, 0, len(data.List))}
+	for _, item := range data.List {
+		var conditionId int
+		if len(item.Weather) > 0 {
+			conditionId = item.Weather[0].ID
+		}
+		forecast.Items = append(forecast.Items, ForecastItem{
+			Time:        int64(item.Dt),
+			Temperature: item.Main.Temp,
+			FeelsLike:   item.Main.FeelsLike,
+			WindSpeed:   item.Wind.Speed,
+			ConditionId: conditionId,
+		})
+	}
+
+	return forecast, nil
+}
@@ -0,0 +1,58 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Поддерживаемые поставщики погоды
+const (
+	ProviderOpenWeatherMap = "openweathermap"
+	ProviderOpenMeteo      = "openmeteo"
+)
+
+var ErrNoCoordinates = errors.New("для запроса прогноза нужны координаты города")
+
+// Provider - источник прогноза погоды
+type Provider interface {
+	Name() string
+	Forecast(ctx context.Context, loc Location) (*Forecast, error)
+}
+
+// Location описывает точку, для которой запрашивается прогноз
+type Location struct {
+	CityID int
+	Lat    float64
+	Lon    float64
+}
+
+func (l Location) HasCoordinates() bool {
+	return l.Lat != 0 || l.Lon != 0
+}
+
+// Forecast - нормализованный прогноз, не зависящий от поставщика
+type Forecast struct {
+	Items []ForecastItem
+}
+
+// ForecastItem - прогноз на один интервал (шаг 3 часа)
+type ForecastItem struct {
+	Time        int64 // Unix-время начала интервала
+	Temperature float64
+	FeelsLike   float64
+	WindSpeed   float64 // м/с
+	ConditionId int     // Код погоды в нотации OpenWeatherMap
+}
+
+// NewProvider создаёт поставщика погоды по имени из конфига
+func NewProvider(name, apiKey string) (Provider, error) {
+	switch name {
+	case ProviderOpenWeatherMap, "":
+		return NewOpenWeatherMap(apiKey), nil
+	case ProviderOpenMeteo:
+		return NewOpenMeteo(), nil
+	default:
+		return nil, fmt.Errorf("неизвестный поставщик погоды: %s", name)
+	}
+}
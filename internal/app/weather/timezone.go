@@ -0,0 +1,32 @@
+package weather
+
+import (
+	"time"
+	_ "time/tzdata" // База часовых поясов внутри бинарника, не зависим от образа
+	"weather-bot/internal/models"
+
+	"github.com/rs/zerolog/log"
+)
+
+// loadLocation возвращает часовой пояс по имени IANA, при ошибке - UTC
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Warn().Err(err).Str("timezone", name).Msg("Неизвестный часовой пояс, используем UTC")
+		return time.UTC
+	}
+	return loc
+}
+
+// Today возвращает сегодняшнюю дату (ключ FullDay) по местному времени города из прогноза
+func Today(forecast *models.ProcessedForecast) string {
+	return LocalDate(forecast, time.Now())
+}
+
+// LocalDate возвращает дату момента t по местному времени города из прогноза
+func LocalDate(forecast *models.ProcessedForecast, t time.Time) string {
+	return t.In(loadLocation(forecast.Timezone)).Format("2006-01-02")
+}
@@ -1,44 +1,59 @@
 package weather
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"sort"
 	"strconv"
 	"time"
 	"weather-bot/internal/app/monitoring"
-	"weather-bot/internal/app/services"
 	"weather-bot/internal/models"
 
-	"github.com/briandowns/openweathermap"
 	"github.com/rs/zerolog/log"
 )
 
-// Временные промежутки
-var dayParts = map[string][]int{
-	"morning": {6, 9, 12},
-	"day":     {12, 15, 18},
-	"evening": {18, 21},
-	"night":   {0, 3, 6},
+// Временные промежутки (часы по местному времени города, границы включительно)
+var dayParts = map[string]dayPart{
+	"morning": {6, 12},
+	"day":     {12, 18},
+	"evening": {18, 23},
+	"night":   {0, 6},
 }
 
-func Get(cityID string) (*models.ProcessedForecast, error) {
+// Store - хранилище городов и прогнозов, которое нужно клиенту погоды
+type Store interface {
+	GetCity(ctx context.Context, id int) (*models.City, error)
+	GetWeather(ctx context.Context, id int) (*models.ProcessedForecast, error)
+	SaveWeather(ctx context.Context, id int, forecast *models.ProcessedForecast) error
+}
+
+// Client отдаёт прогноз из хранилища, а при его отсутствии запрашивает у поставщика и сохраняет
+type Client struct {
+	store    Store
+	provider Provider
+}
+
+func NewClient(store Store, provider Provider) *Client {
+	return &Client{store: store, provider: provider}
+}
+
+func (c *Client) Get(ctx context.Context, cityID string) (*models.ProcessedForecast, error) {
 	cityId, err := strconv.Atoi(cityID)
 	if err != nil {
 		return nil, fmt.Errorf("Неверный формат ID города: %v", err)
 	}
 	// Проверяем кеш
-	if forecast, err := services.Global().GetWeather(cityId); err == nil {
+	if forecast, err := c.store.GetWeather(ctx, cityId); err == nil {
 		monitoring.WeatherCacheHitsTotal.Inc()
 		return forecast, nil
 	}
 	log.Warn().Msg("не удалось получить forecast из хранилищ")
 
-	// Получаем прогноз из OpenWeather
-	processedForecast, err := GetNewWeather(cityId)
+	// Получаем прогноз у поставщика погоды
+	processedForecast, err := c.GetNewWeather(ctx, cityId)
 	if err != nil {
 		monitoring.WeatherAPIErrorsTotal.Inc()
-		return nil, fmt.Errorf("Не удалось получить погоду из OpenWeather: %v", err)
+		return nil, fmt.Errorf("Не удалось получить погоду у поставщика: %v", err)
 	}
 
 	log.Info().Str("cityID", cityID).Msg("Новая погода для города получена")
@@ -47,80 +62,89 @@ func Get(cityID string) (*models.ProcessedForecast, error) {
 
 }
 
-func Update(cityIDs []string) error {
+// Update обновляет погоду для всех городов и возвращает те, которые обновить не удалось
+func (c *Client) Update(ctx context.Context, cityIDs []string) ([]string, error) {
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
+	var failed []string
+	var lastErr error
 	for _, cityID := range cityIDs {
 		cityId, err := strconv.Atoi(cityID)
 		if err != nil {
-			return fmt.Errorf("Неверный формат ID города: %v", err)
+			log.Error().Err(err).Str("cityID", cityID).Msg("Неверный формат ID города")
+			continue
 		}
 
-		<-ticker.C
-		_, err = GetNewWeather(cityId)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		_, err = c.GetNewWeather(ctx, cityId)
 		if err != nil {
 			monitoring.WeatherAPIErrorsTotal.Inc()
-			return err
+			log.Error().Err(err).Int("cityID", cityId).Msg("Ошибка при обновлении погоды города")
+			failed = append(failed, cityID)
+			lastErr = err
 		}
 	}
-	return nil
+
+	if len(failed) > 0 {
+		return failed, fmt.Errorf("не удалось обновить погоду для %d из %d городов: %w", len(failed), len(cityIDs), lastErr)
+	}
+	return nil, nil
 }
 
-func GetNewWeather(cityID int) (*models.ProcessedForecast, error) {
+func (c *Client) GetNewWeather(ctx context.Context, cityID int) (*models.ProcessedForecast, error) {
+	// Координаты нужны поставщикам без поиска по ID, часовой пояс - чтобы делить прогноз на части дня по местному времени
+	location := Location{CityID: cityID}
+	var timezone string
+	city, err := c.store.GetCity(ctx, cityID)
+	if err != nil {
+		log.Warn().Err(err).Int("cityID", cityID).Msg("Не удалось получить город, прогноз будет запрошен по ID и посчитан в UTC")
+	} else {
+		location.Lat, location.Lon = city.Lat, city.Lon
+		timezone = city.Timezone
+	}
+
 	monitoring.WeatherAPIRequestsTotal.Inc()
-	// Запрашиваем прогноз из OpenWeather
-	forecastData, err := fetchWeatherFromAPI(cityID)
+	// Запрашиваем прогноз у поставщика
+	forecastData, err := c.provider.Forecast(ctx, location)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", c.provider.Name(), err)
 	}
 
 	// Обрабатываем прогноз сразу на 5 дней
-	processedForecast, err := processWeatherData(forecastData)
+	processedForecast, err := processWeatherData(forecastData, timezone)
 	if err != nil {
 		return nil, err
 	}
 
-	// Сохраняем (на 25 часов)
-	if err = services.Global().SaveWeather(cityID, processedForecast); err != nil {
+	// Сохраняем до истечения WEATHER_CACHE_TTL
+	if err = c.store.SaveWeather(ctx, cityID, processedForecast); err != nil {
 		log.Error().Err(err).Int("cityID", cityID).Msg("Error saving weather")
 	}
 
 	return processedForecast, nil
 }
 
-func fetchWeatherFromAPI(cityID int) (*openweathermap.ForecastWeatherData, error) {
-	// Инициализируем клиент OpenWeather
-	owm, err := openweathermap.NewForecast("5", "C", "ru", os.Getenv("OPENWEATHER_API_KEY"))
-	if err != nil {
-		return nil, fmt.Errorf("ошибка инициализации OpenWeather API: %w", err)
-	}
-
-	// Запрашиваем прогноз для города
-	err = owm.DailyByID(cityID, 60) // 5-дневный прогноз
-	if err != nil {
-		return nil, fmt.Errorf("ошибка запроса погоды: %w", err)
-	}
-
-	return owm, nil
-}
-
-func processWeatherData(forecast *openweathermap.ForecastWeatherData) (*models.ProcessedForecast, error) {
-	forecastData, ok := forecast.ForecastWeatherJson.(*openweathermap.Forecast5WeatherData)
-	if !ok {
-		return nil, fmt.Errorf("не удалось преобразовать ForecastWeatherJson в Forecast5WeatherData")
+func processWeatherData(forecast *Forecast, timezone string) (*models.ProcessedForecast, error) {
+	if forecast == nil || len(forecast.Items) == 0 {
+		return nil, fmt.Errorf("пустой прогноз погоды")
 	}
+	loc := loadLocation(timezone)
 
 	// Создаём пустые карты для хранения прогноза
 	fullDayForecasts := make(map[string]models.FullDayForecast)
 	var shortDayForecasts []models.ShortDayForecast
 
 	// Разбиваем прогноз по дням
-	daysData := make(map[string][]openweathermap.Forecast5WeatherList)
+	daysData := make(map[string][]ForecastItem)
 	var dates []string
 
-	for _, item := range forecastData.List {
-		itemDate := time.Unix(int64(item.Dt), 0).UTC().Format("2006-01-02")
+	for _, item := range forecast.Items {
+		itemDate := time.Unix(item.Time, 0).In(loc).Format("2006-01-02")
 		daysData[itemDate] = append(daysData[itemDate], item)
 	}
 
@@ -133,12 +157,12 @@ func processWeatherData(forecast *openweathermap.ForecastWeatherData) (*models.P
 	// Обрабатываем каждый день
 	for i, date := range dates {
 		// Полный прогноз на 1 день (без ночи)
-		dayForecast := processFullDayForecast(daysData[date])
+		dayForecast := processFullDayForecast(daysData[date], loc)
 
 		// Если есть следующий день — берём ночь оттуда
 		if i+1 < len(dates) {
 			nextDate := dates[i+1]
-			nightForecast := calculateSummary(daysData[nextDate], dayParts["night"])
+			nightForecast := calculateSummary(daysData[nextDate], dayParts["night"], loc)
 			dayForecast.Night = nightForecast
 		}
 
@@ -151,30 +175,30 @@ func processWeatherData(forecast *openweathermap.ForecastWeatherData) (*models.P
 	return &models.ProcessedForecast{
 		FullDay:   fullDayForecasts,
 		ShortDays: shortDayForecasts,
+		Timezone:  loc.String(),
 	}, nil
 }
 
-func processFullDayForecast(data []openweathermap.Forecast5WeatherList) models.FullDayForecast {
+func processFullDayForecast(data []ForecastItem, loc *time.Location) models.FullDayForecast {
 
 	return models.FullDayForecast{
-		Morning: calculateSummary(data, dayParts["morning"]),
-		Day:     calculateSummary(data, dayParts["day"]),
-		Evening: calculateSummary(data, dayParts["evening"]),
+		Morning: calculateSummary(data, dayParts["morning"], loc),
+		Day:     calculateSummary(data, dayParts["day"], loc),
+		Evening: calculateSummary(data, dayParts["evening"], loc),
 	}
 }
 
-func processShortDayForecast(date string, data []openweathermap.Forecast5WeatherList) models.ShortDayForecast {
+func processShortDayForecast(date string, data []ForecastItem) models.ShortDayForecast {
 	var tempSum float64
 	var count int
 	weatherCount := make(map[int]int)
 
 	for _, item := range data {
-		tempSum += item.Main.Temp
+		tempSum += item.Temperature
 		count++
 
 		// Подсчёт доминирующей погоды
-		weatherCondition := item.Weather[0].ID
-		weatherCount[weatherCondition]++
+		weatherCount[item.ConditionId]++
 	}
 
 	// Выбираем самую частую погоду
@@ -12,13 +12,16 @@ import (
 )
 
 type Cache struct {
-	client  *redis.Client
-	Healthy bool
-	mu      sync.RWMutex
+	client     *redis.Client
+	weatherTTL time.Duration
+	Healthy    bool
+	mu         sync.RWMutex
+	groups     sync.Map // Очереди, для стримов которых уже создана группа воркеров
 }
 
-func NewCache(client *redis.Client) *Cache {
-	return &Cache{client: client}
+// NewCache - хранилище в Redis, прогноз погоды хранится weatherTTL
+func NewCache(client *redis.Client, weatherTTL time.Duration) *Cache {
+	return &Cache{client: client, weatherTTL: weatherTTL}
 }
 
 // Инициализация Redis
@@ -36,8 +39,8 @@ func Init(addr string) (*redis.Client, error) {
 
 }
 
-func (cache *Cache) HealthCheck() {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+func (cache *Cache) HealthCheck(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
 	_, err := cache.client.Ping(ctx).Result()
@@ -4,16 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"weather-bot/internal/app/storage"
 	"weather-bot/internal/models"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 )
 
 var _ storage.CityStorage = (*Cache)(nil)
 
-func (c *Cache) SaveCity(city models.City) error {
+// Хеш городов по ID и гео-индекс (ключи не попадают под шаблон "city:*")
+const (
+	citiesByIDKey = "cities"
+	citiesGeoKey  = "cities:geo"
+)
+
+func (c *Cache) SaveCity(ctx context.Context, city models.City) error {
 	redisKey := fmt.Sprintf("city:%s", city.Name)
 
 	// Преобразуем структуру в JSON
@@ -23,8 +31,28 @@ func (c *Cache) SaveCity(city models.City) error {
 		return fmt.Errorf("ошибка при сериализации города: %w", err)
 	}
 
+	// Индекс по ID
+	err = c.client.HSet(ctx, citiesByIDKey, strconv.Itoa(city.ID), cityData).Err()
+	if err != nil {
+		log.Error().Err(err).Int("cityID", city.ID).Msg("Ошибка записи города в индекс по ID")
+		return fmt.Errorf("ошибка записи города в индекс по ID: %w", err)
+	}
+
+	// Гео-индекс для поиска ближайшего города
+	if city.Lat != 0 || city.Lon != 0 {
+		err = c.client.GeoAdd(ctx, citiesGeoKey, &redis.GeoLocation{
+			Name:      strconv.Itoa(city.ID),
+			Longitude: city.Lon,
+			Latitude:  city.Lat,
+		}).Err()
+		if err != nil {
+			log.Error().Err(err).Int("cityID", city.ID).Msg("Ошибка записи города в гео-индекс")
+			return fmt.Errorf("ошибка записи города в гео-индекс: %w", err)
+		}
+	}
+
 	// Проверяем, существует ли уже город с таким названием
-	existingCities, err := c.client.LRange(context.Background(), redisKey, 0, -1).Result()
+	existingCities, err := c.client.LRange(ctx, redisKey, 0, -1).Result()
 	if err != nil {
 		log.Error().Err(err).Msg("Ошибка при проверке существующих городов")
 		return fmt.Errorf("ошибка при проверке существующих городов: %w", err)
@@ -37,7 +65,7 @@ func (c *Cache) SaveCity(city models.City) error {
 		}
 		if existingCity.ID == city.ID {
 			// Город с таким ID уже существует, обновляем его
-			err = c.client.LSet(context.Background(), redisKey, int64(i), string(cityData)).Err()
+			err = c.client.LSet(ctx, redisKey, int64(i), string(cityData)).Err()
 			if err != nil {
 				log.Error().Err(err).Int("cityID", city.ID).Msg("Ошибка при обновлении города в Redis")
 				return fmt.Errorf("ошибка при обновлении города в Redis: %w", err)
@@ -47,7 +75,7 @@ func (c *Cache) SaveCity(city models.City) error {
 	}
 
 	// Если город не найден, добавляем новый
-	err = c.client.RPush(context.Background(), redisKey, cityData).Err()
+	err = c.client.RPush(ctx, redisKey, cityData).Err()
 	if err != nil {
 		log.Error().Err(err).Int("cityID", city.ID).Msg("Ошибка записи в Redis")
 		return fmt.Errorf("ошибка записи в Redis: %w", err)
@@ -56,12 +84,12 @@ func (c *Cache) SaveCity(city models.City) error {
 	return nil
 }
 
-func (c *Cache) GetCities(city string) ([]models.City, error) {
+func (c *Cache) GetCities(ctx context.Context, city string) ([]models.City, error) {
 	seen := make(map[string]bool)
 	var result []models.City
 	redisKey := fmt.Sprintf("city:%s", city)
 
-	citiesData, err := c.client.LRange(context.Background(), redisKey, 0, -1).Result()
+	citiesData, err := c.client.LRange(ctx, redisKey, 0, -1).Result()
 	if err != nil {
 		log.Error().Err(err).Msg("Ошибка получения городов из Redis")
 		return nil, fmt.Errorf("ошибка получения городов из Redis: %w", err)
@@ -83,10 +111,54 @@ func (c *Cache) GetCities(city string) ([]models.City, error) {
 	return result, nil
 }
 
-func (c *Cache) GetCitiesNames() ([]string, error) {
+func (c *Cache) GetCity(ctx context.Context, id int) (*models.City, error) {
+	data, err := c.client.HGet(ctx, citiesByIDKey, strconv.Itoa(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения города %d из Redis: %w", id, err)
+	}
+
+	var city models.City
+	if err := json.Unmarshal([]byte(data), &city); err != nil {
+		return nil, fmt.Errorf("ошибка десериализации города %d: %w", id, err)
+	}
+	return &city, nil
+}
+
+// GetNearestCity ищет ближайший город через GEOSEARCH и возвращает его вместе с расстоянием в км
+func (c *Cache) GetNearestCity(ctx context.Context, lat, lon float64) (*models.City, float64, error) {
+	locations, err := c.client.GeoSearchLocation(ctx, citiesGeoKey, &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{
+			Longitude:  lon,
+			Latitude:   lat,
+			Radius:     storage.NearestCityRadiusKm,
+			RadiusUnit: "km",
+			Sort:       "ASC",
+			Count:      1,
+		},
+		WithDist: true,
+	}).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка поиска ближайшего города в Redis: %w", err)
+	}
+	if len(locations) == 0 {
+		return nil, 0, fmt.Errorf("в радиусе %d км нет городов", storage.NearestCityRadiusKm)
+	}
+
+	id, err := strconv.Atoi(locations[0].Name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("неверный ID города в гео-индексе: %w", err)
+	}
+	city, err := c.GetCity(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return city, locations[0].Dist, nil
+}
+
+func (c *Cache) GetCitiesNames(ctx context.Context) ([]string, error) {
 
 	// Получаем все ключи, соответствующие шаблону "city:*"
-	keys, err := c.client.Keys(context.Background(), "city:*").Result()
+	keys, err := c.client.Keys(ctx, "city:*").Result()
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения ключей из Redis: %w", err)
 	}
@@ -101,9 +173,7 @@ func (c *Cache) GetCitiesNames() ([]string, error) {
 	return cities, nil
 }
 
-func (c *Cache) GetCitiesIds() ([]string, error) {
-	ctx := context.Background()
-
+func (c *Cache) GetCitiesIds(ctx context.Context) ([]string, error) {
 	// Получаем все ключи, соответствующие шаблону "user:*"
 	userKeys, err := c.client.Keys(ctx, "user:*").Result()
 	if err != nil {
@@ -113,16 +183,52 @@ func (c *Cache) GetCitiesIds() ([]string, error) {
 
 	var cityIDs []string
 	seenCities := make(map[string]bool)
+	// Города неактивных пользователей не обновляем
+	inactive := make(map[string]bool)
 
-	// Извлекаем `city_id` у каждого пользователя
+	// Извлекаем `city_id` у каждого активного пользователя
 	for _, key := range userKeys {
-		cityID, err := c.client.HGet(ctx, key, "city_id").Result()
-		if err == nil && cityID != "" && !seenCities[cityID] {
+		values, err := c.client.HMGet(ctx, key, "city_id", "active").Result()
+		if err != nil {
+			continue
+		}
+		if active, ok := values[1].(string); ok && active == "0" {
+			inactive[strings.TrimPrefix(key, "user:")] = true
+			continue
+		}
+		cityID, _ := values[0].(string)
+		if cityID != "" && !seenCities[cityID] {
 			cityIDs = append(cityIDs, cityID)
 			seenCities[cityID] = true
 		}
 	}
 
+	// Добавляем города из сохранённых мест пользователей
+	placeKeys, err := c.client.Keys(ctx, "user_cities:*").Result()
+	if err != nil {
+		log.Error().Err(err).Msg("Ошибка получения ключей мест пользователей из Redis")
+		return nil, err
+	}
+	for _, key := range placeKeys {
+		if inactive[strings.TrimPrefix(key, "user_cities:")] {
+			continue
+		}
+		places, err := c.client.HVals(ctx, key).Result()
+		if err != nil {
+			continue
+		}
+		for _, data := range places {
+			var place models.UserCity
+			if err := json.Unmarshal([]byte(data), &place); err != nil {
+				continue
+			}
+			if place.CityID != "" && !seenCities[place.CityID] {
+				cityIDs = append(cityIDs, place.CityID)
+				seenCities[place.CityID] = true
+			}
+		}
+	}
+
 	if len(cityIDs) == 0 {
 		return nil, fmt.Errorf("нет данных в Redis, нужно запросить из БД")
 	}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"weather-bot/internal/app/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Недоставленных задач храним не больше этого количества, старые вытесняются
+const deadLettersMaxLen = 10000
+
+// DeadLetter подтверждает задачу и записывает её в очередь недоставленных вместе с причиной
+func (c *Cache) DeadLetter(ctx context.Context, queue string, job storage.Job, reason string) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: storage.QueueNotificationsDLQ,
+			MaxLen: deadLettersMaxLen,
+			Approx: true,
+			Values: map[string]any{
+				"queue":    queue,
+				"job":      job.ID,
+				"reason":   reason,
+				"attempts": job.Attempt + 1,
+				"failedAt": time.Now().Unix(),
+			},
+		})
+		pipe.XAck(ctx, queue, consumerGroup, job.Delivery)
+		pipe.XDel(ctx, queue, job.Delivery)
+		pipe.HDel(ctx, attemptsKey(queue), job.ID)
+		pipe.HDel(ctx, scheduledForKey(queue), job.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Ошибка переноса задачи %s в очередь недоставленных: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (c *Cache) DeadLetters(ctx context.Context, before string, limit int) ([]storage.DeadLetter, error) {
+	end := "+"
+	if before != "" {
+		end = "(" + before
+	}
+	messages, err := c.client.XRevRangeN(ctx, storage.QueueNotificationsDLQ, end, "-", int64(limit)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка чтения очереди недоставленных: %w", err)
+	}
+
+	letters := make([]storage.DeadLetter, 0, len(messages))
+	for _, msg := range messages {
+		letters = append(letters, deadLetter(msg))
+	}
+	return letters, nil
+}
+
+func (c *Cache) GetDeadLetter(ctx context.Context, id string) (*storage.DeadLetter, error) {
+	messages, err := c.client.XRangeN(ctx, storage.QueueNotificationsDLQ, id, id, 1).Result()
+	// ID не в формате стрима - такой записи точно нет
+	if err != nil && strings.Contains(err.Error(), "Invalid stream ID") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка чтения %s из очереди недоставленных: %w", id, err)
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	letter := deadLetter(messages[0])
+	return &letter, nil
+}
+
+func deadLetter(msg redis.XMessage) storage.DeadLetter {
+	attempts, _ := strconv.Atoi(fmt.Sprint(msg.Values["attempts"]))
+	failedAt, _ := strconv.ParseInt(fmt.Sprint(msg.Values["failedAt"]), 10, 64)
+	queue, _ := msg.Values["queue"].(string)
+	jobID, _ := msg.Values["job"].(string)
+	reason, _ := msg.Values["reason"].(string)
+	return storage.DeadLetter{
+		ID:       msg.ID,
+		Queue:    queue,
+		JobID:    jobID,
+		Reason:   reason,
+		Attempts: attempts,
+		FailedAt: failedAt,
+	}
+}
+
+func (c *Cache) RemoveDeadLetter(ctx context.Context, id string) error {
+	if err := c.client.XDel(ctx, storage.QueueNotificationsDLQ, id).Err(); err != nil {
+		return fmt.Errorf("Ошибка удаления %s из очереди недоставленных: %w", id, err)
+	}
+	return nil
+}
@@ -2,8 +2,11 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 	"weather-bot/internal/app/storage"
 
 	"github.com/redis/go-redis/v9"
@@ -12,129 +15,269 @@ import (
 
 var _ storage.NotificationStorage = (*Cache)(nil)
 
-func (c *Cache) ScheduleWeatherUpdate(executeAt int64) error {
-	ctx := context.Background()
-	// ID задачи
-	jobID := fmt.Sprintf("weather_update:%d", executeAt)
-
-	// Добавляем задачу в Redis Stream
-	_, err := c.client.XAdd(ctx, &redis.XAddArgs{
-		Stream: "weather_updates",
-		Values: map[string]any{
-			"job_id":    jobID,
-			"executeAt": executeAt,
-		},
-	}).Result()
+// Группа воркеров, читающих готовые задачи. Все реплики бота входят в одну группу.
+const consumerGroup = "workers"
+
+// Готовые задачи без подтверждения дольше этого времени забирает другой воркер.
+// Долгие задачи, как обновление погоды, подтверждаются до начала работы.
+const claimIdle = 5 * time.Minute
+
+// Сколько ждём новых задач в XREADGROUP
+const readBlock = time.Second
+
+// Готовые задачи держим в стриме не дольше этого количества
+const streamMaxLen = 100000
+
+// Отложенные задачи лежат в ZSET с executeAt в качестве score, наступившие переносятся
+// в Redis Stream с тем же именем, что и очередь, и читаются группой воркеров
+func queueKey(queue string) string {
+	return "queue:" + queue
+}
+
+// Счётчики неудачных попыток задач очереди
+func attemptsKey(queue string) string {
+	return "queue:" + queue + ":attempts"
+}
+
+// Исходное время повторяемых задач очереди
+func scheduledForKey(queue string) string {
+	return "queue:" + queue + ":scheduled_for"
+}
 
+// promoteScript атомарно переносит наступившие задачи из ZSET в стрим, поэтому каждая задача
+// попадает в стрим один раз, сколько бы реплик ни вызывали его одновременно
+var promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'WITHSCORES', 'LIMIT', 0, tonumber(ARGV[2]))
+for i = 1, #due, 2 do
+	local attempt = redis.call('HGET', KEYS[3], due[i]) or '0'
+	local scheduledFor = redis.call('HGET', KEYS[4], due[i]) or due[i + 1]
+	redis.call('ZREM', KEYS[1], due[i])
+	redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[3], '*', 'job', due[i], 'executeAt', due[i + 1], 'attempt', attempt, 'scheduledFor', scheduledFor)
+end
+return #due / 2
+`)
+
+// Schedule откладывает задачу до executeAt. Это новое время задачи, а не повтор.
+func (c *Cache) Schedule(ctx context.Context, queue, jobID string, executeAt int64) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, queueKey(queue), redis.Z{Score: float64(executeAt), Member: jobID})
+		pipe.HDel(ctx, scheduledForKey(queue), jobID)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("Ошибка записи задачи на обновление погоды в Redis: %w", err)
+		return fmt.Errorf("Ошибка записи задачи %s в очередь %s: %w", jobID, queue, err)
 	}
 	return nil
 }
 
-func (c *Cache) RemoveWeatherUpdate() error {
-	ctx := context.Background()
+func (c *Cache) Due(ctx context.Context, queue, consumer string, now int64, limit int) ([]storage.Job, error) {
+	if err := c.ensureGroup(ctx, queue); err != nil {
+		return nil, err
+	}
+
+	if err := promoteScript.Run(ctx, c.client, []string{queueKey(queue), queue, attemptsKey(queue), scheduledForKey(queue)}, now, limit, streamMaxLen).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("Ошибка переноса наступивших задач очереди %s: %w", queue, err)
+	}
 
-	messages, err := c.client.XRange(ctx, "weather_updates", "-", "+").Result()
+	// Сначала забираем задачи, которые взял и не подтвердил упавший воркер
+	messages, _, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   queue,
+		Group:    consumerGroup,
+		Consumer: consumer,
+		MinIdle:  claimIdle,
+		Start:    "0-0",
+		Count:    int64(limit),
+	}).Result()
 	if err != nil {
-		log.Error().Err(err).Msg("Ошибка чтения из Redis Stream")
-		return err
+		return nil, c.groupError(queue, fmt.Errorf("Ошибка XAUTOCLAIM очереди %s: %w", queue, err))
+	}
+	if len(messages) > 0 {
+		log.Warn().Str("queue", queue).Int("jobs", len(messages)).Msg("Забраны неподтверждённые задачи другого воркера")
+	}
+
+	if len(messages) < limit {
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    consumerGroup,
+			Consumer: consumer,
+			Streams:  []string{queue, ">"},
+			Count:    int64(limit - len(messages)),
+			Block:    readBlock,
+		}).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, c.groupError(queue, fmt.Errorf("Ошибка XREADGROUP очереди %s: %w", queue, err))
+		}
+		for _, stream := range streams {
+			messages = append(messages, stream.Messages...)
+		}
 	}
 
+	jobs := make([]storage.Job, 0, len(messages))
 	for _, msg := range messages {
-		_, err := c.client.XDel(ctx, "weather_updates", msg.ID).Result()
-		if err != nil {
-			log.Error().Err(err).Msgf("Ошибка удаления задачи %s", msg.ID)
-			return err
+		jobID, _ := msg.Values["job"].(string)
+		executeAt, _ := strconv.ParseFloat(fmt.Sprint(msg.Values["executeAt"]), 64)
+		attempt, _ := strconv.Atoi(fmt.Sprint(msg.Values["attempt"]))
+		job := storage.Job{ID: jobID, ExecuteAt: int64(executeAt), Delivery: msg.ID, Attempt: attempt}
+		// Записи, добавленные до появления поля, содержат только executeAt
+		job.ScheduledFor = job.ExecuteAt
+		if scheduledFor, err := strconv.ParseFloat(fmt.Sprint(msg.Values["scheduledFor"]), 64); err == nil {
+			job.ScheduledFor = int64(scheduledFor)
 		}
+		jobs = append(jobs, job)
 	}
-	return nil
+	return jobs, nil
 }
 
-func (c *Cache) GetScheduleWeatherUpdate() ([]redis.XStream, error) {
-	ctx := context.Background()
-
-	streams, err := c.client.XRead(ctx, &redis.XReadArgs{
-		Streams: []string{"weather_updates", "0"},
-		Count:   1,
-		Block:   0, // Блокируемся и ждём новую задачу
-	}).Result()
-
+// Ack подтверждает задачу и удаляет её из стрима, чтобы стрим не рос
+func (c *Cache) Ack(ctx context.Context, queue string, job storage.Job) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, queue, consumerGroup, job.Delivery)
+		pipe.XDel(ctx, queue, job.Delivery)
+		pipe.HDel(ctx, attemptsKey(queue), job.ID)
+		pipe.HDel(ctx, scheduledForKey(queue), job.ID)
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("Ошибка подтверждения задачи %s: %w", job.ID, err)
 	}
-	return streams, nil
+	return nil
 }
 
-func (c *Cache) ScheduleUserNotification(userID int64, executeAt int64) error {
-	ctx := context.Background()
-
-	_, err := c.client.XAdd(ctx, &redis.XAddArgs{
-		Stream: "user_notifications",
-		Values: map[string]any{
-			"user_id":   userID,
-			"executeAt": executeAt,
-		},
-	}).Result()
+// Cancel убирает задачу из отложенных. Уже выданную воркеру задачу воркер проверяет сам.
+func (c *Cache) Cancel(ctx context.Context, queue, jobID string) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, queueKey(queue), jobID)
+		pipe.HDel(ctx, attemptsKey(queue), jobID)
+		pipe.HDel(ctx, scheduledForKey(queue), jobID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Ошибка удаления задачи %s из очереди %s: %w", jobID, queue, err)
+	}
+	return nil
+}
 
+// Retry подтверждает выданную задачу и снова откладывает её до executeAt со следующим номером попытки,
+// исходное время задачи сохраняется
+func (c *Cache) Retry(ctx context.Context, queue string, job storage.Job, executeAt int64) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, attemptsKey(queue), job.ID, job.Attempt+1)
+		pipe.HSetNX(ctx, scheduledForKey(queue), job.ID, job.ScheduledFor)
+		pipe.ZAdd(ctx, queueKey(queue), redis.Z{Score: float64(executeAt), Member: job.ID})
+		pipe.XAck(ctx, queue, consumerGroup, job.Delivery)
+		pipe.XDel(ctx, queue, job.Delivery)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("Ошибка записи задачи уведомления в Redis Stream: %w", err)
+		return fmt.Errorf("Ошибка повторного планирования задачи %s: %w", job.ID, err)
 	}
 	return nil
 }
 
-func (c *Cache) RemoveUserNotification(userID int64) error {
-	ctx := context.Background()
+func (c *Cache) ScheduledAt(ctx context.Context, queue, jobID string) (int64, error) {
+	score, err := c.client.ZScore(ctx, queueKey(queue), jobID).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Ошибка чтения задачи %s: %w", jobID, err)
+	}
+	return int64(score), nil
+}
 
-	messages, err := c.client.XRange(ctx, "user_notifications", "-", "+").Result()
+func (c *Cache) QueueLength(ctx context.Context, queue string) (int64, error) {
+	return c.client.ZCard(ctx, queueKey(queue)).Result()
+}
+
+// ClaimOnce выставляет ключ, если его ещё нет. false - ключ уже занят (задача уже выполнена).
+func (c *Cache) ClaimOnce(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := c.client.SetNX(ctx, "once:"+key, 1, ttl).Result()
 	if err != nil {
-		log.Error().Err(err).Msg("Ошибка чтения из Redis Stream")
-		return err
+		return false, fmt.Errorf("Ошибка записи ключа идемпотентности %s: %w", key, err)
 	}
+	return ok, nil
+}
 
-	for _, msg := range messages {
-		if msg.Values["user_id"] == strconv.FormatInt(userID, 10) {
-			_, err := c.client.XDel(ctx, "user_notifications", msg.ID).Result()
-			if err != nil {
-				log.Error().Err(err).Msgf("Ошибка удаления уведомления пользователя %d", userID)
-				return err
-			}
-		}
+func (c *Cache) ReleaseOnce(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, "once:"+key).Err(); err != nil {
+		return fmt.Errorf("Ошибка удаления ключа идемпотентности %s: %w", key, err)
 	}
 	return nil
 }
 
-func (c *Cache) GetScheduleUserNotifications() ([]redis.XStream, error) {
-	ctx := context.Background()
+// ensureGroup создаёт группу воркеров для стрима очереди один раз за время работы
+func (c *Cache) ensureGroup(ctx context.Context, queue string) error {
+	if _, ok := c.groups.Load(queue); ok {
+		return nil
+	}
 
-	streams, err := c.client.XRead(ctx, &redis.XReadArgs{
-		Streams: []string{"user_notifications", "0"},
-		Count:   10,
-		Block:   0, // Блокируемся и ждём новую задачу
-	}).Result()
+	err := c.client.XGroupCreateMkStream(ctx, queue, consumerGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("Ошибка создания группы воркеров для %s: %w", queue, err)
+	}
+	c.groups.Store(queue, true)
+	return nil
+}
 
-	if err != nil {
-		return nil, err
+// groupError сбрасывает признак созданной группы, если стрим удалили вместе с ней
+func (c *Cache) groupError(queue string, err error) error {
+	if strings.Contains(err.Error(), "NOGROUP") {
+		c.groups.Delete(queue)
 	}
-	return streams, nil
+	return err
 }
 
-func (c *Cache) GetUserNotificationTime(userID int64) (string, error) {
-	ctx := context.Background()
+// MigrateLegacyStreams переносит задачи старого формата (до очереди на ZSET) из Redis Streams.
+// Стримы с теми же именами теперь хранят готовые задачи, их записи содержат поле job и не трогаются.
+func (c *Cache) MigrateLegacyStreams(ctx context.Context) error {
+	for _, queue := range []string{storage.QueueUserNotifications, storage.QueueWeatherUpdates} {
+		keyType, err := c.client.Type(ctx, queue).Result()
+		if err != nil {
+			return fmt.Errorf("Ошибка чтения типа ключа %s: %w", queue, err)
+		}
+		if keyType != "stream" {
+			continue
+		}
 
-	// Читаем уведомление из Redis Stream
-	messages, err := c.client.XRange(ctx, "user_notifications", "-", "+").Result()
-	if err != nil {
-		log.Error().Err(err).Msg("Ошибка чтения уведомлений из Redis")
-		return "", fmt.Errorf("Redis is not available")
-	}
+		messages, err := c.client.XRange(ctx, queue, "-", "+").Result()
+		if err != nil {
+			return fmt.Errorf("Ошибка чтения из Redis Stream %s: %w", queue, err)
+		}
 
-	// Ищем уведомление текущего пользователя
-	for _, msg := range messages {
-		if msg.Values["user_id"] == strconv.FormatInt(userID, 10) {
-			return msg.Values["executeAt"].(string), nil
+		migrated := 0
+		for _, msg := range messages {
+			if _, ok := msg.Values["job"]; ok {
+				continue
+			}
+
+			executeAt, err := strconv.ParseInt(fmt.Sprint(msg.Values["executeAt"]), 10, 64)
+			if err != nil {
+				log.Warn().Err(err).Str("stream", queue).Str("id", msg.ID).Msg("Пропускаем задачу с неверным executeAt")
+				continue
+			}
+
+			jobID := storage.WeatherUpdateJobID
+			if queue == storage.QueueUserNotifications {
+				userID, err := strconv.ParseInt(fmt.Sprint(msg.Values["user_id"]), 10, 64)
+				if err != nil {
+					log.Warn().Err(err).Str("id", msg.ID).Msg("Пропускаем уведомление с неверным user_id")
+					continue
+				}
+				subscriptionID, _ := msg.Values["subscription_id"].(string)
+				jobID = storage.NotificationJobID(userID, subscriptionID)
+			}
+
+			if err := c.Schedule(ctx, queue, jobID, executeAt); err != nil {
+				return err
+			}
+			if err := c.client.XDel(ctx, queue, msg.ID).Err(); err != nil {
+				return fmt.Errorf("Ошибка удаления задачи %s из Redis Stream %s: %w", msg.ID, queue, err)
+			}
+			migrated++
 		}
-	}
 
-	return "", nil
+		if migrated > 0 {
+			log.Info().Str("stream", queue).Int("jobs", migrated).Msg("Задачи перенесены из Redis Stream в очередь")
+		}
+	}
+	return nil
 }
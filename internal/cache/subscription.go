@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/models"
+
+	"github.com/rs/zerolog/log"
+)
+
+var _ storage.SubscriptionStorage = (*Cache)(nil)
+
+func subscriptionsKey(userID int64) string {
+	return fmt.Sprintf("subscriptions:%d", userID)
+}
+
+func (c *Cache) SaveSubscription(ctx context.Context, userID int64, sub models.Subscription) error {
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации подписки: %w", err)
+	}
+
+	if err := c.client.HSet(ctx, subscriptionsKey(userID), sub.ID, data).Err(); err != nil {
+		log.Error().Err(err).Int64("userID", userID).Str("subscription", sub.ID).Msg("Ошибка записи подписки в Redis")
+		return fmt.Errorf("ошибка записи в Redis: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) GetSubscriptions(ctx context.Context, userID int64) ([]models.Subscription, error) {
+	values, err := c.client.HVals(ctx, subscriptionsKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения подписок из Redis: %w", err)
+	}
+
+	subs := make([]models.Subscription, 0, len(values))
+	for _, value := range values {
+		var sub models.Subscription
+		if err := json.Unmarshal([]byte(value), &sub); err != nil {
+			log.Error().Err(err).Int64("userID", userID).Str("data", value).Msg("Ошибка десериализации подписки")
+			continue
+		}
+		subs = append(subs, sub)
+	}
+
+	// Тот же порядок, что и в БД
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Time != subs[j].Time {
+			return subs[i].Time < subs[j].Time
+		}
+		return subs[i].ID < subs[j].ID
+	})
+	return subs, nil
+}
+
+func (c *Cache) RemoveSubscription(ctx context.Context, userID int64, id string) error {
+	if err := c.client.HDel(ctx, subscriptionsKey(userID), id).Err(); err != nil {
+		return fmt.Errorf("ошибка удаления подписки из Redis: %w", err)
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"weather-bot/internal/app/storage"
 	"weather-bot/internal/models"
@@ -12,7 +14,7 @@ import (
 
 var _ storage.UserStorage = (*Cache)(nil)
 
-func (c *Cache) SaveUser(u *models.User) error {
+func (c *Cache) SaveUser(ctx context.Context, u *models.User) error {
 	redisKey := fmt.Sprintf("user:%d", u.TgID)
 
 	// Формируем данные для записи
@@ -24,10 +26,14 @@ func (c *Cache) SaveUser(u *models.User) error {
 		"region":  u.Region,
 		"state":   u.State,
 		"sticker": u.Sticker,
+		"draft":   u.Draft,
+
+		"active":     u.Active,
+		"blocked_at": u.BlockedAt,
 	}
 
 	// Сохраняем в Redis
-	err := c.client.HSet(context.Background(), redisKey, userData).Err()
+	err := c.client.HSet(ctx, redisKey, userData).Err()
 	if err != nil {
 		log.Error().Err(err).Int64("userID", u.TgID).Msgf("Ошибка записи в Redis: %v", err)
 		return fmt.Errorf("ошибка записи в Redis: %w", err)
@@ -38,10 +44,10 @@ func (c *Cache) SaveUser(u *models.User) error {
 	return nil
 }
 
-func (c *Cache) GetUser(userId int64) (*models.User, error) {
+func (c *Cache) GetUser(ctx context.Context, userId int64) (*models.User, error) {
 	redisKey := fmt.Sprintf("user:%d", userId)
 
-	userData, err := c.client.HGetAll(context.Background(), redisKey).Result()
+	userData, err := c.client.HGetAll(ctx, redisKey).Result()
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения данных из Redis: %w", err)
 	}
@@ -61,6 +67,15 @@ func (c *Cache) GetUser(userId int64) (*models.User, error) {
 		return nil, fmt.Errorf("ошибка преобразования булевого значения из Redis: %w", err)
 	}
 
+	// Пользователи, сохранённые до появления статуса, активны
+	active := true
+	if value, ok := userData["active"]; ok {
+		if active, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("ошибка преобразования булевого значения из Redis: %w", err)
+		}
+	}
+	blockedAt, _ := strconv.ParseInt(userData["blocked_at"], 10, 64)
+
 	// Создаем и заполняем структуру User
 	user := &models.User{
 		TgID:    userId,
@@ -71,9 +86,59 @@ func (c *Cache) GetUser(userId int64) (*models.User, error) {
 		Region:  userData["region"],
 		State:   userData["state"],
 		Sticker: stickerBool,
+		Draft:   userData["draft"],
+
+		Active:    active,
+		BlockedAt: blockedAt,
 	}
 
 	//log.Info().Msgf("Пользователь получен из Redis: tg_id=%d, name=%s, city=%s, city_id=%s, state=%s", user.TgID, user.Name, user.City, user.CityID, user.State)
 
 	return user, nil
 }
+
+func userCitiesKey(userID int64) string {
+	return fmt.Sprintf("user_cities:%d", userID)
+}
+
+func (c *Cache) SaveUserCity(ctx context.Context, userID int64, city models.UserCity) error {
+	data, err := json.Marshal(city)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации места: %w", err)
+	}
+
+	if err := c.client.HSet(ctx, userCitiesKey(userID), city.Name, data).Err(); err != nil {
+		log.Error().Err(err).Int64("userID", userID).Str("name", city.Name).Msg("Ошибка записи места в Redis")
+		return fmt.Errorf("ошибка записи в Redis: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) GetUserCities(ctx context.Context, userID int64) ([]models.UserCity, error) {
+	values, err := c.client.HVals(ctx, userCitiesKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения мест из Redis: %w", err)
+	}
+
+	cities := make([]models.UserCity, 0, len(values))
+	for _, value := range values {
+		var city models.UserCity
+		if err := json.Unmarshal([]byte(value), &city); err != nil {
+			log.Error().Err(err).Int64("userID", userID).Str("data", value).Msg("Ошибка десериализации места")
+			continue
+		}
+		cities = append(cities, city)
+	}
+
+	sort.Slice(cities, func(i, j int) bool {
+		return cities[i].Name < cities[j].Name
+	})
+	return cities, nil
+}
+
+func (c *Cache) RemoveUserCity(ctx context.Context, userID int64, name string) error {
+	if err := c.client.HDel(ctx, userCitiesKey(userID), name).Err(); err != nil {
+		return fmt.Errorf("ошибка удаления места из Redis: %w", err)
+	}
+	return nil
+}
@@ -4,15 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"time"
 	"weather-bot/internal/app/storage"
 	"weather-bot/internal/models"
 )
 
 var _ storage.WeatherStorage = (*Cache)(nil)
 
-func (c *Cache) GetWeather(cityID int) (*models.ProcessedForecast, error) {
-	ctx := context.Background()
+func (c *Cache) GetWeather(ctx context.Context, cityID int) (*models.ProcessedForecast, error) {
 	cacheKey := fmt.Sprintf("weather:city:%d", cityID)
 
 	cachedData, err := c.client.Get(ctx, cacheKey).Result()
@@ -27,8 +25,7 @@ func (c *Cache) GetWeather(cityID int) (*models.ProcessedForecast, error) {
 	return &forecast, nil
 }
 
-func (c *Cache) SaveWeather(cityID int, forecast *models.ProcessedForecast) error {
-	ctx := context.Background()
+func (c *Cache) SaveWeather(ctx context.Context, cityID int, forecast *models.ProcessedForecast) error {
 	cacheKey := fmt.Sprintf("weather:city:%d", cityID)
 
 	data, err := json.Marshal(forecast)
@@ -36,7 +33,7 @@ func (c *Cache) SaveWeather(cityID int, forecast *models.ProcessedForecast) erro
 		return fmt.Errorf("ошибка сериализации данных: %w", err)
 	}
 
-	err = c.client.Set(ctx, cacheKey, data, 25*time.Hour).Err()
+	err = c.client.Set(ctx, cacheKey, data, c.weatherTTL).Err()
 	if err != nil {
 		return fmt.Errorf("ошибка записи в Redis: %w", err)
 	}
@@ -1,26 +1,298 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Поставщик погоды по умолчанию, ему нужен ключ OPENWEATHER_API_KEY
+const providerOpenWeatherMap = "openweathermap"
+
+// Хранилища: Redis с PostgreSQL (по умолчанию) или память процесса для локального запуска без них
+const (
+	StorageRedis  = "redis"
+	StorageMemory = "memory"
+)
+
+// Config - все настройки бота. Значения берутся из умолчаний (Defaults), затем из файла
+// CONFIG_FILE (YAML или TOML), затем из переменных окружения.
 type Config struct {
+	BotToken string
+	// Хранилище: StorageRedis или StorageMemory. В памяти данные теряются при перезапуске.
+	Storage     string
 	RedisURL    string
 	PostgresURL string
-	BotToken    string
-	WeatherKey  string
+	// Порт сервера метрик Prometheus
+	MetricsPort string
+
+	// Telegram ID администраторов, им доступны служебные команды (/dlq)
+	AdminIDs []int64
+
+	Weather  Weather
+	Jobs     Jobs
+	Updates  Updates
+	Telegram Telegram
+}
+
+type Weather struct {
+	// Поставщик погоды: openweathermap (по умолчанию) или openmeteo
+	Provider string
+	// Запасной поставщик, к которому обращаемся при ошибках основного (необязательно)
+	FallbackProvider string
+	APIKey           string
+	// Сколько прогноз хранится в Redis
+	CacheTTL time.Duration
+}
+
+type Jobs struct {
+	// Пауза перед запуском воркеров очередей после старта бота
+	StartDelay          time.Duration
+	HealthCheckInterval time.Duration
+	CleanupInterval     time.Duration
+
+	// Если оба поставщика недоступны, обновление повторяется Retries раз с паузой RetryDelay
+	// только для неудавшихся городов, остальные обновятся при следующем плановом обновлении
+	WeatherRefreshInterval   time.Duration
+	WeatherRefreshRetries    int
+	WeatherRefreshRetryDelay time.Duration
+
+	// Неудавшееся уведомление повторяется с задержкой Base, 2*Base, 4*Base... но не больше Max
+	NotificationMaxAttempts int
+	NotificationRetryBase   time.Duration
+	NotificationRetryMax    time.Duration
+}
+
+type Updates struct {
+	// Параллельные обработчики обновлений и размер очереди каждого
+	Workers   int
+	QueueSize int
+	// Сколько после сигнала остановки ждём обработчики и фоновые задачи
+	ShutdownTimeout time.Duration
+}
+
+// Telegram - лимиты очереди исходящих сообщений
+type Telegram struct {
+	// Сообщений в секунду на всего бота и сколько можно отправить подряд без ожидания
+	Rate  float64
+	Burst int
+	// Минимальный интервал между сообщениями в один чат
+	ChatInterval time.Duration
+	// Параллельные очереди отправки (чат всегда попадает в одну) и размер каждой
+	Workers   int
+	QueueSize int
+	// Сколько раз повторять отправку после ответа 429
+	MaxRetries int
+}
+
+func Defaults() Config {
+	return Config{
+		Storage:     StorageRedis,
+		MetricsPort: "3000",
+		Weather: Weather{
+			Provider: providerOpenWeatherMap,
+			CacheTTL: 25 * time.Hour,
+		},
+		Jobs: Jobs{
+			StartDelay:               2 * time.Minute,
+			HealthCheckInterval:      time.Minute,
+			CleanupInterval:          6 * time.Hour,
+			WeatherRefreshInterval:   4 * time.Hour,
+			WeatherRefreshRetries:    3,
+			WeatherRefreshRetryDelay: 2 * time.Minute,
+			NotificationMaxAttempts:  5,
+			NotificationRetryBase:    time.Minute,
+			NotificationRetryMax:     30 * time.Minute,
+		},
+		Updates: Updates{
+			Workers:         16,
+			QueueSize:       64,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		// Лимиты Telegram: около 30 сообщений в секунду и 1 сообщение в секунду в чат
+		Telegram: Telegram{
+			Rate:         25,
+			Burst:        25,
+			ChatInterval: time.Second,
+			Workers:      32,
+			QueueSize:    256,
+			MaxRetries:   3,
+		},
+	}
+}
+
+// Load читает настройки из файла CONFIG_FILE (если задан) и окружения
+func Load() (*Config, error) {
+	return LoadFrom(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+}
+
+// LoadFrom читает настройки из файла path (пустой - без файла) и переменных, которые возвращает lookupEnv.
+// Проверку обязательных полей выполняет Validate.
+func LoadFrom(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Defaults()
+	fields := cfg.fields()
+
+	var errs []error
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		byKey := make(map[string]field, len(fields))
+		for _, f := range fields {
+			byKey[f.key] = f
+		}
+		for key, value := range values {
+			f, ok := byKey[key]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: неизвестный параметр %s", path, key))
+				continue
+			}
+			if err := f.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", path, key, err))
+			}
+		}
+	}
+
+	for _, f := range fields {
+		value, ok := lookupEnv(f.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("ошибка чтения конфигурации: %w", errors.Join(errs...))
+	}
+	return &cfg, nil
 }
 
-func Load() *Config {
-	// err := godotenv.Load("../internal/config/.env")
-	// if err != nil {
-	// 	log.Fatal("Ошибка загрузки .env файла")
-	// }
+// Validate проверяет, что заданы обязательные параметры и значения имеют смысл
+func (c *Config) Validate() error {
+	var errs []error
+	required := func(value, env string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("не задан %s", env))
+		}
+	}
+	positive := func(value time.Duration, env string) {
+		if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s должен быть больше нуля", env))
+		}
+	}
+	atLeastOne := func(value int, env string) {
+		if value < 1 {
+			errs = append(errs, fmt.Errorf("%s должен быть не меньше 1", env))
+		}
+	}
+
+	required(c.BotToken, "TELEGRAM_BOT_TOKEN")
+	switch c.Storage {
+	case StorageRedis:
+		required(c.RedisURL, "REDIS_URL")
+		required(c.PostgresURL, "POSTGRES_URL")
+	case StorageMemory:
+	default:
+		errs = append(errs, fmt.Errorf("неизвестное хранилище STORAGE_BACKEND: %s", c.Storage))
+	}
+	if c.Weather.Provider == providerOpenWeatherMap || c.Weather.FallbackProvider == providerOpenWeatherMap {
+		required(c.Weather.APIKey, "OPENWEATHER_API_KEY")
+	}
+
+	positive(c.Weather.CacheTTL, "WEATHER_CACHE_TTL")
+	positive(c.Jobs.HealthCheckInterval, "HEALTH_CHECK_INTERVAL")
+	positive(c.Jobs.CleanupInterval, "CLEANUP_INTERVAL")
+	positive(c.Jobs.WeatherRefreshInterval, "WEATHER_REFRESH_INTERVAL")
+	positive(c.Jobs.WeatherRefreshRetryDelay, "WEATHER_REFRESH_RETRY_DELAY")
+	positive(c.Jobs.NotificationRetryBase, "NOTIFICATION_RETRY_BASE")
+	positive(c.Jobs.NotificationRetryMax, "NOTIFICATION_RETRY_MAX")
+	positive(c.Updates.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+	atLeastOne(c.Jobs.WeatherRefreshRetries, "WEATHER_REFRESH_RETRIES")
+	atLeastOne(c.Jobs.NotificationMaxAttempts, "NOTIFICATION_MAX_ATTEMPTS")
+	atLeastOne(c.Updates.Workers, "UPDATE_WORKERS")
+	atLeastOne(c.Updates.QueueSize, "UPDATE_QUEUE_SIZE")
+	atLeastOne(c.Telegram.Burst, "TELEGRAM_BURST")
+	atLeastOne(c.Telegram.Workers, "TELEGRAM_SEND_WORKERS")
+	atLeastOne(c.Telegram.QueueSize, "TELEGRAM_QUEUE_SIZE")
+	if c.Telegram.Rate <= 0 {
+		errs = append(errs, errors.New("TELEGRAM_RATE должен быть больше нуля"))
+	}
+	if c.Telegram.ChatInterval < 0 {
+		errs = append(errs, errors.New("TELEGRAM_CHAT_INTERVAL не может быть отрицательным"))
+	}
+	if c.Telegram.MaxRetries < 0 {
+		errs = append(errs, errors.New("TELEGRAM_MAX_RETRIES не может быть отрицательным"))
+	}
+	if time.Duration(c.Jobs.WeatherRefreshRetries)*c.Jobs.WeatherRefreshRetryDelay >= c.Jobs.WeatherRefreshInterval {
+		errs = append(errs, errors.New("повторы WEATHER_REFRESH_RETRIES с паузой WEATHER_REFRESH_RETRY_DELAY должны укладываться в WEATHER_REFRESH_INTERVAL"))
+	}
+	if c.Jobs.StartDelay < 0 {
+		errs = append(errs, errors.New("JOBS_START_DELAY не может быть отрицательным"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("неверная конфигурация: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// fields связывает параметры с ключами файла и переменными окружения
+func (c *Config) fields() []field {
+	return []field{
+		{"bot_token", "TELEGRAM_BOT_TOKEN", stringVar(&c.BotToken)},
+		{"storage", "STORAGE_BACKEND", stringVar(&c.Storage)},
+		{"redis_url", "REDIS_URL", stringVar(&c.RedisURL)},
+		{"postgres_url", "POSTGRES_URL", stringVar(&c.PostgresURL)},
+		{"metrics_port", "METRICS_SERVER_ADDR", stringVar(&c.MetricsPort)},
+		{"admin_ids", "ADMIN_IDS", idsVar(&c.AdminIDs)},
+
+		{"weather.provider", "WEATHER_PROVIDER", stringVar(&c.Weather.Provider)},
+		{"weather.fallback_provider", "WEATHER_FALLBACK_PROVIDER", stringVar(&c.Weather.FallbackProvider)},
+		{"weather.api_key", "OPENWEATHER_API_KEY", stringVar(&c.Weather.APIKey)},
+		{"weather.cache_ttl", "WEATHER_CACHE_TTL", durationVar(&c.Weather.CacheTTL)},
+
+		{"jobs.start_delay", "JOBS_START_DELAY", durationVar(&c.Jobs.StartDelay)},
+		{"jobs.health_check_interval", "HEALTH_CHECK_INTERVAL", durationVar(&c.Jobs.HealthCheckInterval)},
+		{"jobs.cleanup_interval", "CLEANUP_INTERVAL", durationVar(&c.Jobs.CleanupInterval)},
+		{"jobs.weather_refresh_interval", "WEATHER_REFRESH_INTERVAL", durationVar(&c.Jobs.WeatherRefreshInterval)},
+		{"jobs.weather_refresh_retries", "WEATHER_REFRESH_RETRIES", intVar(&c.Jobs.WeatherRefreshRetries)},
+		{"jobs.weather_refresh_retry_delay", "WEATHER_REFRESH_RETRY_DELAY", durationVar(&c.Jobs.WeatherRefreshRetryDelay)},
+		{"jobs.notification_max_attempts", "NOTIFICATION_MAX_ATTEMPTS", intVar(&c.Jobs.NotificationMaxAttempts)},
+		{"jobs.notification_retry_base", "NOTIFICATION_RETRY_BASE", durationVar(&c.Jobs.NotificationRetryBase)},
+		{"jobs.notification_retry_max", "NOTIFICATION_RETRY_MAX", durationVar(&c.Jobs.NotificationRetryMax)},
+
+		{"updates.workers", "UPDATE_WORKERS", intVar(&c.Updates.Workers)},
+		{"updates.queue_size", "UPDATE_QUEUE_SIZE", intVar(&c.Updates.QueueSize)},
+		{"updates.shutdown_timeout", "SHUTDOWN_TIMEOUT", durationVar(&c.Updates.ShutdownTimeout)},
+
+		{"telegram.rate", "TELEGRAM_RATE", floatVar(&c.Telegram.Rate)},
+		{"telegram.burst", "TELEGRAM_BURST", intVar(&c.Telegram.Burst)},
+		{"telegram.chat_interval", "TELEGRAM_CHAT_INTERVAL", durationVar(&c.Telegram.ChatInterval)},
+		{"telegram.workers", "TELEGRAM_SEND_WORKERS", intVar(&c.Telegram.Workers)},
+		{"telegram.queue_size", "TELEGRAM_QUEUE_SIZE", intVar(&c.Telegram.QueueSize)},
+		{"telegram.max_retries", "TELEGRAM_MAX_RETRIES", intVar(&c.Telegram.MaxRetries)},
+	}
+}
 
-	return &Config{
-		RedisURL:    os.Getenv("REDIS_URL"),
-		PostgresURL: os.Getenv("POSTGRES_URL"),
-		BotToken:    os.Getenv("TELEGRAM_BOT_TOKEN"),
-		WeatherKey:  os.Getenv("OPENWEATHER_API_KEY"),
+// Список ID через запятую: "1, 2" в окружении или [1, 2] в файле
+func parseIDs(s string) ([]int64, error) {
+	var ids []int64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("неверный ID %q", field)
+		}
+		ids = append(ids, id)
 	}
+	return ids, nil
 }
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// field - параметр конфигурации: ключ в файле ("weather.cache_ttl"), переменная окружения
+// и разбор значения из строки. Файл и окружение разбираются одинаково.
+type field struct {
+	key string
+	env string
+	set func(string) error
+}
+
+func stringVar(dst *string) func(string) error {
+	return func(value string) error {
+		*dst = value
+		return nil
+	}
+}
+
+func intVar(dst *int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("неверное число %q", value)
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func floatVar(dst *float64) func(string) error {
+	return func(value string) error {
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("неверное число %q", value)
+		}
+		*dst = f
+		return nil
+	}
+}
+
+// Длительность в формате Go: "90s", "10m", "4h"
+func durationVar(dst *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("неверная длительность %q, пример: 10m, 4h", value)
+		}
+		*dst = d
+		return nil
+	}
+}
+
+func idsVar(dst *[]int64) func(string) error {
+	return func(value string) error {
+		ids, err := parseIDs(value)
+		if err != nil {
+			return err
+		}
+		*dst = ids
+		return nil
+	}
+}
+
+// readFile читает YAML (.yaml, .yml) или TOML (.toml) и возвращает значения по ключам
+// вида "weather.cache_ttl". Списки склеиваются через запятую, как в переменных окружения.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+	}
+
+	var tree map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый формат файла конфигурации %q, нужен .yaml, .yml или .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", tree, values)
+	return values, nil
+}
+
+func flatten(prefix string, tree map[string]any, values map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(key, v, values)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			// Пустое значение в файле - оставляем умолчание
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"weather-bot/internal/app/storage"
 	"weather-bot/internal/models"
 
@@ -11,13 +12,13 @@ import (
 
 var _ storage.CityStorage = (*Database)(nil) // Проверка интерфейса
 
-func (db *Database) SaveCity(city models.City) error {
+func (db *Database) SaveCity(ctx context.Context, city models.City) error {
 
-	_, err := db.pool.Exec(context.Background(), `
-			INSERT INTO cities (id, name, federal_district, region, city_district, street,country)
-			VALUES ($1, $2, $3, $4, $5, $6,$7)
-			ON CONFLICT (id) DO UPDATE SET name = $2, federal_district = $3, region = $4, city_district = $5, street = $6, country = $7`,
-		city.ID, city.Name, city.FederalDistrict, city.Region, city.CityDistrict, city.Street, city.Country,
+	_, err := db.pool.Exec(ctx, `
+			INSERT INTO cities (id, name, federal_district, region, city_district, street, country, timezone, lat, lon)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (id) DO UPDATE SET name = $2, federal_district = $3, region = $4, city_district = $5, street = $6, country = $7, timezone = $8, lat = $9, lon = $10`,
+		city.ID, city.Name, city.FederalDistrict, city.Region, city.CityDistrict, city.Street, city.Country, city.Timezone, city.Lat, city.Lon,
 	)
 	if err != nil {
 		log.Error().Err(err).Msg("Ошибка записи города в БД")
@@ -28,11 +29,9 @@ func (db *Database) SaveCity(city models.City) error {
 }
 
 // GetCities ищет города в PostgreSQL по имени
-func (db *Database) GetCities(name string) ([]models.City, error) {
-	ctx := context.Background()
-
+func (db *Database) GetCities(ctx context.Context, name string) ([]models.City, error) {
 	rows, err := db.pool.Query(ctx, `
-		SELECT id, name, federal_district, region, city_district, street, country 
+		SELECT id, name, federal_district, region, city_district, street, country, COALESCE(timezone, ''), COALESCE(lat, 0), COALESCE(lon, 0)
 		FROM cities 
 		WHERE name = $1`, name)
 	if err != nil {
@@ -46,7 +45,7 @@ func (db *Database) GetCities(name string) ([]models.City, error) {
 
 	for rows.Next() {
 		var city models.City
-		err := rows.Scan(&city.ID, &city.Name, &city.FederalDistrict, &city.Region, &city.CityDistrict, &city.Street, &city.Country)
+		err := rows.Scan(&city.ID, &city.Name, &city.FederalDistrict, &city.Region, &city.CityDistrict, &city.Street, &city.Country, &city.Timezone, &city.Lat, &city.Lon)
 		if err != nil {
 			log.Error().Err(err).Msg("Ошибка чтения данных из БД")
 			continue
@@ -68,11 +67,52 @@ func (db *Database) GetCities(name string) ([]models.City, error) {
 	return result, nil
 }
 
-func (d *Database) GetCitiesIds() ([]string, error) {
-	ctx := context.Background()
+// GetCity ищет город в PostgreSQL по ID
+func (db *Database) GetCity(ctx context.Context, id int) (*models.City, error) {
+	var city models.City
+	err := db.pool.QueryRow(ctx, `
+		SELECT id, name, federal_district, region, city_district, street, country, COALESCE(timezone, ''), COALESCE(lat, 0), COALESCE(lon, 0)
+		FROM cities
+		WHERE id = $1`, id).Scan(&city.ID, &city.Name, &city.FederalDistrict, &city.Region, &city.CityDistrict, &city.Street, &city.Country, &city.Timezone, &city.Lat, &city.Lon)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения города %d из БД: %w", id, err)
+	}
+	return &city, nil
+}
+
+// GetNearestCity ищет ближайший город по формуле гаверсинусов
+func (db *Database) GetNearestCity(ctx context.Context, lat, lon float64) (*models.City, float64, error) {
+	var city models.City
+	var distance float64
+	err := db.pool.QueryRow(ctx, `
+		SELECT id, name, federal_district, region, city_district, street, country, timezone, lat, lon, distance
+		FROM (
+			SELECT id, name, federal_district, region, city_district, street, country, COALESCE(timezone, '') AS timezone, lat, lon,
+				2 * 6371 * asin(sqrt(
+					power(sin(radians(lat - $1) / 2), 2) +
+					cos(radians($1)) * cos(radians(lat)) * power(sin(radians(lon - $2) / 2), 2)
+				)) AS distance
+			FROM cities
+			WHERE lat IS NOT NULL AND lon IS NOT NULL AND (lat <> 0 OR lon <> 0)
+		) AS c
+		WHERE distance <= $3
+		ORDER BY distance
+		LIMIT 1`, lat, lon, float64(storage.NearestCityRadiusKm)).
+		Scan(&city.ID, &city.Name, &city.FederalDistrict, &city.Region, &city.CityDistrict, &city.Street, &city.Country, &city.Timezone, &city.Lat, &city.Lon, &distance)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка поиска ближайшего города в БД: %w", err)
+	}
+	return &city, distance, nil
+}
+
+func (d *Database) GetCitiesIds(ctx context.Context) ([]string, error) {
 	var cityIDs []string
 
-	rows, err := d.pool.Query(ctx, "SELECT DISTINCT city_id FROM users")
+	// Города неактивных пользователей не обновляем
+	rows, err := d.pool.Query(ctx, `
+		SELECT city_id FROM users WHERE active
+		UNION
+		SELECT uc.city_id FROM user_cities uc JOIN users u ON u.tg_id = uc.user_id WHERE u.active`)
 	if err != nil {
 		return nil, err
 	}
@@ -91,8 +131,7 @@ func (d *Database) GetCitiesIds() ([]string, error) {
 	return cityIDs, nil
 }
 
-func (d *Database) GetCitiesNames() ([]string, error) {
-	ctx := context.Background()
+func (d *Database) GetCitiesNames(ctx context.Context) ([]string, error) {
 	var citiesNames []string
 
 	rows, err := d.pool.Query(ctx, "SELECT DISTINCT name FROM cities")
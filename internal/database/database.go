@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/rs/zerolog/log"
 )
 
 type Database struct {
@@ -16,62 +15,33 @@ func NewDatabase(pool *pgxpool.Pool) *Database {
 	return &Database{pool: pool}
 }
 
-// Инициализация PostgreSQL
-func Init(url string) (*pgxpool.Pool, error) {
-	pool, err := pgxpool.New(context.Background(), url)
+// Connect подключается к PostgreSQL без применения миграций
+func Connect(ctx context.Context, url string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("Ошибка подключения к БД: %v", err)
 	}
-
-	// Проверяем и создаём таблицы
-	if err := ensureTables(pool); err != nil {
-		return nil, fmt.Errorf("Ошибка инициализации таблиц: %v", err)
-	}
-
 	return pool, nil
-
 }
 
-func ensureTables(pool *pgxpool.Pool) error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS users (
-			tg_id INT PRIMARY KEY,
-			chat_id INT NOT NULL,
-            name TEXT NOT NULL,
-			city TEXT NOT NULL,
-			city_id TEXT NOT NULL,
-			region TEXT,
-			state TEXT,
-			sticker BOOLEAN DEFAULT FALSE
-        );
-		CREATE INDEX IF NOT EXISTS idx_tg_id ON users(tg_id);
-		CREATE TABLE IF NOT EXISTS weather (
-    		city_id INT PRIMARY KEY,
-    		forecast JSONB NOT NULL,
-    		updated_at TIMESTAMP DEFAULT NOW()
-		);
-		CREATE TABLE IF NOT EXISTS cities (
-    		id SERIAL PRIMARY KEY,
-    		name TEXT NOT NULL,
-    		federal_district TEXT,
-    		region TEXT,
-    		city_district TEXT,
-    		street TEXT
-		);`,
-		`ALTER TABLE users ALTER COLUMN tg_id SET DATA TYPE BIGINT;`,
-		`ALTER TABLE users ALTER COLUMN chat_id SET DATA TYPE BIGINT;`,
-		`ALTER TABLE cities ADD COLUMN IF NOT EXISTS country TEXT;`,
+// Инициализация PostgreSQL: подключение и применение новых миграций
+func Init(ctx context.Context, url string) (*pgxpool.Pool, error) {
+	pool, err := Connect(ctx, url)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, query := range queries {
-		if _, err := pool.Exec(context.Background(), query); err != nil {
-			log.Debug().Msgf("Ошибка выполнения запроса: %s, ошибка: %v", query, err)
-			return err
-		}
-
+	migrator, err := NewMigrator(pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("Ошибка применения миграций: %w", err)
 	}
 
-	return nil
+	return pool, nil
 }
 
 func (db *Database) Close() {
@@ -0,0 +1,27 @@
+-- Таблицы первой версии бота. IF NOT EXISTS - базы, созданные до миграций, уже содержат их
+CREATE TABLE IF NOT EXISTS users (
+	tg_id INT PRIMARY KEY,
+	chat_id INT NOT NULL,
+	name TEXT NOT NULL,
+	city TEXT NOT NULL,
+	city_id TEXT NOT NULL,
+	region TEXT,
+	state TEXT,
+	sticker BOOLEAN DEFAULT FALSE
+);
+CREATE INDEX IF NOT EXISTS idx_tg_id ON users(tg_id);
+
+CREATE TABLE IF NOT EXISTS weather (
+	city_id INT PRIMARY KEY,
+	forecast JSONB NOT NULL,
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS cities (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	federal_district TEXT,
+	region TEXT,
+	city_district TEXT,
+	street TEXT
+);
@@ -0,0 +1,2 @@
+ALTER TABLE users ALTER COLUMN chat_id SET DATA TYPE INT;
+ALTER TABLE users ALTER COLUMN tg_id SET DATA TYPE INT;
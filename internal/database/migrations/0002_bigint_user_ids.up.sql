@@ -0,0 +1,3 @@
+-- Telegram ID не помещаются в INT
+ALTER TABLE users ALTER COLUMN tg_id SET DATA TYPE BIGINT;
+ALTER TABLE users ALTER COLUMN chat_id SET DATA TYPE BIGINT;
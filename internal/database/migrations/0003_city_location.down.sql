@@ -0,0 +1,4 @@
+ALTER TABLE cities DROP COLUMN IF EXISTS lon;
+ALTER TABLE cities DROP COLUMN IF EXISTS lat;
+ALTER TABLE cities DROP COLUMN IF EXISTS timezone;
+ALTER TABLE cities DROP COLUMN IF EXISTS country;
@@ -0,0 +1,5 @@
+-- Координаты для запроса прогноза и часовой пояс для деления дня на части
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS country TEXT;
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS timezone TEXT;
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS lat DOUBLE PRECISION;
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS lon DOUBLE PRECISION;
@@ -0,0 +1,11 @@
+-- Сохранённые места пользователя ("Дом", "Дача") и черновик диалога
+ALTER TABLE users ADD COLUMN IF NOT EXISTS draft TEXT;
+
+CREATE TABLE IF NOT EXISTS user_cities (
+	user_id BIGINT NOT NULL,
+	name TEXT NOT NULL,
+	city TEXT NOT NULL,
+	city_id TEXT NOT NULL,
+	region TEXT,
+	PRIMARY KEY (user_id, name)
+);
@@ -0,0 +1,12 @@
+-- Подписки на уведомления: город, время, дни недели (битовая маска) и время для выходных
+CREATE TABLE IF NOT EXISTS subscriptions (
+	user_id BIGINT NOT NULL,
+	id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	city TEXT NOT NULL,
+	city_id TEXT NOT NULL,
+	time TEXT NOT NULL,
+	days SMALLINT NOT NULL DEFAULT 0,
+	weekend_time TEXT,
+	PRIMARY KEY (user_id, id)
+);
@@ -0,0 +1,3 @@
+-- Пользователи, заблокировавшие бота, не получают уведомлений до следующего сообщения
+ALTER TABLE users ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS blocked_at TIMESTAMPTZ;
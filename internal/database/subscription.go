@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/models"
+
+	"github.com/rs/zerolog/log"
+)
+
+var _ storage.SubscriptionStorage = (*Database)(nil)
+
+func (d *Database) SaveSubscription(ctx context.Context, userID int64, sub models.Subscription) error {
+	_, err := d.pool.Exec(ctx, `
+		INSERT INTO subscriptions (user_id, id, name, city, city_id, time, days, weekend_time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, id) DO UPDATE SET name = $3, city = $4, city_id = $5, time = $6, days = $7, weekend_time = $8`,
+		userID, sub.ID, sub.Name, sub.City, sub.CityID, sub.Time, int16(sub.Days), sub.WeekendTime)
+	if err != nil {
+		return fmt.Errorf("ошибка записи подписки в БД: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) GetSubscriptions(ctx context.Context, userID int64) ([]models.Subscription, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT id, name, city, city_id, time, days, COALESCE(weekend_time, '')
+		FROM subscriptions
+		WHERE user_id = $1
+		ORDER BY time, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения подписок из БД: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []models.Subscription
+	for rows.Next() {
+		var sub models.Subscription
+		var days int16
+		if err := rows.Scan(&sub.ID, &sub.Name, &sub.City, &sub.CityID, &sub.Time, &days, &sub.WeekendTime); err != nil {
+			log.Error().Err(err).Int64("userID", userID).Msg("Ошибка чтения подписки из БД")
+			continue
+		}
+		sub.Days = models.Weekdays(days)
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+func (d *Database) RemoveSubscription(ctx context.Context, userID int64, id string) error {
+	_, err := d.pool.Exec(ctx, "DELETE FROM subscriptions WHERE user_id = $1 AND id = $2", userID, id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления подписки из БД: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"time"
 	"weather-bot/internal/app/storage"
 	"weather-bot/internal/models"
 
@@ -13,12 +14,12 @@ import (
 var _ storage.UserStorage = (*Database)(nil)
 
 // SaveUser записывает или обновляет пользователя в БД
-func (d *Database) SaveUser(u *models.User) error {
-	_, err := d.pool.Exec(context.Background(), `
-		INSERT INTO users (tg_id, chat_id, name, city, city_id, region, state, sticker) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
-		ON CONFLICT (tg_id) DO UPDATE SET chat_id = $2, name = $3, city = $4, city_id = $5, region = $6, state = $7, sticker = $8`,
-		u.TgID, u.ChatID, u.Name, u.City, u.CityID, u.Region, u.State, u.Sticker)
+func (d *Database) SaveUser(ctx context.Context, u *models.User) error {
+	_, err := d.pool.Exec(ctx, `
+		INSERT INTO users (tg_id, chat_id, name, city, city_id, region, state, sticker, draft, active, blocked_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) 
+		ON CONFLICT (tg_id) DO UPDATE SET chat_id = $2, name = $3, city = $4, city_id = $5, region = $6, state = $7, sticker = $8, draft = $9, active = $10, blocked_at = $11`,
+		u.TgID, u.ChatID, u.Name, u.City, u.CityID, u.Region, u.State, u.Sticker, u.Draft, u.Active, blockedAt(u))
 	if err != nil {
 		log.Error().Err(err).Msg("Ошибка записи юзера в БД")
 	}
@@ -28,14 +29,23 @@ func (d *Database) SaveUser(u *models.User) error {
 	return nil
 }
 
-func (d *Database) GetUser(userID int64) (*models.User, error) {
+// blockedAt - время блокировки для колонки blocked_at, NULL для активных
+func blockedAt(u *models.User) *time.Time {
+	if u.BlockedAt == 0 {
+		return nil
+	}
+	t := time.Unix(u.BlockedAt, 0)
+	return &t
+}
+
+func (d *Database) GetUser(ctx context.Context, userID int64) (*models.User, error) {
 	var user models.User
 
-	err := d.pool.QueryRow(context.Background(), `
-	SELECT tg_id, name, city, city_id, region, state, sticker
+	err := d.pool.QueryRow(ctx, `
+	SELECT tg_id, chat_id, name, city, city_id, region, state, sticker, COALESCE(draft, ''), active, COALESCE(EXTRACT(EPOCH FROM blocked_at)::BIGINT, 0)
 	FROM users
 	WHERE tg_id = $1
-`, userID).Scan(&user.TgID, &user.Name, &user.City, &user.CityID, &user.Region, &user.State, &user.Sticker)
+`, userID).Scan(&user.TgID, &user.ChatID, &user.Name, &user.City, &user.CityID, &user.Region, &user.State, &user.Sticker, &user.Draft, &user.Active, &user.BlockedAt)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -48,3 +58,46 @@ func (d *Database) GetUser(userID int64) (*models.User, error) {
 
 	return &user, nil
 }
+
+func (d *Database) SaveUserCity(ctx context.Context, userID int64, city models.UserCity) error {
+	_, err := d.pool.Exec(ctx, `
+		INSERT INTO user_cities (user_id, name, city, city_id, region)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, name) DO UPDATE SET city = $3, city_id = $4, region = $5`,
+		userID, city.Name, city.City, city.CityID, city.Region)
+	if err != nil {
+		return fmt.Errorf("ошибка записи места в БД: %w", err)
+	}
+	return nil
+}
+
+func (d *Database) GetUserCities(ctx context.Context, userID int64) ([]models.UserCity, error) {
+	rows, err := d.pool.Query(ctx, `
+		SELECT name, city, city_id, COALESCE(region, '')
+		FROM user_cities
+		WHERE user_id = $1
+		ORDER BY name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения мест из БД: %w", err)
+	}
+	defer rows.Close()
+
+	var cities []models.UserCity
+	for rows.Next() {
+		var city models.UserCity
+		if err := rows.Scan(&city.Name, &city.City, &city.CityID, &city.Region); err != nil {
+			log.Error().Err(err).Int64("userID", userID).Msg("Ошибка чтения места из БД")
+			continue
+		}
+		cities = append(cities, city)
+	}
+	return cities, nil
+}
+
+func (d *Database) RemoveUserCity(ctx context.Context, userID int64, name string) error {
+	_, err := d.pool.Exec(ctx, "DELETE FROM user_cities WHERE user_id = $1 AND name = $2", userID, name)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления места из БД: %w", err)
+	}
+	return nil
+}
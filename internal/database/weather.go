@@ -10,9 +10,9 @@ import (
 
 var _ storage.WeatherStorage = (*Database)(nil)
 
-func (d *Database) GetWeather(cityID int) (*models.ProcessedForecast, error) {
+func (d *Database) GetWeather(ctx context.Context, cityID int) (*models.ProcessedForecast, error) {
 	var forecastJSON string
-	err := d.pool.QueryRow(context.Background(), "SELECT forecast FROM weather WHERE city_id = $1", cityID).Scan(&forecastJSON)
+	err := d.pool.QueryRow(ctx, "SELECT forecast FROM weather WHERE city_id = $1", cityID).Scan(&forecastJSON)
 	if err != nil {
 		return nil, err
 	}
@@ -25,12 +25,12 @@ func (d *Database) GetWeather(cityID int) (*models.ProcessedForecast, error) {
 	return &forecast, nil
 }
 
-func (d *Database) SaveWeather(cityID int, forecast *models.ProcessedForecast) error {
+func (d *Database) SaveWeather(ctx context.Context, cityID int, forecast *models.ProcessedForecast) error {
 	data, err := json.Marshal(forecast)
 	if err != nil {
 		return fmt.Errorf("ошибка сериализации данных: %w", err)
 	}
-	_, err = d.pool.Exec(context.Background(), `
+	_, err = d.pool.Exec(ctx, `
     INSERT INTO weather (city_id, forecast, updated_at) 
     VALUES ($1, $2, NOW()) 
     ON CONFLICT (city_id) DO UPDATE 
@@ -41,7 +41,7 @@ func (d *Database) SaveWeather(cityID int, forecast *models.ProcessedForecast) e
 	return nil
 }
 
-func (d *Database) CleanupOldWeatherData() error {
-	_, err := d.pool.Exec(context.Background(), "DELETE FROM weather WHERE updated_at < NOW() - INTERVAL '2 days'")
+func (d *Database) CleanupOldWeatherData(ctx context.Context) error {
+	_, err := d.pool.Exec(ctx, "DELETE FROM weather WHERE updated_at < NOW() - INTERVAL '2 days'")
 	return err
 }
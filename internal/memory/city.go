@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/models"
+	"weather-bot/pkg/utils"
+)
+
+func (s *Storage) SaveCity(ctx context.Context, city models.City) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.cities[city.ID]; !exists {
+		s.cityNames[city.Name] = append(s.cityNames[city.Name], city.ID)
+	}
+	s.cities[city.ID] = city
+	return nil
+}
+
+// GetCities возвращает города с таким названием, по одному на регион, как и Redis
+func (s *Storage) GetCities(ctx context.Context, name string) ([]models.City, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var result []models.City
+	for _, id := range s.cityNames[name] {
+		city := s.cities[id]
+		if seen[city.Region] {
+			continue
+		}
+		seen[city.Region] = true
+		result = append(result, city)
+	}
+	return result, nil
+}
+
+func (s *Storage) GetCity(ctx context.Context, id int) (*models.City, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	city, ok := s.cities[id]
+	if !ok {
+		return nil, fmt.Errorf("город %d не найден", id)
+	}
+	return &city, nil
+}
+
+func (s *Storage) GetNearestCity(ctx context.Context, lat, lon float64) (*models.City, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var nearest *models.City
+	var nearestDistance float64
+	for _, city := range s.cities {
+		if city.Lat == 0 && city.Lon == 0 {
+			continue
+		}
+		distance := utils.Distance(lat, lon, city.Lat, city.Lon)
+		if distance > storage.NearestCityRadiusKm {
+			continue
+		}
+		if nearest == nil || distance < nearestDistance {
+			city := city
+			nearest, nearestDistance = &city, distance
+		}
+	}
+	if nearest == nil {
+		return nil, 0, fmt.Errorf("в радиусе %d км нет городов", storage.NearestCityRadiusKm)
+	}
+	return nearest, nearestDistance, nil
+}
+
+func (s *Storage) GetCitiesNames(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.cityNames))
+	for name := range s.cityNames {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// GetCitiesIds возвращает города активных пользователей и их сохранённых мест
+func (s *Storage) GetCitiesIds(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cityIDs []string
+	seen := make(map[string]bool)
+	add := func(cityID string) {
+		if cityID != "" && !seen[cityID] {
+			seen[cityID] = true
+			cityIDs = append(cityIDs, cityID)
+		}
+	}
+
+	for id, user := range s.users {
+		if !user.Active {
+			continue
+		}
+		add(user.CityID)
+		for _, place := range s.userCities[id] {
+			add(place.CityID)
+		}
+	}
+	return cityIDs, nil
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/models"
+)
+
+var (
+	_ storage.Cache    = (*Storage)(nil)
+	_ storage.Database = (*Storage)(nil)
+)
+
+// Storage - хранилище в памяти процесса, заменяет и Redis, и Postgres.
+// Данные теряются при перезапуске, поэтому подходит для тестов и локального запуска.
+type Storage struct {
+	mu         sync.Mutex
+	weatherTTL time.Duration
+	claimIdle  time.Duration
+
+	cities      map[int]models.City
+	cityNames   map[string][]int // ID городов по названию в порядке добавления
+	users       map[int64]models.User
+	userCities  map[int64]map[string]models.UserCity
+	subs        map[int64]map[string]models.Subscription
+	weather     map[int]forecastEntry
+	queues      map[string]*queue
+	once        map[string]time.Time // Ключ идемпотентности и время его истечения
+	deadLetters []storage.DeadLetter
+	seq         int64 // Счётчик ID доставок и записей очереди недоставленных
+}
+
+type forecastEntry struct {
+	forecast  models.ProcessedForecast
+	expiresAt time.Time
+}
+
+// NewStorage - хранилище в памяти, прогноз погоды хранится weatherTTL
+func NewStorage(weatherTTL time.Duration) *Storage {
+	return &Storage{
+		weatherTTL: weatherTTL,
+		claimIdle:  defaultClaimIdle,
+		cities:     make(map[int]models.City),
+		cityNames:  make(map[string][]int),
+		users:      make(map[int64]models.User),
+		userCities: make(map[int64]map[string]models.UserCity),
+		subs:       make(map[int64]map[string]models.Subscription),
+		weather:    make(map[int]forecastEntry),
+		queues:     make(map[string]*queue),
+		once:       make(map[string]time.Time),
+	}
+}
+
+// Память всегда доступна
+func (s *Storage) HealthCheck(ctx context.Context) {}
+
+func (s *Storage) IsHealthy() bool {
+	return true
+}
+
+// CleanupOldWeatherData удаляет истёкшие прогнозы
+func (s *Storage) CleanupOldWeatherData(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, entry := range s.weather {
+		if now.After(entry.expiresAt) {
+			delete(s.weather, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"weather-bot/internal/app/storage"
+)
+
+// Выданную и не подтверждённую дольше этого времени задачу получает следующий вызов Due, как в Redis
+const defaultClaimIdle = 5 * time.Minute
+
+// SetClaimIdle меняет время, после которого неподтверждённую задачу забирает другой воркер,
+// чтобы тесты могли проверить перехват задачи, не дожидаясь пяти минут
+func (s *Storage) SetClaimIdle(idle time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claimIdle = idle
+}
+
+// queue - отложенные задачи (jobID -> executeAt) и задачи, выданные воркерам и ещё не подтверждённые
+type queue struct {
+	scheduled    map[string]int64
+	attempts     map[string]int
+	scheduledFor map[string]int64      // Исходное время повторяемых задач
+	pending      map[string]pendingJob // По ID доставки
+}
+
+type pendingJob struct {
+	job         storage.Job
+	deliveredAt time.Time
+}
+
+// queue возвращает очередь, создавая её при первом обращении. Вызывается под s.mu.
+func (s *Storage) queue(name string) *queue {
+	q, ok := s.queues[name]
+	if !ok {
+		q = &queue{
+			scheduled:    make(map[string]int64),
+			attempts:     make(map[string]int),
+			scheduledFor: make(map[string]int64),
+			pending:      make(map[string]pendingJob),
+		}
+		s.queues[name] = q
+	}
+	return q
+}
+
+func (s *Storage) nextID() string {
+	s.seq++
+	return strconv.FormatInt(s.seq, 10)
+}
+
+// Schedule откладывает задачу до executeAt. Это новое время задачи, а не повтор.
+func (s *Storage) Schedule(ctx context.Context, queue, jobID string, executeAt int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.queue(queue)
+	q.scheduled[jobID] = executeAt
+	delete(q.scheduledFor, jobID)
+	return nil
+}
+
+func (s *Storage) Due(ctx context.Context, queue, consumer string, now int64, limit int) ([]storage.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.queue(queue)
+	var jobs []storage.Job
+
+	// Сначала задачи, которые взял и не подтвердил упавший воркер
+	for delivery, p := range q.pending {
+		if len(jobs) == limit {
+			return jobs, nil
+		}
+		if time.Since(p.deliveredAt) >= s.claimIdle {
+			p.deliveredAt = time.Now()
+			q.pending[delivery] = p
+			jobs = append(jobs, p.job)
+		}
+	}
+
+	var due []string
+	for jobID, executeAt := range q.scheduled {
+		if executeAt <= now {
+			due = append(due, jobID)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return q.scheduled[due[i]] < q.scheduled[due[j]]
+	})
+
+	for _, jobID := range due {
+		if len(jobs) == limit {
+			break
+		}
+		job := storage.Job{ID: jobID, ExecuteAt: q.scheduled[jobID], Delivery: s.nextID(), Attempt: q.attempts[jobID]}
+		job.ScheduledFor = job.ExecuteAt
+		if scheduledFor, ok := q.scheduledFor[jobID]; ok {
+			job.ScheduledFor = scheduledFor
+		}
+		delete(q.scheduled, jobID)
+		q.pending[job.Delivery] = pendingJob{job: job, deliveredAt: time.Now()}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (s *Storage) Ack(ctx context.Context, queue string, job storage.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.queue(queue)
+	delete(q.pending, job.Delivery)
+	delete(q.attempts, job.ID)
+	delete(q.scheduledFor, job.ID)
+	return nil
+}
+
+// Cancel убирает задачу из отложенных. Уже выданную воркеру задачу воркер проверяет сам.
+func (s *Storage) Cancel(ctx context.Context, queue, jobID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.queue(queue)
+	delete(q.scheduled, jobID)
+	delete(q.attempts, jobID)
+	delete(q.scheduledFor, jobID)
+	return nil
+}
+
+func (s *Storage) ScheduledAt(ctx context.Context, queue, jobID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.queue(queue).scheduled[jobID], nil
+}
+
+func (s *Storage) QueueLength(ctx context.Context, queue string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(s.queue(queue).scheduled)), nil
+}
+
+func (s *Storage) ClaimOnce(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expiresAt, ok := s.once[key]; ok && time.Now().Before(expiresAt) {
+		return false, nil
+	}
+	s.once[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (s *Storage) ReleaseOnce(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.once, key)
+	return nil
+}
+
+// Retry подтверждает выданную задачу и снова откладывает её до executeAt со следующим номером попытки,
+// исходное время задачи сохраняется
+func (s *Storage) Retry(ctx context.Context, queue string, job storage.Job, executeAt int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.queue(queue)
+	delete(q.pending, job.Delivery)
+	q.attempts[job.ID] = job.Attempt + 1
+	if _, ok := q.scheduledFor[job.ID]; !ok {
+		q.scheduledFor[job.ID] = job.ScheduledFor
+	}
+	q.scheduled[job.ID] = executeAt
+	return nil
+}
+
+// DeadLetter подтверждает задачу и записывает её в очередь недоставленных вместе с причиной
+func (s *Storage) DeadLetter(ctx context.Context, queue string, job storage.Job, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.queue(queue)
+	delete(q.pending, job.Delivery)
+	delete(q.attempts, job.ID)
+	delete(q.scheduledFor, job.ID)
+	s.deadLetters = append(s.deadLetters, storage.DeadLetter{
+		ID:       s.nextID(),
+		Queue:    queue,
+		JobID:    job.ID,
+		Reason:   reason,
+		Attempts: job.Attempt + 1,
+		FailedAt: time.Now().Unix(),
+	})
+	return nil
+}
+
+func (s *Storage) DeadLetters(ctx context.Context, before string, limit int) ([]storage.DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// ID записей - растущие номера, как ID стрима в Redis
+	var end int64 = math.MaxInt64
+	if before != "" {
+		var err error
+		if end, err = strconv.ParseInt(before, 10, 64); err != nil {
+			return nil, fmt.Errorf("неверный ID записи очереди недоставленных %s: %w", before, err)
+		}
+	}
+
+	letters := make([]storage.DeadLetter, 0, min(limit, len(s.deadLetters)))
+	for i := len(s.deadLetters) - 1; i >= 0 && len(letters) < limit; i-- {
+		if id, _ := strconv.ParseInt(s.deadLetters[i].ID, 10, 64); id < end {
+			letters = append(letters, s.deadLetters[i])
+		}
+	}
+	return letters, nil
+}
+
+func (s *Storage) GetDeadLetter(ctx context.Context, id string) (*storage.DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, letter := range s.deadLetters {
+		if letter.ID == id {
+			return &letter, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Storage) RemoveDeadLetter(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, letter := range s.deadLetters {
+		if letter.ID == id {
+			s.deadLetters = append(s.deadLetters[:i], s.deadLetters[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"weather-bot/internal/models"
+)
+
+func (s *Storage) SaveSubscription(ctx context.Context, userID int64, sub models.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subs[userID] == nil {
+		s.subs[userID] = make(map[string]models.Subscription)
+	}
+	s.subs[userID][sub.ID] = sub
+	return nil
+}
+
+func (s *Storage) GetSubscriptions(ctx context.Context, userID int64) ([]models.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := make([]models.Subscription, 0, len(s.subs[userID]))
+	for _, sub := range s.subs[userID] {
+		subs = append(subs, sub)
+	}
+
+	// Тот же порядок, что и в Redis и БД
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Time != subs[j].Time {
+			return subs[i].Time < subs[j].Time
+		}
+		return subs[i].ID < subs[j].ID
+	})
+	return subs, nil
+}
+
+func (s *Storage) RemoveSubscription(ctx context.Context, userID int64, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subs[userID], id)
+	return nil
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"weather-bot/internal/models"
+)
+
+func (s *Storage) SaveUser(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[user.TgID] = *user
+	return nil
+}
+
+// GetUser возвращает копию пользователя или nil, если его нет
+func (s *Storage) GetUser(ctx context.Context, userID int64) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (s *Storage) SaveUserCity(ctx context.Context, userID int64, city models.UserCity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userCities[userID] == nil {
+		s.userCities[userID] = make(map[string]models.UserCity)
+	}
+	s.userCities[userID][city.Name] = city
+	return nil
+}
+
+func (s *Storage) GetUserCities(ctx context.Context, userID int64) ([]models.UserCity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cities := make([]models.UserCity, 0, len(s.userCities[userID]))
+	for _, city := range s.userCities[userID] {
+		cities = append(cities, city)
+	}
+	sort.Slice(cities, func(i, j int) bool {
+		return cities[i].Name < cities[j].Name
+	})
+	return cities, nil
+}
+
+func (s *Storage) RemoveUserCity(ctx context.Context, userID int64, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.userCities[userID], name)
+	return nil
+}
@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"weather-bot/internal/models"
+)
+
+func (s *Storage) GetWeather(ctx context.Context, cityID int) (*models.ProcessedForecast, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.weather[cityID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, fmt.Errorf("нет прогноза для города %d", cityID)
+	}
+	forecast := entry.forecast
+	return &forecast, nil
+}
+
+func (s *Storage) SaveWeather(ctx context.Context, cityID int, forecast *models.ProcessedForecast) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.weather[cityID] = forecastEntry{forecast: *forecast, expiresAt: time.Now().Add(s.weatherTTL)}
+	return nil
+}
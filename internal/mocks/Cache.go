@@ -3,11 +3,14 @@
 package mocks
 
 import (
+	context "context"
 	models "weather-bot/internal/models"
 
 	mock "github.com/stretchr/testify/mock"
 
-	redis "github.com/redis/go-redis/v9"
+	storage "weather-bot/internal/app/storage"
+
+	time "time"
 )
 
 // Cache is an autogenerated mock type for the Cache type
@@ -15,9 +18,151 @@ type Cache struct {
 	mock.Mock
 }
 
-// GetCities provides a mock function with given fields: _a0
-func (_m *Cache) GetCities(_a0 string) ([]models.City, error) {
-	ret := _m.Called(_a0)
+// Ack provides a mock function with given fields: ctx, queue, job
+func (_m *Cache) Ack(ctx context.Context, queue string, job storage.Job) error {
+	ret := _m.Called(ctx, queue, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ack")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Job) error); ok {
+		r0 = rf(ctx, queue, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Cancel provides a mock function with given fields: ctx, queue, jobID
+func (_m *Cache) Cancel(ctx context.Context, queue string, jobID string) error {
+	ret := _m.Called(ctx, queue, jobID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Cancel")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, queue, jobID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClaimOnce provides a mock function with given fields: ctx, key, ttl
+func (_m *Cache) ClaimOnce(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ret := _m.Called(ctx, key, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimOnce")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) (bool, error)); ok {
+		return rf(ctx, key, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) bool); ok {
+		r0 = rf(ctx, key, ttl)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, key, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeadLetter provides a mock function with given fields: ctx, queue, job, reason
+func (_m *Cache) DeadLetter(ctx context.Context, queue string, job storage.Job, reason string) error {
+	ret := _m.Called(ctx, queue, job, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeadLetter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Job, string) error); ok {
+		r0 = rf(ctx, queue, job, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeadLetters provides a mock function with given fields: ctx, before, limit
+func (_m *Cache) DeadLetters(ctx context.Context, before string, limit int) ([]storage.DeadLetter, error) {
+	ret := _m.Called(ctx, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeadLetters")
+	}
+
+	var r0 []storage.DeadLetter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]storage.DeadLetter, error)); ok {
+		return rf(ctx, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []storage.DeadLetter); ok {
+		r0 = rf(ctx, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.DeadLetter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Due provides a mock function with given fields: ctx, queue, consumer, now, limit
+func (_m *Cache) Due(ctx context.Context, queue string, consumer string, now int64, limit int) ([]storage.Job, error) {
+	ret := _m.Called(ctx, queue, consumer, now, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Due")
+	}
+
+	var r0 []storage.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, int) ([]storage.Job, error)); ok {
+		return rf(ctx, queue, consumer, now, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, int) []storage.Job); ok {
+		r0 = rf(ctx, queue, consumer, now, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64, int) error); ok {
+		r1 = rf(ctx, queue, consumer, now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCities provides a mock function with given fields: ctx, name
+func (_m *Cache) GetCities(ctx context.Context, name string) ([]models.City, error) {
+	ret := _m.Called(ctx, name)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCities")
@@ -25,19 +170,19 @@ func (_m *Cache) GetCities(_a0 string) ([]models.City, error) {
 
 	var r0 []models.City
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]models.City, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]models.City, error)); ok {
+		return rf(ctx, name)
 	}
-	if rf, ok := ret.Get(0).(func(string) []models.City); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.City); ok {
+		r0 = rf(ctx, name)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.City)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -45,9 +190,9 @@ func (_m *Cache) GetCities(_a0 string) ([]models.City, error) {
 	return r0, r1
 }
 
-// GetCitiesIds provides a mock function with no fields
-func (_m *Cache) GetCitiesIds() ([]string, error) {
-	ret := _m.Called()
+// GetCitiesIds provides a mock function with given fields: ctx
+func (_m *Cache) GetCitiesIds(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCitiesIds")
@@ -55,19 +200,19 @@ func (_m *Cache) GetCitiesIds() ([]string, error) {
 
 	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]string, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func() []string); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -75,9 +220,9 @@ func (_m *Cache) GetCitiesIds() ([]string, error) {
 	return r0, r1
 }
 
-// GetCitiesNames provides a mock function with no fields
-func (_m *Cache) GetCitiesNames() ([]string, error) {
-	ret := _m.Called()
+// GetCitiesNames provides a mock function with given fields: ctx
+func (_m *Cache) GetCitiesNames(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCitiesNames")
@@ -85,19 +230,19 @@ func (_m *Cache) GetCitiesNames() ([]string, error) {
 
 	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]string, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func() []string); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -105,29 +250,29 @@ func (_m *Cache) GetCitiesNames() ([]string, error) {
 	return r0, r1
 }
 
-// GetScheduleUserNotifications provides a mock function with no fields
-func (_m *Cache) GetScheduleUserNotifications() ([]redis.XStream, error) {
-	ret := _m.Called()
+// GetCity provides a mock function with given fields: ctx, id
+func (_m *Cache) GetCity(ctx context.Context, id int) (*models.City, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetScheduleUserNotifications")
+		panic("no return value specified for GetCity")
 	}
 
-	var r0 []redis.XStream
+	var r0 *models.City
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]redis.XStream, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.City, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func() []redis.XStream); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.City); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]redis.XStream)
+			r0 = ret.Get(0).(*models.City)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -135,29 +280,29 @@ func (_m *Cache) GetScheduleUserNotifications() ([]redis.XStream, error) {
 	return r0, r1
 }
 
-// GetScheduleWeatherUpdate provides a mock function with no fields
-func (_m *Cache) GetScheduleWeatherUpdate() ([]redis.XStream, error) {
-	ret := _m.Called()
+// GetDeadLetter provides a mock function with given fields: ctx, id
+func (_m *Cache) GetDeadLetter(ctx context.Context, id string) (*storage.DeadLetter, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetScheduleWeatherUpdate")
+		panic("no return value specified for GetDeadLetter")
 	}
 
-	var r0 []redis.XStream
+	var r0 *storage.DeadLetter
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]redis.XStream, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*storage.DeadLetter, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func() []redis.XStream); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, string) *storage.DeadLetter); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]redis.XStream)
+			r0 = ret.Get(0).(*storage.DeadLetter)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -165,9 +310,76 @@ func (_m *Cache) GetScheduleWeatherUpdate() ([]redis.XStream, error) {
 	return r0, r1
 }
 
-// GetUser provides a mock function with given fields: _a0
-func (_m *Cache) GetUser(_a0 int64) (*models.User, error) {
-	ret := _m.Called(_a0)
+// GetNearestCity provides a mock function with given fields: ctx, lat, lon
+func (_m *Cache) GetNearestCity(ctx context.Context, lat float64, lon float64) (*models.City, float64, error) {
+	ret := _m.Called(ctx, lat, lon)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetNearestCity")
+	}
+
+	var r0 *models.City
+	var r1 float64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, float64, float64) (*models.City, float64, error)); ok {
+		return rf(ctx, lat, lon)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, float64, float64) *models.City); ok {
+		r0 = rf(ctx, lat, lon)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.City)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, float64, float64) float64); ok {
+		r1 = rf(ctx, lat, lon)
+	} else {
+		r1 = ret.Get(1).(float64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, float64, float64) error); ok {
+		r2 = rf(ctx, lat, lon)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetSubscriptions provides a mock function with given fields: ctx, userID
+func (_m *Cache) GetSubscriptions(ctx context.Context, userID int64) ([]models.Subscription, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubscriptions")
+	}
+
+	var r0 []models.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]models.Subscription, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []models.Subscription); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUser provides a mock function with given fields: ctx, userID
+func (_m *Cache) GetUser(ctx context.Context, userID int64) (*models.User, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetUser")
@@ -175,19 +387,19 @@ func (_m *Cache) GetUser(_a0 int64) (*models.User, error) {
 
 	var r0 *models.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (*models.User, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*models.User, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(int64) *models.User); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *models.User); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -195,27 +407,29 @@ func (_m *Cache) GetUser(_a0 int64) (*models.User, error) {
 	return r0, r1
 }
 
-// GetUserNotificationTime provides a mock function with given fields: _a0
-func (_m *Cache) GetUserNotificationTime(_a0 int64) (string, error) {
-	ret := _m.Called(_a0)
+// GetUserCities provides a mock function with given fields: ctx, userID
+func (_m *Cache) GetUserCities(ctx context.Context, userID int64) ([]models.UserCity, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserNotificationTime")
+		panic("no return value specified for GetUserCities")
 	}
 
-	var r0 string
+	var r0 []models.UserCity
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (string, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]models.UserCity, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(int64) string); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []models.UserCity); ok {
+		r0 = rf(ctx, userID)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UserCity)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -223,9 +437,9 @@ func (_m *Cache) GetUserNotificationTime(_a0 int64) (string, error) {
 	return r0, r1
 }
 
-// GetWeather provides a mock function with given fields: _a0
-func (_m *Cache) GetWeather(_a0 int) (*models.ProcessedForecast, error) {
-	ret := _m.Called(_a0)
+// GetWeather provides a mock function with given fields: ctx, cityID
+func (_m *Cache) GetWeather(ctx context.Context, cityID int) (*models.ProcessedForecast, error) {
+	ret := _m.Called(ctx, cityID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetWeather")
@@ -233,19 +447,19 @@ func (_m *Cache) GetWeather(_a0 int) (*models.ProcessedForecast, error) {
 
 	var r0 *models.ProcessedForecast
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) (*models.ProcessedForecast, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.ProcessedForecast, error)); ok {
+		return rf(ctx, cityID)
 	}
-	if rf, ok := ret.Get(0).(func(int) *models.ProcessedForecast); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.ProcessedForecast); ok {
+		r0 = rf(ctx, cityID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.ProcessedForecast)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, cityID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -253,9 +467,9 @@ func (_m *Cache) GetWeather(_a0 int) (*models.ProcessedForecast, error) {
 	return r0, r1
 }
 
-// HealthCheck provides a mock function with no fields
-func (_m *Cache) HealthCheck() {
-	_m.Called()
+// HealthCheck provides a mock function with given fields: ctx
+func (_m *Cache) HealthCheck(ctx context.Context) {
+	_m.Called(ctx)
 }
 
 // IsHealthy provides a mock function with no fields
@@ -276,17 +490,45 @@ func (_m *Cache) IsHealthy() bool {
 	return r0
 }
 
-// RemoveUserNotification provides a mock function with given fields: _a0
-func (_m *Cache) RemoveUserNotification(_a0 int64) error {
-	ret := _m.Called(_a0)
+// QueueLength provides a mock function with given fields: ctx, queue
+func (_m *Cache) QueueLength(ctx context.Context, queue string) (int64, error) {
+	ret := _m.Called(ctx, queue)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RemoveUserNotification")
+		panic("no return value specified for QueueLength")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+		return rf(ctx, queue)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, queue)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, queue)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseOnce provides a mock function with given fields: ctx, key
+func (_m *Cache) ReleaseOnce(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseOnce")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -294,17 +536,71 @@ func (_m *Cache) RemoveUserNotification(_a0 int64) error {
 	return r0
 }
 
-// RemoveWeatherUpdate provides a mock function with no fields
-func (_m *Cache) RemoveWeatherUpdate() error {
-	ret := _m.Called()
+// RemoveDeadLetter provides a mock function with given fields: ctx, id
+func (_m *Cache) RemoveDeadLetter(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RemoveWeatherUpdate")
+		panic("no return value specified for RemoveDeadLetter")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveSubscription provides a mock function with given fields: ctx, userID, id
+func (_m *Cache) RemoveSubscription(ctx context.Context, userID int64, id string) error {
+	ret := _m.Called(ctx, userID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveUserCity provides a mock function with given fields: ctx, userID, name
+func (_m *Cache) RemoveUserCity(ctx context.Context, userID int64, name string) error {
+	ret := _m.Called(ctx, userID, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveUserCity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, userID, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Retry provides a mock function with given fields: ctx, queue, job, executeAt
+func (_m *Cache) Retry(ctx context.Context, queue string, job storage.Job, executeAt int64) error {
+	ret := _m.Called(ctx, queue, job, executeAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Retry")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Job, int64) error); ok {
+		r0 = rf(ctx, queue, job, executeAt)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -312,17 +608,17 @@ func (_m *Cache) RemoveWeatherUpdate() error {
 	return r0
 }
 
-// SaveCity provides a mock function with given fields: _a0
-func (_m *Cache) SaveCity(_a0 models.City) error {
-	ret := _m.Called(_a0)
+// SaveCity provides a mock function with given fields: ctx, city
+func (_m *Cache) SaveCity(ctx context.Context, city models.City) error {
+	ret := _m.Called(ctx, city)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveCity")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(models.City) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, models.City) error); ok {
+		r0 = rf(ctx, city)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -330,17 +626,35 @@ func (_m *Cache) SaveCity(_a0 models.City) error {
 	return r0
 }
 
-// SaveUser provides a mock function with given fields: _a0
-func (_m *Cache) SaveUser(_a0 *models.User) error {
-	ret := _m.Called(_a0)
+// SaveSubscription provides a mock function with given fields: ctx, userID, sub
+func (_m *Cache) SaveSubscription(ctx context.Context, userID int64, sub models.Subscription) error {
+	ret := _m.Called(ctx, userID, sub)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, models.Subscription) error); ok {
+		r0 = rf(ctx, userID, sub)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveUser provides a mock function with given fields: ctx, user
+func (_m *Cache) SaveUser(ctx context.Context, user *models.User) error {
+	ret := _m.Called(ctx, user)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveUser")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.User) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.User) error); ok {
+		r0 = rf(ctx, user)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -348,17 +662,17 @@ func (_m *Cache) SaveUser(_a0 *models.User) error {
 	return r0
 }
 
-// SaveWeather provides a mock function with given fields: _a0, _a1
-func (_m *Cache) SaveWeather(_a0 int, _a1 *models.ProcessedForecast) error {
-	ret := _m.Called(_a0, _a1)
+// SaveUserCity provides a mock function with given fields: ctx, userID, city
+func (_m *Cache) SaveUserCity(ctx context.Context, userID int64, city models.UserCity) error {
+	ret := _m.Called(ctx, userID, city)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SaveWeather")
+		panic("no return value specified for SaveUserCity")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int, *models.ProcessedForecast) error); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, models.UserCity) error); ok {
+		r0 = rf(ctx, userID, city)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -366,17 +680,17 @@ func (_m *Cache) SaveWeather(_a0 int, _a1 *models.ProcessedForecast) error {
 	return r0
 }
 
-// ScheduleUserNotification provides a mock function with given fields: _a0, _a1
-func (_m *Cache) ScheduleUserNotification(_a0 int64, _a1 int64) error {
-	ret := _m.Called(_a0, _a1)
+// SaveWeather provides a mock function with given fields: ctx, cityID, forecast
+func (_m *Cache) SaveWeather(ctx context.Context, cityID int, forecast *models.ProcessedForecast) error {
+	ret := _m.Called(ctx, cityID, forecast)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ScheduleUserNotification")
+		panic("no return value specified for SaveWeather")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64, int64) error); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, int, *models.ProcessedForecast) error); ok {
+		r0 = rf(ctx, cityID, forecast)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -384,17 +698,17 @@ func (_m *Cache) ScheduleUserNotification(_a0 int64, _a1 int64) error {
 	return r0
 }
 
-// ScheduleWeatherUpdate provides a mock function with given fields: _a0
-func (_m *Cache) ScheduleWeatherUpdate(_a0 int64) error {
-	ret := _m.Called(_a0)
+// Schedule provides a mock function with given fields: ctx, queue, jobID, executeAt
+func (_m *Cache) Schedule(ctx context.Context, queue string, jobID string, executeAt int64) error {
+	ret := _m.Called(ctx, queue, jobID, executeAt)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ScheduleWeatherUpdate")
+		panic("no return value specified for Schedule")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) error); ok {
+		r0 = rf(ctx, queue, jobID, executeAt)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -402,6 +716,34 @@ func (_m *Cache) ScheduleWeatherUpdate(_a0 int64) error {
 	return r0
 }
 
+// ScheduledAt provides a mock function with given fields: ctx, queue, jobID
+func (_m *Cache) ScheduledAt(ctx context.Context, queue string, jobID string) (int64, error) {
+	ret := _m.Called(ctx, queue, jobID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ScheduledAt")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (int64, error)); ok {
+		return rf(ctx, queue, jobID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int64); ok {
+		r0 = rf(ctx, queue, jobID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, queue, jobID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewCache creates a new instance of Cache. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCache(t interface {
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	context "context"
 	models "weather-bot/internal/models"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,17 +14,17 @@ type Database struct {
 	mock.Mock
 }
 
-// CleanupOldWeatherData provides a mock function with no fields
-func (_m *Database) CleanupOldWeatherData() error {
-	ret := _m.Called()
+// CleanupOldWeatherData provides a mock function with given fields: ctx
+func (_m *Database) CleanupOldWeatherData(ctx context.Context) error {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CleanupOldWeatherData")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -31,9 +32,9 @@ func (_m *Database) CleanupOldWeatherData() error {
 	return r0
 }
 
-// GetCities provides a mock function with given fields: _a0
-func (_m *Database) GetCities(_a0 string) ([]models.City, error) {
-	ret := _m.Called(_a0)
+// GetCities provides a mock function with given fields: ctx, name
+func (_m *Database) GetCities(ctx context.Context, name string) ([]models.City, error) {
+	ret := _m.Called(ctx, name)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCities")
@@ -41,19 +42,19 @@ func (_m *Database) GetCities(_a0 string) ([]models.City, error) {
 
 	var r0 []models.City
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]models.City, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]models.City, error)); ok {
+		return rf(ctx, name)
 	}
-	if rf, ok := ret.Get(0).(func(string) []models.City); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.City); ok {
+		r0 = rf(ctx, name)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.City)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -61,9 +62,9 @@ func (_m *Database) GetCities(_a0 string) ([]models.City, error) {
 	return r0, r1
 }
 
-// GetCitiesIds provides a mock function with no fields
-func (_m *Database) GetCitiesIds() ([]string, error) {
-	ret := _m.Called()
+// GetCitiesIds provides a mock function with given fields: ctx
+func (_m *Database) GetCitiesIds(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCitiesIds")
@@ -71,19 +72,19 @@ func (_m *Database) GetCitiesIds() ([]string, error) {
 
 	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]string, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func() []string); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -91,9 +92,9 @@ func (_m *Database) GetCitiesIds() ([]string, error) {
 	return r0, r1
 }
 
-// GetCitiesNames provides a mock function with no fields
-func (_m *Database) GetCitiesNames() ([]string, error) {
-	ret := _m.Called()
+// GetCitiesNames provides a mock function with given fields: ctx
+func (_m *Database) GetCitiesNames(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetCitiesNames")
@@ -101,19 +102,19 @@ func (_m *Database) GetCitiesNames() ([]string, error) {
 
 	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]string, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func() []string); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -121,9 +122,106 @@ func (_m *Database) GetCitiesNames() ([]string, error) {
 	return r0, r1
 }
 
-// GetUser provides a mock function with given fields: _a0
-func (_m *Database) GetUser(_a0 int64) (*models.User, error) {
-	ret := _m.Called(_a0)
+// GetCity provides a mock function with given fields: ctx, id
+func (_m *Database) GetCity(ctx context.Context, id int) (*models.City, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCity")
+	}
+
+	var r0 *models.City
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.City, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.City); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.City)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNearestCity provides a mock function with given fields: ctx, lat, lon
+func (_m *Database) GetNearestCity(ctx context.Context, lat float64, lon float64) (*models.City, float64, error) {
+	ret := _m.Called(ctx, lat, lon)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetNearestCity")
+	}
+
+	var r0 *models.City
+	var r1 float64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, float64, float64) (*models.City, float64, error)); ok {
+		return rf(ctx, lat, lon)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, float64, float64) *models.City); ok {
+		r0 = rf(ctx, lat, lon)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.City)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, float64, float64) float64); ok {
+		r1 = rf(ctx, lat, lon)
+	} else {
+		r1 = ret.Get(1).(float64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, float64, float64) error); ok {
+		r2 = rf(ctx, lat, lon)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetSubscriptions provides a mock function with given fields: ctx, userID
+func (_m *Database) GetSubscriptions(ctx context.Context, userID int64) ([]models.Subscription, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubscriptions")
+	}
+
+	var r0 []models.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]models.Subscription, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []models.Subscription); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUser provides a mock function with given fields: ctx, userID
+func (_m *Database) GetUser(ctx context.Context, userID int64) (*models.User, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetUser")
@@ -131,19 +229,49 @@ func (_m *Database) GetUser(_a0 int64) (*models.User, error) {
 
 	var r0 *models.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (*models.User, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*models.User, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(int64) *models.User); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *models.User); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserCities provides a mock function with given fields: ctx, userID
+func (_m *Database) GetUserCities(ctx context.Context, userID int64) ([]models.UserCity, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserCities")
+	}
+
+	var r0 []models.UserCity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]models.UserCity, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []models.UserCity); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.UserCity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -151,9 +279,9 @@ func (_m *Database) GetUser(_a0 int64) (*models.User, error) {
 	return r0, r1
 }
 
-// GetWeather provides a mock function with given fields: _a0
-func (_m *Database) GetWeather(_a0 int) (*models.ProcessedForecast, error) {
-	ret := _m.Called(_a0)
+// GetWeather provides a mock function with given fields: ctx, cityID
+func (_m *Database) GetWeather(ctx context.Context, cityID int) (*models.ProcessedForecast, error) {
+	ret := _m.Called(ctx, cityID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetWeather")
@@ -161,19 +289,19 @@ func (_m *Database) GetWeather(_a0 int) (*models.ProcessedForecast, error) {
 
 	var r0 *models.ProcessedForecast
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) (*models.ProcessedForecast, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.ProcessedForecast, error)); ok {
+		return rf(ctx, cityID)
 	}
-	if rf, ok := ret.Get(0).(func(int) *models.ProcessedForecast); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.ProcessedForecast); ok {
+		r0 = rf(ctx, cityID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.ProcessedForecast)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, cityID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -181,17 +309,71 @@ func (_m *Database) GetWeather(_a0 int) (*models.ProcessedForecast, error) {
 	return r0, r1
 }
 
-// SaveCity provides a mock function with given fields: _a0
-func (_m *Database) SaveCity(_a0 models.City) error {
-	ret := _m.Called(_a0)
+// RemoveSubscription provides a mock function with given fields: ctx, userID, id
+func (_m *Database) RemoveSubscription(ctx context.Context, userID int64, id string) error {
+	ret := _m.Called(ctx, userID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveUserCity provides a mock function with given fields: ctx, userID, name
+func (_m *Database) RemoveUserCity(ctx context.Context, userID int64, name string) error {
+	ret := _m.Called(ctx, userID, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveUserCity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, userID, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveCity provides a mock function with given fields: ctx, city
+func (_m *Database) SaveCity(ctx context.Context, city models.City) error {
+	ret := _m.Called(ctx, city)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveCity")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(models.City) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, models.City) error); ok {
+		r0 = rf(ctx, city)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveSubscription provides a mock function with given fields: ctx, userID, sub
+func (_m *Database) SaveSubscription(ctx context.Context, userID int64, sub models.Subscription) error {
+	ret := _m.Called(ctx, userID, sub)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, models.Subscription) error); ok {
+		r0 = rf(ctx, userID, sub)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -199,17 +381,35 @@ func (_m *Database) SaveCity(_a0 models.City) error {
 	return r0
 }
 
-// SaveUser provides a mock function with given fields: _a0
-func (_m *Database) SaveUser(_a0 *models.User) error {
-	ret := _m.Called(_a0)
+// SaveUser provides a mock function with given fields: ctx, user
+func (_m *Database) SaveUser(ctx context.Context, user *models.User) error {
+	ret := _m.Called(ctx, user)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveUser")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.User) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.User) error); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveUserCity provides a mock function with given fields: ctx, userID, city
+func (_m *Database) SaveUserCity(ctx context.Context, userID int64, city models.UserCity) error {
+	ret := _m.Called(ctx, userID, city)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveUserCity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, models.UserCity) error); ok {
+		r0 = rf(ctx, userID, city)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -217,17 +417,17 @@ func (_m *Database) SaveUser(_a0 *models.User) error {
 	return r0
 }
 
-// SaveWeather provides a mock function with given fields: _a0, _a1
-func (_m *Database) SaveWeather(_a0 int, _a1 *models.ProcessedForecast) error {
-	ret := _m.Called(_a0, _a1)
+// SaveWeather provides a mock function with given fields: ctx, cityID, forecast
+func (_m *Database) SaveWeather(ctx context.Context, cityID int, forecast *models.ProcessedForecast) error {
+	ret := _m.Called(ctx, cityID, forecast)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveWeather")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int, *models.ProcessedForecast) error); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, int, *models.ProcessedForecast) error); ok {
+		r0 = rf(ctx, cityID, forecast)
 	} else {
 		r0 = ret.Error(0)
 	}
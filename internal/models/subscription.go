@@ -0,0 +1,171 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subscription - ежедневное уведомление с прогнозом для одного города
+type Subscription struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"` // Название места ("Дача") или город
+	City   string   `json:"city"`
+	CityID string   `json:"city_id"`
+	Time   string   `json:"time"` // Время отправки "HH:MM"
+	Days   Weekdays `json:"days"`
+
+	WeekendTime string `json:"weekend_time,omitempty"` // Время отправки в субботу и воскресенье, если отличается
+}
+
+func NewSubscription(name, city, cityID, notificationTime string, days Weekdays) Subscription {
+	return Subscription{
+		ID:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		Name:   name,
+		City:   city,
+		CityID: cityID,
+		Time:   notificationTime,
+		Days:   days,
+	}
+}
+
+// NewMainCitySubscription - подписка на основной город пользователя. Город в ней не хранится
+// и берётся из профиля при отправке, поэтому прогноз следует за сменой города через /city.
+func NewMainCitySubscription(notificationTime string, days Weekdays) Subscription {
+	return NewSubscription("", "", "", notificationTime, days)
+}
+
+// MainCity - подписка на основной город пользователя
+func (s Subscription) MainCity() bool {
+	return s.CityID == ""
+}
+
+// ForUser подставляет в подписку на основной город текущий город пользователя
+func (s Subscription) ForUser(user *User) Subscription {
+	if s.MainCity() {
+		s.Name = user.City
+		s.City = user.City
+		s.CityID = user.CityID
+	}
+	return s
+}
+
+// Title - подпись города в прогнозе, название места вводил пользователь, поэтому экранируем для HTML
+func (s Subscription) Title() string {
+	if s.Name == "" || s.Name == s.City {
+		return s.City
+	}
+	return html.EscapeString(s.Name) + ", " + s.City
+}
+
+// TimeOn возвращает время отправки в указанный день недели
+func (s Subscription) TimeOn(day time.Weekday) string {
+	if s.WeekendTime != "" && Weekend.Has(day) {
+		return s.WeekendTime
+	}
+	return s.Time
+}
+
+func (s Subscription) String() string {
+	name := s.Name
+	if name == "" {
+		name = s.City
+	}
+	if name == "" {
+		name = "Основной город"
+	}
+	if s.WeekendTime != "" && s.WeekendTime != s.Time {
+		return fmt.Sprintf("%s в %s, в выходные в %s (%s)", name, s.Time, s.WeekendTime, s.Days)
+	}
+	return fmt.Sprintf("%s в %s (%s)", name, s.Time, s.Days)
+}
+
+// Weekdays - битовая маска дней недели, бит с номером time.Weekday. Пустая маска - каждый день.
+type Weekdays uint8
+
+const (
+	EveryDay Weekdays = 1<<7 - 1
+	WorkDays Weekdays = 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday
+	Weekend  Weekdays = 1<<time.Saturday | 1<<time.Sunday
+)
+
+// Порядок дней в русской неделе
+var WeekOrder = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Monday:    "Пн",
+	time.Tuesday:   "Вт",
+	time.Wednesday: "Ср",
+	time.Thursday:  "Чт",
+	time.Friday:    "Пт",
+	time.Saturday:  "Сб",
+	time.Sunday:    "Вс",
+}
+
+// WeekdayName - короткое название дня недели ("Пн")
+func WeekdayName(day time.Weekday) string {
+	return weekdayNames[day]
+}
+
+func (w Weekdays) Has(day time.Weekday) bool {
+	return w == 0 || w&(1<<day) != 0
+}
+
+// Toggle включает или выключает день недели
+func (w Weekdays) Toggle(day time.Weekday) Weekdays {
+	if w == 0 {
+		w = EveryDay
+	}
+	// Последний день не выключаем: пустая маска означает "каждый день"
+	if toggled := w ^ 1<<day; toggled != 0 {
+		return toggled
+	}
+	return w
+}
+
+func (w Weekdays) String() string {
+	switch w {
+	case 0, EveryDay:
+		return "каждый день"
+	case WorkDays:
+		return "по будням"
+	case Weekend:
+		return "по выходным"
+	}
+
+	var names []string
+	for _, day := range WeekOrder {
+		if w.Has(day) {
+			names = append(names, weekdayNames[day])
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseWeekdays разбирает список дней вида "Сб, Вс" или "пн вт ср"
+func ParseWeekdays(s string) (Weekdays, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '/'
+	})
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("не указаны дни недели")
+	}
+
+	var w Weekdays
+	for _, field := range fields {
+		found := false
+		for day, name := range weekdayNames {
+			if strings.EqualFold(field, name) {
+				w |= 1 << day
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("неизвестный день недели: %s", field)
+		}
+	}
+	return w, nil
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type User struct {
 	TgID    int64  `json:"tg_id"`
 	ChatID  int64  `json:"chat_id"`
@@ -9,6 +11,12 @@ type User struct {
 	Region  string `json:"federal_subject,omitempty"`
 	State   string `json:"state"`
 	Sticker bool   `json:"sticker"`
+	Draft   string `json:"draft,omitempty"` // Промежуточные данные многошагового диалога
+
+	// Пользователь заблокировал бота или удалил аккаунт: уведомления не отправляются,
+	// его города не обновляются, пока он снова не напишет боту
+	Active    bool  `json:"active"`
+	BlockedAt int64 `json:"blocked_at,omitempty"` // Unix-время блокировки, 0 для активных
 }
 
 func NewUser(tgID int64, chatID int64, name, state string) *User {
@@ -18,9 +26,22 @@ func NewUser(tgID int64, chatID int64, name, state string) *User {
 		Name:    name,
 		State:   state,
 		Sticker: true,
+		Active:  true,
 	}
 }
 
+// Block помечает пользователя недоступным
+func (u *User) Block(at time.Time) {
+	u.Active = false
+	u.BlockedAt = at.Unix()
+}
+
+// Activate снимает отметку о блокировке
+func (u *User) Activate() {
+	u.Active = true
+	u.BlockedAt = 0
+}
+
 func (u *User) Update(city, cityID, state string, sticker bool, region string) {
 	u.City = city
 	u.CityID = cityID
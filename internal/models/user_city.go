@@ -0,0 +1,9 @@
+package models
+
+// UserCity - сохранённое пользователем место ("Дом", "Дача", "Работа")
+type UserCity struct {
+	Name   string `json:"name"` // Название, которое дал пользователь
+	City   string `json:"city"`
+	CityID string `json:"city_id"`
+	Region string `json:"region,omitempty"`
+}
@@ -27,6 +27,7 @@ type ShortDayForecast struct {
 
 // Итоговая структура, которая хранится в Redis и БД
 type ProcessedForecast struct {
-	FullDay   map[string]FullDayForecast `json:"full_day"`   // Прогноз на каждый день (детально)
-	ShortDays []ShortDayForecast         `json:"short_days"` // Краткий прогноз на 5 дней
+	FullDay   map[string]FullDayForecast `json:"full_day"`           // Прогноз на каждый день (детально)
+	ShortDays []ShortDayForecast         `json:"short_days"`         // Краткий прогноз на 5 дней
+	Timezone  string                     `json:"timezone,omitempty"` // Часовой пояс, в котором посчитаны даты и части дня
 }
@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"weather-bot/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	return path
+}
+
+var requiredEnv = map[string]string{
+	"TELEGRAM_BOT_TOKEN":  "token",
+	"REDIS_URL":           "redis:6379",
+	"POSTGRES_URL":        "postgres://bot@postgres/bot",
+	"OPENWEATHER_API_KEY": "key",
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := config.LoadFrom("", env(requiredEnv))
+
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, "token", cfg.BotToken)
+	assert.Equal(t, config.StorageRedis, cfg.Storage)
+	assert.Equal(t, "openweathermap", cfg.Weather.Provider)
+	assert.Equal(t, 25*time.Hour, cfg.Weather.CacheTTL)
+	assert.Equal(t, 6*time.Hour, cfg.Jobs.CleanupInterval)
+	assert.Equal(t, 4*time.Hour, cfg.Jobs.WeatherRefreshInterval)
+	assert.Equal(t, 3, cfg.Jobs.WeatherRefreshRetries)
+	assert.Equal(t, 2*time.Minute, cfg.Jobs.WeatherRefreshRetryDelay)
+}
+
+func TestLoad_YAMLFile(t *testing.T) {
+	path := writeFile(t, "bot.yaml", `
+bot_token: file-token
+redis_url: redis:6379
+postgres_url: postgres://bot@postgres/bot
+admin_ids: [1, 2]
+weather:
+  provider: openmeteo
+  cache_ttl: 12h
+jobs:
+  cleanup_interval: 1h
+  weather_refresh_retries: 5
+`)
+
+	cfg, err := config.LoadFrom(path, env(nil))
+
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, "file-token", cfg.BotToken)
+	assert.Equal(t, []int64{1, 2}, cfg.AdminIDs)
+	assert.Equal(t, "openmeteo", cfg.Weather.Provider)
+	assert.Equal(t, 12*time.Hour, cfg.Weather.CacheTTL)
+	assert.Equal(t, time.Hour, cfg.Jobs.CleanupInterval)
+	assert.Equal(t, 5, cfg.Jobs.WeatherRefreshRetries)
+	// Не заданное в файле остаётся по умолчанию
+	assert.Equal(t, 4*time.Hour, cfg.Jobs.WeatherRefreshInterval)
+}
+
+func TestLoad_TOMLFileWithEnvOverride(t *testing.T) {
+	path := writeFile(t, "bot.toml", `
+bot_token = "file-token"
+
+[updates]
+workers = 4
+shutdown_timeout = "10s"
+`)
+
+	cfg, err := config.LoadFrom(path, env(map[string]string{"TELEGRAM_BOT_TOKEN": "env-token", "UPDATE_WORKERS": "8"}))
+
+	assert.NoError(t, err)
+	// Окружение важнее файла
+	assert.Equal(t, "env-token", cfg.BotToken)
+	assert.Equal(t, 8, cfg.Updates.Workers)
+	assert.Equal(t, 10*time.Second, cfg.Updates.ShutdownTimeout)
+}
+
+func TestLoad_InvalidValues(t *testing.T) {
+	path := writeFile(t, "bot.yaml", `
+weather:
+  cache_tll: 12h
+jobs:
+  cleanup_interval: 6
+`)
+
+	_, err := config.LoadFrom(path, env(map[string]string{"ADMIN_IDS": "1,abc"}))
+
+	assert.ErrorContains(t, err, "неизвестный параметр weather.cache_tll")
+	assert.ErrorContains(t, err, "jobs.cleanup_interval")
+	assert.ErrorContains(t, err, "ADMIN_IDS")
+}
+
+func TestValidate_Required(t *testing.T) {
+	cfg, err := config.LoadFrom("", env(map[string]string{"UPDATE_WORKERS": "0"}))
+	assert.NoError(t, err)
+
+	err = cfg.Validate()
+
+	assert.ErrorContains(t, err, "TELEGRAM_BOT_TOKEN")
+	assert.ErrorContains(t, err, "REDIS_URL")
+	assert.ErrorContains(t, err, "POSTGRES_URL")
+	// Поставщик по умолчанию - OpenWeatherMap, ему нужен ключ
+	assert.ErrorContains(t, err, "OPENWEATHER_API_KEY")
+	assert.ErrorContains(t, err, "UPDATE_WORKERS")
+}
+
+func TestValidate_MemoryStorage(t *testing.T) {
+	cfg, err := config.LoadFrom("", env(map[string]string{"TELEGRAM_BOT_TOKEN": "token", "WEATHER_PROVIDER": "openmeteo", "STORAGE_BACKEND": "memory"}))
+	assert.NoError(t, err)
+
+	// Redis и PostgreSQL не нужны
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, config.StorageMemory, cfg.Storage)
+
+	cfg.Storage = "sqlite"
+	assert.ErrorContains(t, cfg.Validate(), "STORAGE_BACKEND")
+}
+
+func TestValidate_WeatherRetriesFitRefreshInterval(t *testing.T) {
+	cfg, err := config.LoadFrom("", env(requiredEnv))
+	assert.NoError(t, err)
+
+	// Повторы обновления не должны тянуться до следующего планового обновления
+	cfg.Jobs.WeatherRefreshRetries = 42
+	cfg.Jobs.WeatherRefreshRetryDelay = 10 * time.Minute
+	assert.ErrorContains(t, cfg.Validate(), "WEATHER_REFRESH_RETRIES")
+}
+
+func TestLoad_TelegramLimits(t *testing.T) {
+	path := writeFile(t, "bot.yaml", `
+telegram:
+  rate: 12.5
+  chat_interval: 2s
+  workers: 8
+`)
+
+	cfg, err := config.LoadFrom(path, env(map[string]string{"TELEGRAM_MAX_RETRIES": "5"}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 12.5, cfg.Telegram.Rate)
+	assert.Equal(t, 2*time.Second, cfg.Telegram.ChatInterval)
+	assert.Equal(t, 8, cfg.Telegram.Workers)
+	assert.Equal(t, 5, cfg.Telegram.MaxRetries)
+	// Не заданное остаётся по умолчанию
+	assert.Equal(t, 256, cfg.Telegram.QueueSize)
+
+	cfg.Telegram.Rate = 0
+	cfg.Telegram.Workers = 0
+	err = cfg.Validate()
+	assert.ErrorContains(t, err, "TELEGRAM_RATE")
+	assert.ErrorContains(t, err, "TELEGRAM_SEND_WORKERS")
+}
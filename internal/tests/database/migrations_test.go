@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"testing"
+	"testing/fstest"
+	"weather-bot/internal/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations_Embedded(t *testing.T) {
+	migrations, err := database.Migrations()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	// Версии идут подряд с первой, у каждой есть откат
+	for i, mig := range migrations {
+		assert.Equal(t, int64(i+1), mig.Version, mig.Name)
+		assert.NotEmpty(t, mig.Up, mig.Name)
+		assert.NotEmpty(t, mig.Down, mig.Name)
+	}
+}
+
+func TestLoadMigrations_SortedByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_later.up.sql":   {Data: []byte("SELECT 10")},
+		"0010_later.down.sql": {Data: []byte("SELECT -10")},
+		"0002_first.up.sql":   {Data: []byte("SELECT 2")},
+		"0002_first.down.sql": {Data: []byte("SELECT -2")},
+	}
+
+	migrations, err := database.LoadMigrations(fsys)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []database.Migration{
+		{Version: 2, Name: "first", Up: "SELECT 2", Down: "SELECT -2"},
+		{Version: 10, Name: "later", Up: "SELECT 10", Down: "SELECT -10"},
+	}, migrations)
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"нет down", fstest.MapFS{
+			"0001_init.up.sql": {Data: []byte("SELECT 1")},
+		}},
+		{"разные имена одной версии", fstest.MapFS{
+			"0001_init.up.sql":    {Data: []byte("SELECT 1")},
+			"0001_other.down.sql": {Data: []byte("SELECT 1")},
+		}},
+		{"неверное имя файла", fstest.MapFS{
+			"init.sql": {Data: []byte("SELECT 1")},
+		}},
+		{"нулевая версия", fstest.MapFS{
+			"0000_init.up.sql":   {Data: []byte("SELECT 1")},
+			"0000_init.down.sql": {Data: []byte("SELECT 1")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := database.LoadMigrations(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+	"weather-bot/internal/app/jobs"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/models"
+	"weather-bot/pkg/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// chooseMoscow проходит /start, ищет Москву и выбирает её в Тверской области
+func chooseMoscow(t *testing.T, bot *testBot) {
+	replies := bot.send("/start")
+	if assert.NotEmpty(t, replies) {
+		assert.Contains(t, replies[len(replies)-1].Text, "Введите название вашего города")
+	}
+
+	replies = bot.send("Москва")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Equal(t, "🔍 Найдено несколько городов. Пожалуйста, выберите нужный:", replies[0].Text)
+		assert.Subset(t, buttons(replies[0]), []string{"Москва (Москва)", "Москва (Тверская область)"})
+	}
+	picker := replies[0].ID
+
+	replies = bot.press("Москва (Тверская область)")
+	if assert.NotEmpty(t, replies, texts(replies)) {
+		assert.Equal(t, "🎉 Отлично! Город Москва сохранен.", replies[len(replies)-1].Text)
+		assert.Contains(t, buttons(replies[len(replies)-1]), "Узнать погоду")
+	}
+	// Сообщение с выбором заменяется выбранным городом
+	assert.Contains(t, bot.message(picker).Text, "Тверская область")
+	assert.Nil(t, bot.message(picker).Keyboard)
+}
+
+func TestConversation_StartChooseCityAndSubscribe(t *testing.T) {
+	bot := newTestBot(t)
+	ctx := context.Background()
+
+	chooseMoscow(t, bot)
+
+	cached, stored := bot.user()
+	if assert.NotNil(t, cached) && assert.NotNil(t, stored) {
+		assert.Equal(t, "524902", cached.CityID)
+		assert.Equal(t, "Тверская область", cached.Region)
+		assert.Equal(t, "none", cached.State)
+		assert.Equal(t, *cached, *stored)
+	}
+
+	replies := bot.send("/notifications")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Contains(t, replies[0].Text, "У вас пока нет уведомлений")
+		assert.Equal(t, []string{"➕ Добавить", "↩ Отмена"}, buttons(replies[0]))
+	}
+
+	replies = bot.send("➕ Добавить")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Equal(t, []string{"Москва", "↩ Отмена"}, buttons(replies[0]))
+	}
+
+	replies = bot.send("Москва")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Contains(t, replies[0].Text, "Введите время")
+	}
+
+	replies = bot.send("08:30")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Contains(t, replies[0].Text, "В какие дни присылать прогноз в 08:30")
+		assert.Contains(t, buttons(replies[0]), "👌 Готово")
+	}
+	days := replies[0].ID
+
+	// Переключение дней правит то же сообщение, не отправляя новых
+	assert.Empty(t, bot.press("Будни"))
+	assert.Contains(t, buttons(bot.message(days)), "✅ Пн")
+	assert.NotContains(t, buttons(bot.message(days)), "✅ Сб")
+
+	// Только будни: время для выходных не спрашивается
+	replies = bot.press("👌 Готово")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Contains(t, replies[0].Text, "🎉 Отлично! Уведомление сохранено")
+		assert.Contains(t, buttons(replies[0]), "Узнать погоду")
+	}
+	assert.Nil(t, bot.message(days).Keyboard)
+
+	cachedSubs, _ := bot.cache.GetSubscriptions(ctx, bot.from.ID)
+	storedSubs, _ := bot.db.GetSubscriptions(ctx, bot.from.ID)
+	if assert.Len(t, cachedSubs, 1) {
+		sub := cachedSubs[0]
+		assert.Equal(t, "08:30", sub.Time)
+		// Основной город в подписке не хранится и берётся из профиля
+		assert.True(t, sub.MainCity())
+		assert.Equal(t, "524902", sub.ForUser(cached).CityID)
+		assert.Equal(t, models.WorkDays, sub.Days)
+		assert.Equal(t, cachedSubs, storedSubs)
+
+		next, err := jobs.NextNotificationTime(sub, time.Now())
+		assert.NoError(t, err)
+		scheduled, _ := bot.cache.ScheduledAt(ctx, storage.QueueUserNotifications, storage.NotificationJobID(bot.from.ID, sub.ID))
+		assert.Equal(t, next.Unix(), scheduled)
+	}
+
+	cached, _ = bot.user()
+	if assert.NotNil(t, cached) {
+		assert.Equal(t, "none", cached.State)
+		assert.Empty(t, cached.Draft)
+	}
+}
+
+func TestConversation_SubscriptionWithWeekendTime(t *testing.T) {
+	bot := newTestBot(t)
+	chooseMoscow(t, bot)
+
+	bot.send("/notifications")
+	bot.send("➕ Добавить")
+	bot.send("Москва")
+	bot.send("07:00")
+
+	// По умолчанию выбраны все дни, поэтому бот спрашивает время для выходных
+	replies := bot.press("👌 Готово")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Contains(t, replies[0].Text, "В выходные присылать тоже в 07:00")
+		assert.Equal(t, []string{"Так же, как в будни", "↩ Отмена"}, buttons(replies[0]))
+	}
+
+	replies = bot.send("25:00")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Contains(t, replies[0].Text, "Неверный формат времени")
+	}
+
+	replies = bot.send("10:00")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Contains(t, replies[0].Text, "🎉 Отлично! Уведомление сохранено")
+	}
+
+	subs, _ := bot.db.GetSubscriptions(context.Background(), bot.from.ID)
+	if assert.Len(t, subs, 1) {
+		assert.Equal(t, models.EveryDay, subs[0].Days)
+		assert.Equal(t, "07:00", subs[0].Time)
+		assert.Equal(t, "10:00", subs[0].WeekendTime)
+	}
+}
+
+func TestConversation_WeatherIsCached(t *testing.T) {
+	bot := newTestBot(t)
+	chooseMoscow(t, bot)
+
+	replies := bot.send("Узнать погоду")
+	if assert.NotEmpty(t, replies) {
+		assert.Contains(t, texts(replies), "Москва")
+		assert.Contains(t, texts(replies), "21")
+	}
+
+	// Повторный запрос берёт прогноз из хранилища
+	bot.send("/weather")
+	assert.Equal(t, 1, bot.provider.Calls())
+}
+
+func TestConversation_NotificationDelivered(t *testing.T) {
+	bot := newTestBot(t)
+	ctx := context.Background()
+	chooseMoscow(t, bot)
+
+	bot.send("/notifications")
+	bot.send("➕ Добавить")
+	bot.send("Москва")
+	bot.send("08:30")
+	bot.press("Каждый день")
+	bot.press("👌 Готово")
+	bot.send("Так же, как в будни")
+
+	subs, _ := bot.cache.GetSubscriptions(ctx, bot.from.ID)
+	if !assert.Len(t, subs, 1) {
+		return
+	}
+	jobID := storage.NotificationJobID(bot.from.ID, subs[0].ID)
+
+	// Время уведомления наступило: воркер отправляет прогноз и планирует следующий
+	assert.NoError(t, bot.cache.Schedule(ctx, storage.QueueUserNotifications, jobID, time.Now().Add(-time.Minute).Unix()))
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	before := bot.sender.count()
+	bot.sender.onSend = func(sentMessage) { cancel() }
+	bot.worker.Process(workerCtx)
+
+	// Прогноз, за ним стикер
+	replies := bot.sender.since(before)
+	if assert.NotEmpty(t, replies) {
+		assert.Equal(t, bot.chatID, replies[0].ChatID)
+		assert.Contains(t, replies[0].Text, "Прогноз на сегодня (Москва)")
+	}
+
+	next, err := jobs.NextNotificationTime(subs[0], time.Now())
+	assert.NoError(t, err)
+	scheduled, _ := bot.cache.ScheduledAt(ctx, storage.QueueUserNotifications, jobID)
+	assert.Equal(t, next.Unix(), scheduled)
+}
+
+func TestConversation_NotificationFollowsChangedCity(t *testing.T) {
+	bot := newTestBot(t)
+	ctx := context.Background()
+	chooseMoscow(t, bot)
+
+	bot.send("/notifications")
+	bot.send("➕ Добавить")
+	bot.send("Москва")
+	bot.send("08:30")
+	bot.press("Каждый день")
+	bot.press("👌 Готово")
+	bot.send("Так же, как в будни")
+
+	// Пользователь переехал: уведомление на основной город приходит уже для нового города
+	bot.send("/city")
+	replies := bot.send("Волгоград")
+	if assert.NotEmpty(t, replies, texts(replies)) {
+		assert.Equal(t, "🎉 Отлично! Город Волгоград сохранен.", replies[len(replies)-1].Text)
+	}
+
+	subs, _ := bot.cache.GetSubscriptions(ctx, bot.from.ID)
+	if !assert.Len(t, subs, 1) {
+		return
+	}
+	jobID := storage.NotificationJobID(bot.from.ID, subs[0].ID)
+	assert.NoError(t, bot.cache.Schedule(ctx, storage.QueueUserNotifications, jobID, time.Now().Add(-time.Minute).Unix()))
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	before := bot.sender.count()
+	bot.sender.onSend = func(sentMessage) { cancel() }
+	bot.worker.Process(workerCtx)
+
+	replies = bot.sender.since(before)
+	if assert.NotEmpty(t, replies) {
+		assert.Contains(t, replies[0].Text, "Прогноз на сегодня (Волгоград)")
+	}
+}
+
+func TestConversation_RetryAfterMidnightIsNotDuplicated(t *testing.T) {
+	bot := newTestBot(t)
+	ctx := context.Background()
+	chooseMoscow(t, bot)
+
+	bot.send("/notifications")
+	bot.send("➕ Добавить")
+	bot.send("Москва")
+	bot.send("23:50")
+	bot.press("Каждый день")
+	bot.press("👌 Готово")
+	bot.send("Так же, как в будни")
+
+	subs, _ := bot.cache.GetSubscriptions(ctx, bot.from.ID)
+	if !assert.Len(t, subs, 1) {
+		return
+	}
+	jobID := storage.NotificationJobID(bot.from.ID, subs[0].ID)
+
+	// Уведомление на вчера не ушло с первой попытки, повтор выполняется уже сегодня
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	scheduledFor := time.Now().In(moscow).AddDate(0, 0, -1)
+	assert.NoError(t, bot.cache.Schedule(ctx, storage.QueueUserNotifications, jobID, scheduledFor.Unix()))
+	due, _ := bot.cache.Due(ctx, storage.QueueUserNotifications, "worker", time.Now().Unix(), 10)
+	if !assert.Len(t, due, 1) {
+		return
+	}
+	assert.NoError(t, bot.cache.Retry(ctx, storage.QueueUserNotifications, due[0], time.Now().Add(-time.Second).Unix()))
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	bot.sender.onSend = func(sentMessage) { cancel() }
+	bot.worker.Process(workerCtx)
+
+	// Отправка засчитана за вчерашний день по местному времени города
+	claimed, _ := bot.cache.ClaimOnce(ctx, "sent:"+jobID+":"+scheduledFor.Format("2006-01-02"), time.Hour)
+	assert.False(t, claimed)
+}
+
+func TestConversation_BlockedUserIsDeactivatedOnReply(t *testing.T) {
+	bot := newTestBot(t)
+	ctx := context.Background()
+	chooseMoscow(t, bot)
+
+	bot.send("/notifications")
+	bot.send("➕ Добавить")
+	bot.send("Москва")
+	bot.send("08:30")
+	bot.press("👌 Готово")
+	bot.send("Так же, как в будни")
+
+	subs, _ := bot.cache.GetSubscriptions(ctx, bot.from.ID)
+	if !assert.Len(t, subs, 1) {
+		return
+	}
+	jobID := storage.NotificationJobID(bot.from.ID, subs[0].ID)
+	scheduled, _ := bot.cache.ScheduledAt(ctx, storage.QueueUserNotifications, jobID)
+	assert.NotZero(t, scheduled)
+
+	// Пользователь заблокировал бота, ответ на его последнее сообщение не доходит
+	bot.sender.err = telegram.Classify(&tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"})
+	assert.Empty(t, bot.send("/weather"))
+
+	cached, stored := bot.user()
+	if assert.NotNil(t, cached) && assert.NotNil(t, stored) {
+		assert.False(t, cached.Active)
+		assert.False(t, stored.Active)
+		assert.NotZero(t, stored.BlockedAt)
+	}
+	scheduled, _ = bot.cache.ScheduledAt(ctx, storage.QueueUserNotifications, jobID)
+	assert.Zero(t, scheduled)
+	ids, _ := bot.db.GetCitiesIds(ctx)
+	assert.Empty(t, ids)
+}
+
+func TestConversation_DeadLettersForAdminOnly(t *testing.T) {
+	bot := newTestBot(t, 1)
+	chooseMoscow(t, bot)
+
+	replies := bot.send("/dlq")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Contains(t, replies[0].Text, "Я не понимаю такую команду")
+	}
+
+	admin := newTestBot(t, 1001)
+	chooseMoscow(t, admin)
+
+	replies = admin.send("/dlq")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Equal(t, "📭 Недоставленных уведомлений нет.", replies[0].Text)
+	}
+}
+
+func TestConversation_ReplayAllDeadLetters(t *testing.T) {
+	admin := newTestBot(t, 1001)
+	ctx := context.Background()
+	chooseMoscow(t, admin)
+
+	// Недоставленных больше, чем помещается на одну страницу /dlq
+	now := time.Now().Unix()
+	for i := range 25 {
+		assert.NoError(t, admin.cache.Schedule(ctx, storage.QueueUserNotifications, storage.NotificationJobID(int64(i), "s"), now))
+	}
+	due, _ := admin.cache.Due(ctx, storage.QueueUserNotifications, "worker", now, 100)
+	for _, job := range due {
+		assert.NoError(t, admin.cache.DeadLetter(ctx, storage.QueueUserNotifications, job, "blocked"))
+	}
+
+	replies := admin.send("/dlq replay 404")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Contains(t, replies[0].Text, "не найдено")
+	}
+
+	replies = admin.send("/dlq replay")
+	if assert.Len(t, replies, 1, texts(replies)) {
+		assert.Equal(t, "🔁 Возвращено в очередь: 25", replies[0].Text)
+	}
+	letters, _ := admin.cache.DeadLetters(ctx, "", 100)
+	assert.Empty(t, letters)
+	length, _ := admin.cache.QueueLength(ctx, storage.QueueUserNotifications)
+	assert.Equal(t, int64(25), length)
+}
@@ -0,0 +1,289 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"weather-bot/internal/app/handlers"
+	"weather-bot/internal/app/jobs"
+	"weather-bot/internal/app/reply"
+	"weather-bot/internal/app/search"
+	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/weather"
+	"weather-bot/internal/config"
+	"weather-bot/internal/memory"
+	"weather-bot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Города справочника: две Москвы в разных регионах, чтобы проверить выбор региона
+var testCities = []models.City{
+	{ID: 524901, Name: "Москва", Region: "Москва", Lat: 55.75, Lon: 37.62, Timezone: "Europe/Moscow"},
+	{ID: 524902, Name: "Москва", Region: "Тверская область", Lat: 56.40, Lon: 34.95, Timezone: "Europe/Moscow"},
+	{ID: 472757, Name: "Волгоград", Region: "Волгоградская область", Lat: 48.72, Lon: 44.50, Timezone: "Europe/Volgograd"},
+}
+
+// sentMessage - сообщение бота. Keyboard - ReplyKeyboardMarkup, InlineKeyboardMarkup или nil.
+type sentMessage struct {
+	ID       int
+	ChatID   int64
+	Text     string
+	Keyboard any
+	Sticker  string
+}
+
+// fakeSender - Telegram в памяти: запоминает сообщения и применяет к ним правки
+type fakeSender struct {
+	mu       sync.Mutex
+	messages []*sentMessage
+	answers  []string
+	onSend   func(msg sentMessage)
+	err      error // Ошибка Telegram для всех сообщений в чат, например блокировка бота
+}
+
+func (s *fakeSender) Message(ctx context.Context, chatID int64, text string, keyboard any) error {
+	return s.add(&sentMessage{ChatID: chatID, Text: text, Keyboard: keyboard})
+}
+
+func (s *fakeSender) Sticker(ctx context.Context, chatID int64, stickerID string) error {
+	return s.add(&sentMessage{ChatID: chatID, Sticker: stickerID})
+}
+
+func (s *fakeSender) add(msg *sentMessage) error {
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return s.err
+	}
+	msg.ID = len(s.messages) + 1
+	s.messages = append(s.messages, msg)
+	onSend := s.onSend
+	s.mu.Unlock()
+
+	if onSend != nil {
+		onSend(*msg)
+	}
+	return nil
+}
+
+func (s *fakeSender) Edit(ctx context.Context, chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := s.messages[messageID-1]
+	msg.Text = text
+	if keyboard == nil {
+		msg.Keyboard = nil
+	} else {
+		msg.Keyboard = *keyboard
+	}
+	return nil
+}
+
+func (s *fakeSender) AnswerCallback(ctx context.Context, callbackID string, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.answers = append(s.answers, text)
+	return nil
+}
+
+// since возвращает копии сообщений, отправленных после первых n
+func (s *fakeSender) since(n int) []sentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []sentMessage
+	for _, msg := range s.messages[n:] {
+		result = append(result, *msg)
+	}
+	return result
+}
+
+func (s *fakeSender) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.messages)
+}
+
+// fakeProvider отдаёт одинаковый прогноз на 5 дней вперёд и считает запросы
+type fakeProvider struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeProvider) Forecast(ctx context.Context, loc weather.Location) (*weather.Forecast, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+
+	start := time.Now().UTC().Truncate(24 * time.Hour).Add(-24 * time.Hour)
+	forecast := &weather.Forecast{}
+	for t := start; t.Before(start.Add(6 * 24 * time.Hour)); t = t.Add(3 * time.Hour) {
+		forecast.Items = append(forecast.Items, weather.ForecastItem{
+			Time:        t.Unix(),
+			Temperature: 21,
+			FeelsLike:   19,
+			WindSpeed:   3,
+			ConditionId: 800,
+		})
+	}
+	return forecast, nil
+}
+
+func (p *fakeProvider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+// testBot - бот с Telegram, хранилищами и поставщиком погоды в памяти. Сообщения
+// обрабатываются синхронно, поэтому после send/press все ответы уже отправлены.
+type testBot struct {
+	t        *testing.T
+	handler  *handlers.Handler
+	worker   *jobs.UserWorker
+	sender   *fakeSender
+	provider *fakeProvider
+	cache    *memory.Storage
+	db       *memory.Storage
+
+	from   tgbotapi.User
+	chatID int64
+}
+
+func newTestBot(t *testing.T, adminIDs ...int64) *testBot {
+	cfg := config.Defaults()
+	cache := memory.NewStorage(cfg.Weather.CacheTTL)
+	db := memory.NewStorage(cfg.Weather.CacheTTL)
+	svc := services.NewServiceContainer(cache, db)
+	svc.LoadCities(context.Background(), testCities)
+
+	sender := &fakeSender{}
+	provider := &fakeProvider{}
+	replier := reply.New(sender, svc)
+	weatherClient := weather.NewClient(svc, provider)
+	scheduler := jobs.NewScheduler(svc, cfg.Jobs)
+
+	return &testBot{
+		t: t,
+		handler: handlers.NewHandler(handlers.Deps{
+			Services:  svc,
+			Reply:     replier,
+			Weather:   weatherClient,
+			Search:    search.NewSearcher(svc),
+			Scheduler: scheduler,
+			AdminIDs:  adminIDs,
+		}),
+		worker: jobs.NewUserWorker(jobs.Deps{
+			Services:  svc,
+			Weather:   weatherClient,
+			Reply:     replier,
+			Scheduler: scheduler,
+			Config:    cfg.Jobs,
+		}),
+		sender:   sender,
+		provider: provider,
+		cache:    cache,
+		db:       db,
+		from:     tgbotapi.User{ID: 1001, FirstName: "Анна", UserName: "anna"},
+		chatID:   1001,
+	}
+}
+
+// send отправляет боту текст и возвращает ответы на него
+func (b *testBot) send(text string) []sentMessage {
+	b.t.Helper()
+	return b.update(tgbotapi.Update{Message: &tgbotapi.Message{
+		From: &b.from,
+		Chat: &tgbotapi.Chat{ID: b.chatID},
+		Text: text,
+	}})
+}
+
+// press нажимает inline-кнопку с текстом label в последнем сообщении, где она есть
+func (b *testBot) press(label string) []sentMessage {
+	b.t.Helper()
+
+	messages := b.sender.since(0)
+	for i := len(messages) - 1; i >= 0; i-- {
+		keyboard, ok := messages[i].Keyboard.(tgbotapi.InlineKeyboardMarkup)
+		if !ok {
+			continue
+		}
+		for _, row := range keyboard.InlineKeyboard {
+			for _, button := range row {
+				if button.Text != label || button.CallbackData == nil {
+					continue
+				}
+				return b.update(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+					ID:   "cb",
+					From: &b.from,
+					Message: &tgbotapi.Message{
+						MessageID: messages[i].ID,
+						Chat:      &tgbotapi.Chat{ID: b.chatID},
+						Text:      messages[i].Text,
+					},
+					Data: *button.CallbackData,
+				}})
+			}
+		}
+	}
+	b.t.Fatalf("кнопка %q не найдена", label)
+	return nil
+}
+
+func (b *testBot) update(update tgbotapi.Update) []sentMessage {
+	before := b.sender.count()
+	b.handler.Update(context.Background(), update)
+	return b.sender.since(before)
+}
+
+// message возвращает сообщение бота по ID с учётом правок
+func (b *testBot) message(id int) sentMessage {
+	return b.sender.since(id - 1)[0]
+}
+
+// user - пользователь, как он сохранён в основном и резервном хранилищах
+func (b *testBot) user() (cached, stored *models.User) {
+	b.t.Helper()
+	cached, _ = b.cache.GetUser(context.Background(), b.from.ID)
+	stored, _ = b.db.GetUser(context.Background(), b.from.ID)
+	return cached, stored
+}
+
+// buttons - подписи кнопок клавиатуры сообщения по порядку
+func buttons(msg sentMessage) []string {
+	var labels []string
+	switch keyboard := msg.Keyboard.(type) {
+	case tgbotapi.ReplyKeyboardMarkup:
+		for _, row := range keyboard.Keyboard {
+			for _, button := range row {
+				labels = append(labels, button.Text)
+			}
+		}
+	case tgbotapi.InlineKeyboardMarkup:
+		for _, row := range keyboard.InlineKeyboard {
+			for _, button := range row {
+				labels = append(labels, button.Text)
+			}
+		}
+	}
+	return labels
+}
+
+// texts - тексты сообщений для сообщений об ошибках
+func texts(messages []sentMessage) string {
+	var parts []string
+	for _, msg := range messages {
+		parts = append(parts, msg.Text)
+	}
+	return strings.Join(parts, "\n---\n")
+}
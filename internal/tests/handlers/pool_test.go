@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"weather-bot/internal/app/handlers"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func message(userID int64, text string) tgbotapi.Update {
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		From: &tgbotapi.User{ID: userID},
+		Chat: &tgbotapi.Chat{ID: userID},
+		Text: text,
+	}}
+}
+
+func TestUpdatePool_PerUserOrder(t *testing.T) {
+	var mu sync.Mutex
+	handled := make(map[int64][]string)
+
+	pool := handlers.NewUpdatePool(context.Background(), 4, 8, func(_ context.Context, update tgbotapi.Update) {
+		// Первое сообщение обрабатывается дольше, следующие не должны его обогнать
+		if update.Message.Text == "1" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		mu.Lock()
+		handled[update.Message.From.ID] = append(handled[update.Message.From.ID], update.Message.Text)
+		mu.Unlock()
+	})
+
+	for userID := int64(1); userID <= 10; userID++ {
+		for _, text := range []string{"1", "2", "3"} {
+			pool.Submit(message(userID, text))
+		}
+	}
+	assert.NoError(t, pool.Close(context.Background()))
+
+	assert.Len(t, handled, 10)
+	for userID, texts := range handled {
+		assert.Equal(t, []string{"1", "2", "3"}, texts, "пользователь %d", userID)
+	}
+}
+
+func TestUpdatePool_SlowUserDoesNotBlockOthers(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan int64, 1)
+
+	pool := handlers.NewUpdatePool(context.Background(), 2, 1, func(_ context.Context, update tgbotapi.Update) {
+		if update.Message.From.ID == 1 {
+			<-release
+			return
+		}
+		done <- update.Message.From.ID
+	})
+
+	pool.Submit(message(1, "медленный запрос"))
+	pool.Submit(message(2, "быстрый запрос"))
+
+	select {
+	case userID := <-done:
+		assert.Equal(t, int64(2), userID)
+	case <-time.After(time.Second):
+		t.Fatal("обновление второго пользователя ждёт первого")
+	}
+
+	close(release)
+	assert.NoError(t, pool.Close(context.Background()))
+}
+
+func TestUpdatePool_CloseDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	pool := handlers.NewUpdatePool(context.Background(), 1, 1, func(context.Context, tgbotapi.Update) {
+		<-release
+	})
+	pool.Submit(message(1, "зависший запрос"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, pool.Close(ctx), context.DeadlineExceeded)
+}
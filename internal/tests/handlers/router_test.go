@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"testing"
+	"weather-bot/internal/app/handlers"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_BotCommands(t *testing.T) {
+	r := handlers.NewRouter()
+	noop := func(*handlers.Context) {}
+
+	r.Command(handlers.Command{Name: "/start", Description: "Начать заново", Handler: noop})
+	r.Command(handlers.Command{Name: "/weather", Aliases: []string{"Узнать погоду"}, Description: "Погода на сегодня", Handler: noop})
+	// Служебная команда без описания не попадает в меню
+	r.Command(handlers.Command{Name: "/dlq", Handler: noop})
+
+	assert.Equal(t, []tgbotapi.BotCommand{
+		{Command: "start", Description: "Начать заново"},
+		{Command: "weather", Description: "Погода на сегодня"},
+	}, r.BotCommands())
+}
+
+func TestRouter_DuplicateCommand(t *testing.T) {
+	r := handlers.NewRouter()
+	noop := func(*handlers.Context) {}
+
+	r.Command(handlers.Command{Name: "/weather", Aliases: []string{"Узнать погоду"}, Handler: noop})
+
+	assert.Panics(t, func() {
+		r.Command(handlers.Command{Name: "/today", Aliases: []string{"Узнать погоду"}, Handler: noop})
+	})
+}
+
+func TestRouter_OverlappingCallbacks(t *testing.T) {
+	r := handlers.NewRouter()
+	noop := func(*handlers.Context) {}
+
+	r.Callback("city:", noop)
+	r.Callback("days:", noop)
+
+	assert.Panics(t, func() { r.Callback("city:", noop) })
+	// Данные "city:save:1" подходили бы под оба префикса
+	assert.Panics(t, func() { r.Callback("city:save:", noop) })
+	assert.Panics(t, func() { r.Callback("ci", noop) })
+	assert.Panics(t, func() { r.Callback("", noop) })
+	assert.NotPanics(t, func() { r.Callback("place:", noop) })
+}
+
+func TestBotCommands_Registered(t *testing.T) {
+	commands := handlers.NewHandler(handlers.Deps{AdminIDs: []int64{1}}).BotCommands()
+
+	names := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		names = append(names, cmd.Command)
+		assert.NotEmpty(t, cmd.Description)
+	}
+	assert.Contains(t, names, "weather")
+	assert.Contains(t, names, "notifications")
+	assert.NotContains(t, names, "dlq")
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"weather-bot/internal/app/jobs"
+	"weather-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextNotificationTime(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	// Среда, 15 января 2025, 12:00
+	wednesday := time.Date(2025, time.January, 15, 12, 0, 0, 0, loc)
+
+	tests := []struct {
+		name     string
+		sub      models.Subscription
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Later today",
+			sub:      models.Subscription{Time: "18:30", Days: models.EveryDay},
+			now:      wednesday,
+			expected: time.Date(2025, time.January, 15, 18, 30, 0, 0, loc),
+		},
+		{
+			name:     "Time already passed today",
+			sub:      models.Subscription{Time: "07:00", Days: models.EveryDay},
+			now:      wednesday,
+			expected: time.Date(2025, time.January, 16, 7, 0, 0, 0, loc),
+		},
+		{
+			name:     "Exactly now is not repeated",
+			sub:      models.Subscription{Time: "12:00"},
+			now:      wednesday,
+			expected: time.Date(2025, time.January, 16, 12, 0, 0, 0, loc),
+		},
+		{
+			name:     "Weekend only",
+			sub:      models.Subscription{Time: "07:00", Days: models.Weekend},
+			now:      wednesday,
+			expected: time.Date(2025, time.January, 18, 7, 0, 0, 0, loc),
+		},
+		{
+			name:     "Separate weekend time",
+			sub:      models.Subscription{Time: "07:00", WeekendTime: "10:00", Days: models.EveryDay},
+			now:      time.Date(2025, time.January, 17, 8, 0, 0, 0, loc), // пятница
+			expected: time.Date(2025, time.January, 18, 10, 0, 0, 0, loc),
+		},
+		{
+			name:     "Same weekday next week",
+			sub:      models.Subscription{Time: "09:00", Days: models.Weekdays(1 << time.Wednesday)},
+			now:      wednesday,
+			expected: time.Date(2025, time.January, 22, 9, 0, 0, 0, loc),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := jobs.NextNotificationTime(tt.sub, tt.now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, next)
+		})
+	}
+}
+
+func TestNextNotificationTime_InvalidTime(t *testing.T) {
+	_, err := jobs.NextNotificationTime(models.Subscription{Time: "25:00"}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestNotificationRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, jobs.NotificationRetryDelay(0, time.Minute, 30*time.Minute))
+	assert.Equal(t, 2*time.Minute, jobs.NotificationRetryDelay(1, time.Minute, 30*time.Minute))
+	assert.Equal(t, 8*time.Minute, jobs.NotificationRetryDelay(3, time.Minute, 30*time.Minute))
+	// Задержка ограничена сверху
+	assert.Equal(t, 30*time.Minute, jobs.NotificationRetryDelay(5, time.Minute, 30*time.Minute))
+	assert.Equal(t, 30*time.Minute, jobs.NotificationRetryDelay(64, time.Minute, 30*time.Minute))
+}
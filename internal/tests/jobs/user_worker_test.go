@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"weather-bot/internal/app/jobs"
+	"weather-bot/internal/app/reply"
+	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/app/weather"
+	"weather-bot/internal/config"
+	"weather-bot/internal/mocks"
+	"weather-bot/internal/models"
+	"weather-bot/pkg/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeSender запоминает отправленные сообщения и останавливает воркер после первой отправки
+type fakeSender struct {
+	err      error
+	stop     context.CancelFunc
+	messages []string
+}
+
+func (s *fakeSender) Message(ctx context.Context, chatID int64, text string, keyboard any) error {
+	s.messages = append(s.messages, text)
+	s.stop()
+	return s.err
+}
+
+func (s *fakeSender) Sticker(ctx context.Context, chatID int64, stickerID string) error {
+	return nil
+}
+
+func (s *fakeSender) Edit(ctx context.Context, chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	return nil
+}
+
+func (s *fakeSender) AnswerCallback(ctx context.Context, callbackID string, text string) error {
+	return nil
+}
+
+func newUserWorker(cache *mocks.Cache, db *mocks.Database, sender reply.Sender) *jobs.UserWorker {
+	svc := services.NewServiceContainer(cache, db)
+	cfg := config.Defaults().Jobs
+	return jobs.NewUserWorker(jobs.Deps{
+		Services: svc,
+		// Прогноз берётся из кеша, поставщик не нужен
+		Weather:   weather.NewClient(svc, nil),
+		Reply:     reply.New(sender, svc),
+		Scheduler: jobs.NewScheduler(svc, cfg),
+		Config:    cfg,
+	})
+}
+
+func expectNotification(cache *mocks.Cache, job storage.Job) {
+	user := &models.User{TgID: 7, ChatID: 70, Name: "Иван", Active: true}
+	sub := models.Subscription{ID: "s1", City: "Москва", CityID: "524901", Time: "08:00", Days: models.EveryDay}
+
+	cache.On("IsHealthy").Return(true)
+	cache.On("Due", mock.Anything, storage.QueueUserNotifications, mock.Anything, mock.Anything, mock.Anything).Return([]storage.Job{job}, nil).Once()
+	cache.On("QueueLength", mock.Anything, storage.QueueUserNotifications).Return(int64(1), nil)
+	cache.On("GetUser", mock.Anything, int64(7)).Return(user, nil)
+	cache.On("GetSubscriptions", mock.Anything, int64(7)).Return([]models.Subscription{sub}, nil)
+	cache.On("GetWeather", mock.Anything, 524901).Return(&models.ProcessedForecast{Timezone: "UTC"}, nil)
+	cache.On("ClaimOnce", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	cache.On("Ack", mock.Anything, storage.QueueUserNotifications, job).Return(nil)
+}
+
+func TestUserWorker_SendsNotificationAndSchedulesNext(t *testing.T) {
+	cache := mocks.NewCache(t)
+	db := mocks.NewDatabase(t)
+	job := storage.Job{ID: storage.NotificationJobID(7, "s1")}
+	expectNotification(cache, job)
+	cache.On("Schedule", mock.Anything, storage.QueueUserNotifications, job.ID, mock.Anything).Return(nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sender := &fakeSender{stop: cancel}
+
+	newUserWorker(cache, db, sender).Process(ctx)
+
+	assert.Len(t, sender.messages, 1)
+	assert.Contains(t, sender.messages[0], "Москва")
+}
+
+func TestUserWorker_BlockedUserIsDeactivated(t *testing.T) {
+	cache := mocks.NewCache(t)
+	db := mocks.NewDatabase(t)
+	job := storage.Job{ID: storage.NotificationJobID(7, "s1")}
+	expectNotification(cache, job)
+
+	inactive := mock.MatchedBy(func(user *models.User) bool { return !user.Active })
+	cache.On("SaveUser", mock.Anything, inactive).Return(nil).Once()
+	db.On("SaveUser", mock.Anything, inactive).Return(nil).Once()
+	// Снимаются уведомление старого формата и подписка
+	cache.On("Cancel", mock.Anything, storage.QueueUserNotifications, storage.NotificationJobID(7, "")).Return(nil).Once()
+	cache.On("Cancel", mock.Anything, storage.QueueUserNotifications, job.ID).Return(nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sender := &fakeSender{stop: cancel, err: telegram.ErrBlocked}
+
+	newUserWorker(cache, db, sender).Process(ctx)
+
+	// Повтора и следующего уведомления нет, задача подтверждена
+	cache.AssertNotCalled(t, "Retry", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	cache.AssertNotCalled(t, "Schedule", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
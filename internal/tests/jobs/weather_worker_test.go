@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"weather-bot/internal/app/jobs"
+	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/app/weather"
+	"weather-bot/internal/config"
+	"weather-bot/internal/memory"
+	"weather-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingProvider - недоступный поставщик погоды, считает запросы
+type failingProvider struct {
+	calls atomic.Int32
+}
+
+func (p *failingProvider) Name() string {
+	return "failing"
+}
+
+func (p *failingProvider) Forecast(ctx context.Context, loc weather.Location) (*weather.Forecast, error) {
+	p.calls.Add(1)
+	return nil, errors.New("поставщик недоступен")
+}
+
+func TestWeatherWorker_RetryingUpdateIsNotClaimedByAnotherConsumer(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStorage(time.Hour)
+	// Неподтверждённую задачу другой воркер забирает почти сразу
+	store.SetClaimIdle(10 * time.Millisecond)
+	svc := services.NewServiceContainer(store, store)
+
+	user := models.NewUser(1, 1, "Анна", "none")
+	user.CityID = "524901"
+	assert.NoError(t, store.SaveUser(ctx, user))
+	assert.NoError(t, store.Schedule(ctx, storage.QueueWeatherUpdates, storage.WeatherUpdateJobID, time.Now().Add(-time.Minute).Unix()))
+
+	provider := &failingProvider{}
+	cfg := config.Defaults().Jobs
+	newWorker := func() *jobs.WeatherWorker {
+		return jobs.NewWeatherWorker(jobs.Deps{
+			Services:  svc,
+			Weather:   weather.NewClient(svc, provider),
+			Scheduler: jobs.NewScheduler(svc, cfg),
+			Config:    cfg,
+		})
+	}
+
+	// Первая реплика взяла задачу, оба поставщика недоступны, и она ждёт повтора
+	firstCtx, stopFirst := context.WithCancel(ctx)
+	var first sync.WaitGroup
+	first.Add(1)
+	go func() {
+		defer first.Done()
+		newWorker().Process(firstCtx)
+	}()
+	assert.Eventually(t, func() bool { return provider.calls.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// Вторая реплика не запускает то же обновление ещё раз
+	secondCtx, stopSecond := context.WithTimeout(ctx, 3*time.Second)
+	defer stopSecond()
+	newWorker().Process(secondCtx)
+	assert.Equal(t, int32(1), provider.calls.Load())
+
+	// Следующее обновление уже запланировано
+	next, _ := store.ScheduledAt(ctx, storage.QueueWeatherUpdates, storage.WeatherUpdateJobID)
+	assert.Greater(t, next, time.Now().Unix())
+
+	stopFirst()
+	first.Wait()
+}
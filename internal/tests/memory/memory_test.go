@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+	"weather-bot/internal/app/storage"
+	"weather-bot/internal/memory"
+	"weather-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const queue = storage.QueueUserNotifications
+
+func TestQueue_DueAckRetry(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStorage(time.Hour)
+	now := time.Now().Unix()
+
+	assert.NoError(t, s.Schedule(ctx, queue, "1:a", now+60))
+	assert.NoError(t, s.Schedule(ctx, queue, "1:b", now-10))
+	assert.NoError(t, s.Schedule(ctx, queue, "1:c", now-20))
+
+	at, _ := s.ScheduledAt(ctx, queue, "1:a")
+	assert.Equal(t, now+60, at)
+
+	// Наступившие задачи по порядку, будущие остаются отложенными
+	jobs, err := s.Due(ctx, queue, "worker", now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 2) {
+		assert.Equal(t, "1:c", jobs[0].ID)
+		assert.Equal(t, "1:b", jobs[1].ID)
+		assert.NotEqual(t, jobs[0].Delivery, jobs[1].Delivery)
+	}
+	length, _ := s.QueueLength(ctx, queue)
+	assert.Equal(t, int64(1), length)
+
+	// Выданные задачи повторно не выдаются
+	again, _ := s.Due(ctx, queue, "worker", now, 10)
+	assert.Empty(t, again)
+
+	assert.NoError(t, s.Ack(ctx, queue, jobs[0]))
+	assert.NoError(t, s.Retry(ctx, queue, jobs[1], now))
+
+	retried, _ := s.Due(ctx, queue, "worker", now, 10)
+	if assert.Len(t, retried, 1) {
+		assert.Equal(t, "1:b", retried[0].ID)
+		assert.Equal(t, 1, retried[0].Attempt)
+		// Повтор сохраняет исходное время задачи
+		assert.Equal(t, now-10, retried[0].ScheduledFor)
+	}
+}
+
+func TestQueue_CancelAndDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStorage(time.Hour)
+	now := time.Now().Unix()
+
+	assert.NoError(t, s.Schedule(ctx, queue, "1:a", now))
+	assert.NoError(t, s.Cancel(ctx, queue, "1:a"))
+	at, _ := s.ScheduledAt(ctx, queue, "1:a")
+	assert.Zero(t, at)
+
+	assert.NoError(t, s.Schedule(ctx, queue, "2:a", now))
+	jobs, _ := s.Due(ctx, queue, "worker", now, 10)
+	if assert.Len(t, jobs, 1) {
+		assert.NoError(t, s.DeadLetter(ctx, queue, jobs[0], "blocked"))
+	}
+
+	letters, _ := s.DeadLetters(ctx, "", 10)
+	if assert.Len(t, letters, 1) {
+		assert.Equal(t, "2:a", letters[0].JobID)
+		assert.Equal(t, "blocked", letters[0].Reason)
+		assert.Equal(t, 1, letters[0].Attempts)
+
+		letter, err := s.GetDeadLetter(ctx, letters[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, &letters[0], letter)
+
+		assert.NoError(t, s.RemoveDeadLetter(ctx, letters[0].ID))
+	}
+	letters, _ = s.DeadLetters(ctx, "", 10)
+	assert.Empty(t, letters)
+	letter, err := s.GetDeadLetter(ctx, "2")
+	assert.NoError(t, err)
+	assert.Nil(t, letter)
+}
+
+func TestQueue_DeadLettersPages(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStorage(time.Hour)
+	now := time.Now().Unix()
+
+	for i, id := range []string{"1:a", "2:a", "3:a"} {
+		assert.NoError(t, s.Schedule(ctx, queue, id, now-int64(3-i)))
+	}
+	jobs, _ := s.Due(ctx, queue, "worker", now, 10)
+	for _, job := range jobs {
+		assert.NoError(t, s.DeadLetter(ctx, queue, job, "blocked"))
+	}
+
+	// Следующая страница начинается со следующей по старшинству записи
+	first, _ := s.DeadLetters(ctx, "", 2)
+	if assert.Len(t, first, 2) {
+		assert.Equal(t, "3:a", first[0].JobID)
+		assert.Equal(t, "2:a", first[1].JobID)
+	}
+	second, _ := s.DeadLetters(ctx, first[1].ID, 2)
+	if assert.Len(t, second, 1) {
+		assert.Equal(t, "1:a", second[0].JobID)
+	}
+}
+
+func TestClaimOnce(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStorage(time.Hour)
+
+	first, _ := s.ClaimOnce(ctx, "sent:1:a", time.Hour)
+	second, _ := s.ClaimOnce(ctx, "sent:1:a", time.Hour)
+	assert.True(t, first)
+	assert.False(t, second)
+
+	assert.NoError(t, s.ReleaseOnce(ctx, "sent:1:a"))
+	third, _ := s.ClaimOnce(ctx, "sent:1:a", time.Hour)
+	assert.True(t, third)
+}
+
+func TestWeatherExpires(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStorage(time.Millisecond)
+
+	assert.NoError(t, s.SaveWeather(ctx, 1, &models.ProcessedForecast{Timezone: "UTC"}))
+	forecast, err := s.GetWeather(ctx, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, forecast)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = s.GetWeather(ctx, 1)
+	assert.Error(t, err)
+}
+
+func TestCitiesIdsOfActiveUsers(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStorage(time.Hour)
+
+	active := models.NewUser(1, 1, "Анна", "none")
+	active.CityID = "524901"
+	blocked := models.NewUser(2, 2, "Иван", "none")
+	blocked.CityID = "472757"
+	blocked.Block(time.Now())
+	assert.NoError(t, s.SaveUser(ctx, active))
+	assert.NoError(t, s.SaveUser(ctx, blocked))
+	assert.NoError(t, s.SaveUserCity(ctx, 1, models.UserCity{Name: "Дача", CityID: "472757"}))
+
+	ids, err := s.GetCitiesIds(ctx)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"524901", "472757"}, ids)
+
+	// Пользователь заблокировал бота: его города не обновляются
+	active.Block(time.Now())
+	assert.NoError(t, s.SaveUser(ctx, active))
+	ids, _ = s.GetCitiesIds(ctx)
+	assert.Empty(t, ids)
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"weather-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  models.Weekdays
+		expectErr bool
+	}{
+		{input: "Сб, Вс", expected: models.Weekend},
+		{input: "пн вт ср чт пт", expected: models.WorkDays},
+		{input: "Ср/Пт", expected: 1<<time.Wednesday | 1<<time.Friday},
+		{input: "", expectErr: true},
+		{input: "Сб, завтра", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			days, err := models.ParseWeekdays(tt.input)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, days)
+		})
+	}
+}
+
+func TestWeekdays(t *testing.T) {
+	assert.True(t, models.Weekend.Has(time.Sunday))
+	assert.False(t, models.Weekend.Has(time.Monday))
+	// Пустая маска - каждый день
+	assert.True(t, models.Weekdays(0).Has(time.Wednesday))
+
+	assert.Equal(t, "по выходным", models.Weekend.String())
+	assert.Equal(t, "каждый день", models.EveryDay.String())
+	assert.Equal(t, "Пн, Ср, Вс", models.Weekdays(1<<time.Monday|1<<time.Wednesday|1<<time.Sunday).String())
+}
+
+func TestSubscription_Title(t *testing.T) {
+	assert.Equal(t, "Москва", models.Subscription{Name: "Москва", City: "Москва"}.Title())
+	assert.Equal(t, "Дача, Истра", models.Subscription{Name: "Дача", City: "Истра"}.Title())
+	assert.Equal(t, "&lt;b&gt;, Истра", models.Subscription{Name: "<b>", City: "Истра"}.Title())
+}
+
+func TestWeekdays_Toggle(t *testing.T) {
+	days := models.EveryDay.Toggle(time.Sunday)
+	assert.False(t, days.Has(time.Sunday))
+	assert.True(t, days.Has(time.Saturday))
+
+	// Последний выбранный день не снимается
+	single := models.Weekdays(1 << time.Friday)
+	assert.Equal(t, single, single.Toggle(time.Friday))
+}
+
+func TestSubscription_TimeOn(t *testing.T) {
+	sub := models.Subscription{Name: "Дача", City: "Истра", Time: "07:00", WeekendTime: "10:00", Days: models.EveryDay}
+	assert.Equal(t, "07:00", sub.TimeOn(time.Friday))
+	assert.Equal(t, "10:00", sub.TimeOn(time.Sunday))
+	assert.Equal(t, "Дача в 07:00, в выходные в 10:00 (каждый день)", sub.String())
+}
+
+func TestSubscription_MainCityFollowsUser(t *testing.T) {
+	sub := models.NewMainCitySubscription("08:00", models.EveryDay)
+	user := models.NewUser(1, 1, "Анна", "none")
+	user.City, user.CityID = "Волгоград", "472757"
+
+	assert.True(t, sub.MainCity())
+	assert.Equal(t, "Основной город в 08:00 (каждый день)", sub.String())
+
+	resolved := sub.ForUser(user)
+	assert.Equal(t, "472757", resolved.CityID)
+	assert.Equal(t, "Волгоград", resolved.Title())
+
+	// Подписка на другой город не зависит от основного
+	place := models.NewSubscription("Дача", "Истра", "42", "08:00", models.EveryDay)
+	assert.Equal(t, "42", place.ForUser(user).CityID)
+}
@@ -0,0 +1,24 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"weather-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_BlockAndActivate(t *testing.T) {
+	user := models.NewUser(1, 1, "Иван", "none")
+	assert.True(t, user.Active)
+	assert.Zero(t, user.BlockedAt)
+
+	blockedAt := time.Date(2025, time.January, 15, 9, 0, 0, 0, time.UTC)
+	user.Block(blockedAt)
+	assert.False(t, user.Active)
+	assert.Equal(t, blockedAt.Unix(), user.BlockedAt)
+
+	user.Activate()
+	assert.True(t, user.Active)
+	assert.Zero(t, user.BlockedAt)
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"weather-bot/internal/app/search"
+	"weather-bot/internal/app/services"
+	"weather-bot/internal/mocks"
+	"weather-bot/internal/models"
+	"weather-bot/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDistance(t *testing.T) {
+	// Москва - Санкт-Петербург, около 634 км
+	assert.InDelta(t, 634, utils.Distance(55.7558, 37.6173, 59.9343, 30.3351), 5)
+	assert.Zero(t, utils.Distance(55.7558, 37.6173, 55.7558, 37.6173))
+}
+
+func TestNearestCity(t *testing.T) {
+	primaryMock := mocks.NewCache(t)
+	secondaryMock := mocks.NewDatabase(t)
+	searcher := search.NewSearcher(services.NewServiceContainer(primaryMock, secondaryMock))
+
+	primaryMock.On("GetNearestCity", mock.Anything, 55.88, 37.44).Return(&models.City{ID: 2, Name: "Химки"}, 1.5, nil)
+
+	city, distance, err := searcher.NearestCity(context.Background(), 55.88, 37.44)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Химки", city.Name)
+	assert.Equal(t, 1.5, distance)
+}
+
+func TestNearestCity_NotFound(t *testing.T) {
+	primaryMock := mocks.NewCache(t)
+	secondaryMock := mocks.NewDatabase(t)
+	searcher := search.NewSearcher(services.NewServiceContainer(primaryMock, secondaryMock))
+
+	primaryMock.On("GetNearestCity", mock.Anything, 0.0, 0.0).Return(nil, 0.0, errors.New("no cities"))
+	secondaryMock.On("GetNearestCity", mock.Anything, 0.0, 0.0).Return(nil, 0.0, errors.New("no rows"))
+
+	_, _, err := searcher.NearestCity(context.Background(), 0, 0)
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"weather-bot/internal/app/handlers"
@@ -91,11 +92,11 @@ func TestCityService_SaveCity(t *testing.T) {
 			primaryMock := mocks.NewCache(t)
 			secondaryMock := mocks.NewDatabase(t)
 
-			primaryMock.On("SaveCity", mock.Anything).Return(tt.primaryErr)
-			secondaryMock.On("SaveCity", mock.Anything).Return(tt.secondaryErr)
+			primaryMock.On("SaveCity", mock.Anything, mock.Anything).Return(tt.primaryErr)
+			secondaryMock.On("SaveCity", mock.Anything, mock.Anything).Return(tt.secondaryErr)
 
 			service := services.InitCityService(primaryMock, secondaryMock)
-			err := service.SaveCity(models.City{ID: 1, Name: "Test"})
+			err := service.SaveCity(context.Background(), models.City{ID: 1, Name: "Test"})
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -153,14 +154,14 @@ func TestCityService_GetCities(t *testing.T) {
 			primaryMock := mocks.NewCache(t)
 			secondaryMock := mocks.NewDatabase(t)
 
-			primaryMock.On("GetCities", mock.Anything).Return(tt.primaryCities, tt.primaryErr)
+			primaryMock.On("GetCities", mock.Anything, mock.Anything).Return(tt.primaryCities, tt.primaryErr)
 			if tt.primaryErr != nil {
-				secondaryMock.On("GetCities", mock.Anything).Return(tt.secondaryCities, tt.secondaryErr)
+				secondaryMock.On("GetCities", mock.Anything, mock.Anything).Return(tt.secondaryCities, tt.secondaryErr)
 			}
 
 			service := services.InitCityService(primaryMock, secondaryMock)
 
-			cities, err := service.GetCities("TestCity")
+			cities, err := service.GetCities(context.Background(), "TestCity")
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -236,11 +237,11 @@ func TestCityService_LoadCities(t *testing.T) {
 				pErr := tt.primaryErrs[i]
 				sErr := tt.secondaryErrs[i]
 
-				primaryMock.On("SaveCity", city).Return(pErr)
-				secondaryMock.On("SaveCity", city).Return(sErr)
+				primaryMock.On("SaveCity", mock.Anything, city).Return(pErr)
+				secondaryMock.On("SaveCity", mock.Anything, city).Return(sErr)
 			}
 
-			err := service.LoadCities(tt.inputCities)
+			err := service.LoadCities(context.Background(), tt.inputCities)
 
 			if tt.expectedErr {
 				assert.Error(t, err)
@@ -295,9 +296,9 @@ func TestCityService_GetCitiesNames(t *testing.T) {
 
 				service := services.InitCityService(primaryMock, secondaryMock)
 
-				primaryMock.On(methodName).Return(tt.primaryRes, tt.primaryErr)
+				primaryMock.On(methodName, mock.Anything).Return(tt.primaryRes, tt.primaryErr)
 				if tt.primaryErr != nil {
-					secondaryMock.On(methodName).Return(tt.secondaryRes, tt.secondaryErr)
+					secondaryMock.On(methodName, mock.Anything).Return(tt.secondaryRes, tt.secondaryErr)
 				}
 
 				result, err := getFunc(&service)
@@ -316,7 +317,7 @@ func TestCityService_GetCitiesNames(t *testing.T) {
 	}
 
 	runGetTest(t, func(s *services.CityService) ([]string, error) {
-		return s.GetCitiesNames()
+		return s.GetCitiesNames(context.Background())
 	}, "GetCitiesNames")
 }
 
@@ -361,9 +362,9 @@ func TestCityService_GetCitiesIds(t *testing.T) {
 
 				service := services.InitCityService(primaryMock, secondaryMock)
 
-				primaryMock.On(methodName).Return(tt.primaryRes, tt.primaryErr)
+				primaryMock.On(methodName, mock.Anything).Return(tt.primaryRes, tt.primaryErr)
 				if tt.primaryErr != nil {
-					secondaryMock.On(methodName).Return(tt.secondaryRes, tt.secondaryErr)
+					secondaryMock.On(methodName, mock.Anything).Return(tt.secondaryRes, tt.secondaryErr)
 				}
 
 				result, err := getFunc(&service)
@@ -382,6 +383,67 @@ func TestCityService_GetCitiesIds(t *testing.T) {
 	}
 
 	runGetTest(t, func(s *services.CityService) ([]string, error) {
-		return s.GetCitiesIds()
+		return s.GetCitiesIds(context.Background())
 	}, "GetCitiesIds")
 }
+
+func TestCityService_GetNearestCity(t *testing.T) {
+	tests := []struct {
+		name             string
+		primaryCity      *models.City
+		primaryErr       error
+		secondaryCity    *models.City
+		secondaryErr     error
+		expectedCity     *models.City
+		expectedDistance float64
+		wantErr          bool
+	}{
+		{
+			name:             "Primary returns city",
+			primaryCity:      &models.City{ID: 1, Name: "City1"},
+			expectedCity:     &models.City{ID: 1, Name: "City1"},
+			expectedDistance: 2.5,
+		},
+		{
+			name:             "Primary fails, secondary returns city",
+			primaryErr:       errors.New("primary error"),
+			secondaryCity:    &models.City{ID: 2, Name: "City2"},
+			expectedCity:     &models.City{ID: 2, Name: "City2"},
+			expectedDistance: 2.5,
+		},
+		{
+			name:         "Both fail",
+			primaryErr:   errors.New("primary error"),
+			secondaryErr: errors.New("secondary error"),
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primaryMock := mocks.NewCache(t)
+			secondaryMock := mocks.NewDatabase(t)
+
+			primaryMock.On("GetNearestCity", mock.Anything, 55.0, 37.0).Return(tt.primaryCity, 2.5, tt.primaryErr)
+			if tt.primaryErr != nil {
+				secondaryMock.On("GetNearestCity", mock.Anything, 55.0, 37.0).Return(tt.secondaryCity, 2.5, tt.secondaryErr)
+			}
+
+			service := services.InitCityService(primaryMock, secondaryMock)
+			city, distance, err := service.GetNearestCity(context.Background(), 55.0, 37.0)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				var dualErr *services.DualStorageError
+				assert.ErrorAs(t, err, &dualErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCity, city)
+				assert.Equal(t, tt.expectedDistance, distance)
+			}
+
+			primaryMock.AssertExpectations(t)
+			secondaryMock.AssertExpectations(t)
+		})
+	}
+}
@@ -1,248 +1,235 @@
 package tests
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/storage"
 	"weather-bot/internal/mocks"
+	"weather-bot/internal/models"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
-func TestGetUserNotificationTime_Success(t *testing.T) {
+func TestSchedule_Success(t *testing.T) {
 	mockStorage := mocks.NewCache(t)
 	service := &services.NotificationService{
 		Primary: mockStorage,
 	}
 
-	userID := int64(1)
-	expectedTime := "08:00"
+	jobID := storage.NotificationJobID(1, "sub1")
+	executeAt := int64(111)
 
-	mockStorage.On("GetUserNotificationTime", userID).Return(expectedTime, nil)
+	mockStorage.On("Schedule", mock.Anything, storage.QueueUserNotifications, jobID, executeAt).Return(nil)
 
-	notifTime, err := service.GetUserNotificationTime(userID)
+	err := service.Schedule(context.Background(), storage.QueueUserNotifications, jobID, executeAt)
 
 	assert.NoError(t, err)
-	assert.Equal(t, expectedTime, notifTime)
 
-	mockStorage.AssertCalled(t, "GetUserNotificationTime", userID)
+	mockStorage.AssertCalled(t, "Schedule", mock.Anything, storage.QueueUserNotifications, jobID, executeAt)
 }
 
-func TestGetUserNotificationTime_Error(t *testing.T) {
+func TestSchedule_Error(t *testing.T) {
 	mockStorage := mocks.NewCache(t)
 	service := &services.NotificationService{
 		Primary: mockStorage,
 	}
 
-	userID := int64(1)
+	executeAt := int64(111)
 
-	mockStorage.On("GetUserNotificationTime", userID).Return("", fmt.Errorf("storage error"))
+	mockStorage.On("Schedule", mock.Anything, storage.QueueWeatherUpdates, storage.WeatherUpdateJobID, executeAt).Return(fmt.Errorf("storage error"))
 
-	notifTime, err := service.GetUserNotificationTime(userID)
+	err := service.Schedule(context.Background(), storage.QueueWeatherUpdates, storage.WeatherUpdateJobID, executeAt)
 
 	assert.Error(t, err)
-	assert.Empty(t, notifTime)
 
-	mockStorage.AssertCalled(t, "GetUserNotificationTime", userID)
+	mockStorage.AssertCalled(t, "Schedule", mock.Anything, storage.QueueWeatherUpdates, storage.WeatherUpdateJobID, executeAt)
 }
 
-func TestRemoveUserNotification_Success(t *testing.T) {
+func TestDue_Success(t *testing.T) {
 	mockStorage := mocks.NewCache(t)
 	service := &services.NotificationService{
 		Primary: mockStorage,
 	}
 
-	userID := int64(1)
+	now := int64(111)
+	expectedJobs := []storage.Job{
+		{ID: storage.NotificationJobID(1, "sub1"), ExecuteAt: 100, Delivery: "1-0"},
+		{ID: storage.NotificationJobID(2, ""), ExecuteAt: 110, Delivery: "1-1"},
+	}
 
-	mockStorage.On("RemoveUserNotification", userID).Return(nil)
+	mockStorage.On("Due", mock.Anything, storage.QueueUserNotifications, "worker-1", now, 100).Return(expectedJobs, nil)
 
-	err := service.RemoveUserNotification(userID)
+	jobs, err := service.Due(context.Background(), storage.QueueUserNotifications, "worker-1", now, 100)
 
 	assert.NoError(t, err)
+	assert.Equal(t, expectedJobs, jobs)
 
-	mockStorage.AssertCalled(t, "RemoveUserNotification", userID)
+	mockStorage.AssertCalled(t, "Due", mock.Anything, storage.QueueUserNotifications, "worker-1", now, 100)
 }
 
-func TestRemoveUserNotification_Error(t *testing.T) {
+func TestDue_Error(t *testing.T) {
 	mockStorage := mocks.NewCache(t)
 	service := &services.NotificationService{
 		Primary: mockStorage,
 	}
 
-	userID := int64(1)
+	now := int64(111)
 
-	mockStorage.On("RemoveUserNotification", userID).Return(fmt.Errorf("storage error"))
+	mockStorage.On("Due", mock.Anything, storage.QueueWeatherUpdates, "worker-1", now, 1).Return(nil, fmt.Errorf("storage error"))
 
-	err := service.RemoveUserNotification(userID)
+	jobs, err := service.Due(context.Background(), storage.QueueWeatherUpdates, "worker-1", now, 1)
 
 	assert.Error(t, err)
+	assert.Nil(t, jobs)
 
-	mockStorage.AssertCalled(t, "RemoveUserNotification", userID)
+	mockStorage.AssertCalled(t, "Due", mock.Anything, storage.QueueWeatherUpdates, "worker-1", now, 1)
 }
 
-func TestScheduleUserNotification_Success(t *testing.T) {
+func TestAck_Success(t *testing.T) {
 	mockStorage := mocks.NewCache(t)
 	service := &services.NotificationService{
 		Primary: mockStorage,
 	}
 
-	userID := int64(1)
-	executeAt := int64(111)
+	job := storage.Job{ID: storage.NotificationJobID(1, "sub1"), ExecuteAt: 111, Delivery: "1-0"}
 
-	mockStorage.On("ScheduleUserNotification", userID, executeAt).Return(nil)
+	mockStorage.On("Ack", mock.Anything, storage.QueueUserNotifications, job).Return(nil)
 
-	err := service.ScheduleUserNotification(userID, executeAt)
+	err := service.Ack(context.Background(), storage.QueueUserNotifications, job)
 
 	assert.NoError(t, err)
 
-	mockStorage.AssertCalled(t, "ScheduleUserNotification", userID, executeAt)
-}
-
-func TestScheduleUserNotification_Error(t *testing.T) {
-	mockStorage := mocks.NewCache(t)
-	service := &services.NotificationService{
-		Primary: mockStorage,
-	}
-
-	userID := int64(1)
-	executeAt := int64(111)
-
-	mockStorage.On("ScheduleUserNotification", userID, executeAt).Return(fmt.Errorf("storage error"))
-
-	err := service.ScheduleUserNotification(userID, executeAt)
-
-	assert.Error(t, err)
-
-	mockStorage.AssertCalled(t, "ScheduleUserNotification", userID, executeAt)
+	mockStorage.AssertCalled(t, "Ack", mock.Anything, storage.QueueUserNotifications, job)
 }
 
-func TestScheduleWeatherUpdate_Success(t *testing.T) {
+func TestClaimOnce_AlreadyClaimed(t *testing.T) {
 	mockStorage := mocks.NewCache(t)
 	service := &services.NotificationService{
 		Primary: mockStorage,
 	}
 
-	executeAt := int64(111)
+	key := "sent:1:sub1:2024-05-01"
 
-	mockStorage.On("ScheduleWeatherUpdate", executeAt).Return(nil)
+	mockStorage.On("ClaimOnce", mock.Anything, key, 48*time.Hour).Return(false, nil)
 
-	err := service.ScheduleWeatherUpdate(executeAt)
+	claimed, err := service.ClaimOnce(context.Background(), key, 48*time.Hour)
 
 	assert.NoError(t, err)
-
-	mockStorage.AssertCalled(t, "ScheduleWeatherUpdate", executeAt)
+	assert.False(t, claimed)
 }
 
-func TestScheduleWeatherUpdate_Error(t *testing.T) {
+func TestCancel_Error(t *testing.T) {
 	mockStorage := mocks.NewCache(t)
 	service := &services.NotificationService{
 		Primary: mockStorage,
 	}
 
-	executeAt := int64(111)
+	jobID := storage.NotificationJobID(1, "sub1")
 
-	mockStorage.On("ScheduleWeatherUpdate", executeAt).Return(fmt.Errorf("storage error"))
+	mockStorage.On("Cancel", mock.Anything, storage.QueueUserNotifications, jobID).Return(fmt.Errorf("storage error"))
 
-	err := service.ScheduleWeatherUpdate(executeAt)
+	err := service.Cancel(context.Background(), storage.QueueUserNotifications, jobID)
 
 	assert.Error(t, err)
 
-	mockStorage.AssertCalled(t, "ScheduleWeatherUpdate", executeAt)
+	mockStorage.AssertCalled(t, "Cancel", mock.Anything, storage.QueueUserNotifications, jobID)
 }
 
-func TestRemoveWeatherUpdate_Success(t *testing.T) {
+func TestScheduledAt_Success(t *testing.T) {
 	mockStorage := mocks.NewCache(t)
 	service := &services.NotificationService{
 		Primary: mockStorage,
 	}
 
-	mockStorage.On("RemoveWeatherUpdate").Return(nil)
+	jobID := storage.NotificationJobID(1, "sub1")
+
+	mockStorage.On("ScheduledAt", mock.Anything, storage.QueueUserNotifications, jobID).Return(int64(111), nil)
 
-	err := service.RemoveWeatherUpdate()
+	executeAt, err := service.ScheduledAt(context.Background(), storage.QueueUserNotifications, jobID)
 
 	assert.NoError(t, err)
+	assert.Equal(t, int64(111), executeAt)
 
-	mockStorage.AssertCalled(t, "RemoveWeatherUpdate")
+	mockStorage.AssertCalled(t, "ScheduledAt", mock.Anything, storage.QueueUserNotifications, jobID)
 }
 
-func TestRemoveWeatherUpdate_Error(t *testing.T) {
-	mockStorage := mocks.NewCache(t)
-	service := &services.NotificationService{
-		Primary: mockStorage,
-	}
-
-	mockStorage.On("RemoveWeatherUpdate").Return(fmt.Errorf("storage error"))
-
-	err := service.RemoveWeatherUpdate()
-
-	assert.Error(t, err)
-
-	mockStorage.AssertCalled(t, "RemoveWeatherUpdate")
-}
+func TestCancelUserNotifications(t *testing.T) {
+	mockCache := mocks.NewCache(t)
+	mockDB := mocks.NewDatabase(t)
+	container := services.NewServiceContainer(mockCache, mockDB)
 
-func TestGetScheduleWeatherUpdate_Success(t *testing.T) {
-	mockStorage := mocks.NewCache(t)
-	service := &services.NotificationService{
-		Primary: mockStorage,
+	subs := []models.Subscription{{ID: "a1"}, {ID: "b2"}}
+	mockCache.On("GetSubscriptions", mock.Anything, int64(1)).Return(subs, nil)
+	// Уведомление старого формата и обе подписки
+	for _, id := range []string{"", "a1", "b2"} {
+		mockCache.On("Cancel", mock.Anything, storage.QueueUserNotifications, storage.NotificationJobID(1, id)).Return(nil).Once()
 	}
 
-	expectedSchedule := []redis.XStream{}
-
-	mockStorage.On("GetScheduleWeatherUpdate").Return(expectedSchedule, nil)
-
-	schedule, err := service.GetScheduleWeatherUpdate()
+	err := container.CancelUserNotifications(context.Background(), 1)
 
 	assert.NoError(t, err)
-	assert.Equal(t, expectedSchedule, schedule)
-
-	mockStorage.AssertCalled(t, "GetScheduleWeatherUpdate")
+	mockCache.AssertExpectations(t)
 }
 
-func TestGetScheduleWeatherUpdate_Error(t *testing.T) {
-	mockStorage := mocks.NewCache(t)
-	service := &services.NotificationService{
-		Primary: mockStorage,
-	}
+func TestNotificationJobID(t *testing.T) {
+	jobID := storage.NotificationJobID(42, "k3x9")
+	assert.Equal(t, "42:k3x9", jobID)
 
-	mockStorage.On("GetScheduleWeatherUpdate").Return(nil, fmt.Errorf("storage error"))
+	userID, subscriptionID, err := storage.ParseNotificationJobID(jobID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), userID)
+	assert.Equal(t, "k3x9", subscriptionID)
 
-	_, err := service.GetScheduleWeatherUpdate()
+	// Уведомление, заведённое до появления подписок
+	userID, subscriptionID, err = storage.ParseNotificationJobID("42:")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), userID)
+	assert.Empty(t, subscriptionID)
 
+	_, _, err = storage.ParseNotificationJobID("weather_update")
 	assert.Error(t, err)
-
-	mockStorage.AssertCalled(t, "GetScheduleWeatherUpdate")
 }
 
-func TestGetScheduleUserNotifications_Success(t *testing.T) {
+func TestReplayDeadLetter(t *testing.T) {
 	mockStorage := mocks.NewCache(t)
 	service := &services.NotificationService{
 		Primary: mockStorage,
 	}
 
-	expectedSchedule := []redis.XStream{}
+	letter := storage.DeadLetter{
+		ID:       "1-0",
+		Queue:    storage.QueueUserNotifications,
+		JobID:    storage.NotificationJobID(1, "sub1"),
+		Reason:   "telegram error",
+		Attempts: 5,
+	}
 
-	mockStorage.On("GetScheduleUserNotifications").Return(expectedSchedule, nil)
+	mockStorage.On("Schedule", mock.Anything, storage.QueueUserNotifications, letter.JobID, int64(111)).Return(nil).Once()
+	mockStorage.On("RemoveDeadLetter", mock.Anything, "1-0").Return(nil).Once()
 
-	schedule, err := service.GetScheduleUserNotifications()
+	err := service.ReplayDeadLetter(context.Background(), letter, 111)
 
 	assert.NoError(t, err)
-	assert.Equal(t, expectedSchedule, schedule)
-
-	mockStorage.AssertCalled(t, "GetScheduleUserNotifications")
+	mockStorage.AssertExpectations(t)
 }
 
-func TestGetScheduleUserNotifications_Error(t *testing.T) {
+func TestReplayDeadLetter_ScheduleError(t *testing.T) {
 	mockStorage := mocks.NewCache(t)
 	service := &services.NotificationService{
 		Primary: mockStorage,
 	}
 
-	mockStorage.On("GetScheduleUserNotifications").Return(nil, fmt.Errorf("storage error"))
+	letter := storage.DeadLetter{ID: "1-0", Queue: storage.QueueUserNotifications, JobID: storage.NotificationJobID(1, "sub1")}
 
-	_, err := service.GetScheduleUserNotifications()
+	mockStorage.On("Schedule", mock.Anything, storage.QueueUserNotifications, letter.JobID, int64(111)).Return(fmt.Errorf("storage error"))
 
-	assert.Error(t, err)
+	err := service.ReplayDeadLetter(context.Background(), letter, 111)
 
-	mockStorage.AssertCalled(t, "GetScheduleUserNotifications")
+	// Запись остаётся в очереди недоставленных
+	assert.Error(t, err)
+	mockStorage.AssertNotCalled(t, "RemoveDeadLetter", mock.Anything, "1-0")
 }
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"weather-bot/internal/app/services"
+	"weather-bot/internal/mocks"
+	"weather-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSubscriptionService_SaveSubscription(t *testing.T) {
+	sub := models.Subscription{ID: "a1", Name: "Дача", City: "Истра", CityID: "42", Time: "07:00", Days: models.Weekend}
+	tests := []struct {
+		name             string
+		mockPrimaryErr   error
+		mockSecondaryErr error
+		expectedErr      bool
+	}{
+		{
+			name: "Both storages succeed",
+		},
+		{
+			name:             "Primary succeeds, secondary fails",
+			mockSecondaryErr: errors.New("secondary error"),
+		},
+		{
+			name:             "Both fail",
+			mockPrimaryErr:   errors.New("primary error"),
+			mockSecondaryErr: errors.New("secondary error"),
+			expectedErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primaryMock := mocks.NewCache(t)
+			secondaryMock := mocks.NewDatabase(t)
+
+			primaryMock.On("SaveSubscription", mock.Anything, int64(1), sub).Return(tt.mockPrimaryErr)
+			secondaryMock.On("SaveSubscription", mock.Anything, int64(1), sub).Return(tt.mockSecondaryErr)
+
+			service := services.InitSubscriptionService(primaryMock, secondaryMock)
+
+			err := service.SaveSubscription(context.Background(), 1, sub)
+
+			if tt.expectedErr {
+				var dualErr *services.DualStorageError
+				assert.ErrorAs(t, err, &dualErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_GetSubscription(t *testing.T) {
+	subs := []models.Subscription{
+		{ID: "a1", Name: "Дом", City: "Москва", CityID: "1", Time: "08:00"},
+		{ID: "b2", Name: "Дача", City: "Истра", CityID: "42", Time: "07:00", Days: models.Weekend},
+	}
+
+	primaryMock := mocks.NewCache(t)
+	secondaryMock := mocks.NewDatabase(t)
+
+	// Redis недоступен, подписки читаются из БД
+	primaryMock.On("GetSubscriptions", mock.Anything, int64(1)).Return(nil, errors.New("primary error"))
+	secondaryMock.On("GetSubscriptions", mock.Anything, int64(1)).Return(subs, nil)
+
+	service := services.InitSubscriptionService(primaryMock, secondaryMock)
+
+	sub, err := service.GetSubscription(context.Background(), 1, "b2")
+	assert.NoError(t, err)
+	assert.Equal(t, &subs[1], sub)
+
+	sub, err = service.GetSubscription(context.Background(), 1, "removed")
+	assert.NoError(t, err)
+	assert.Nil(t, sub)
+}
+
+func TestSubscriptionService_RemoveSubscription(t *testing.T) {
+	primaryMock := mocks.NewCache(t)
+	secondaryMock := mocks.NewDatabase(t)
+
+	primaryMock.On("RemoveSubscription", mock.Anything, int64(1), "a1").Return(errors.New("primary error"))
+	secondaryMock.On("RemoveSubscription", mock.Anything, int64(1), "a1").Return(errors.New("secondary error"))
+
+	service := services.InitSubscriptionService(primaryMock, secondaryMock)
+
+	var dualErr *services.DualStorageError
+	assert.ErrorAs(t, service.RemoveSubscription(context.Background(), 1, "a1"), &dualErr)
+}
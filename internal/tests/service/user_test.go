@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"weather-bot/internal/app/services"
@@ -8,6 +9,7 @@ import (
 	"weather-bot/internal/models"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestUserService_SaveUser(t *testing.T) {
@@ -53,12 +55,12 @@ func TestUserService_SaveUser(t *testing.T) {
 			primaryMock := mocks.NewCache(t)
 			secondaryMock := mocks.NewDatabase(t)
 
-			primaryMock.On("SaveUser", tt.user).Return(tt.mockPrimaryErr)
-			secondaryMock.On("SaveUser", tt.user).Return(tt.mockSecondaryErr)
+			primaryMock.On("SaveUser", mock.Anything, tt.user).Return(tt.mockPrimaryErr)
+			secondaryMock.On("SaveUser", mock.Anything, tt.user).Return(tt.mockSecondaryErr)
 
 			service := services.InitUserService(primaryMock, secondaryMock)
 
-			err := service.SaveUser(tt.user)
+			err := service.SaveUser(context.Background(), tt.user)
 
 			if tt.expectedErr {
 				assert.Error(t, err)
@@ -114,14 +116,14 @@ func TestUserService_GetUser(t *testing.T) {
 			primaryMock := mocks.NewCache(t)
 			secondaryMock := mocks.NewDatabase(t)
 
-			primaryMock.On("GetUser", tt.userID).Return(tt.mockPrimaryUser, tt.mockPrimaryErr)
+			primaryMock.On("GetUser", mock.Anything, tt.userID).Return(tt.mockPrimaryUser, tt.mockPrimaryErr)
 			if tt.mockPrimaryErr != nil {
-				secondaryMock.On("GetUser", tt.userID).Return(tt.mockSecondaryUser, tt.mockSecondaryErr)
+				secondaryMock.On("GetUser", mock.Anything, tt.userID).Return(tt.mockSecondaryUser, tt.mockSecondaryErr)
 			}
 
 			service := services.InitUserService(primaryMock, secondaryMock)
 
-			user, err := service.GetUser(tt.userID)
+			user, err := service.GetUser(context.Background(), tt.userID)
 
 			if tt.expectErr {
 				assert.Error(t, err)
@@ -141,3 +143,115 @@ func TestUserService_GetUser(t *testing.T) {
 		})
 	}
 }
+
+func TestUserService_SaveUserCity(t *testing.T) {
+	city := models.UserCity{Name: "Дача", City: "Истра", CityID: "42"}
+	tests := []struct {
+		name             string
+		mockPrimaryErr   error
+		mockSecondaryErr error
+		expectedErr      bool
+	}{
+		{
+			name: "Both storages succeed",
+		},
+		{
+			name:           "Primary fails, secondary succeeds",
+			mockPrimaryErr: errors.New("primary error"),
+		},
+		{
+			name:             "Both fail",
+			mockPrimaryErr:   errors.New("primary error"),
+			mockSecondaryErr: errors.New("secondary error"),
+			expectedErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primaryMock := mocks.NewCache(t)
+			secondaryMock := mocks.NewDatabase(t)
+
+			primaryMock.On("SaveUserCity", mock.Anything, int64(1), city).Return(tt.mockPrimaryErr)
+			secondaryMock.On("SaveUserCity", mock.Anything, int64(1), city).Return(tt.mockSecondaryErr)
+
+			service := services.InitUserService(primaryMock, secondaryMock)
+
+			err := service.SaveUserCity(context.Background(), 1, city)
+
+			if tt.expectedErr {
+				var dualErr *services.DualStorageError
+				assert.ErrorAs(t, err, &dualErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserService_GetUserCities(t *testing.T) {
+	cities := []models.UserCity{{Name: "Дом", City: "Москва", CityID: "1"}, {Name: "Работа", City: "Химки", CityID: "2"}}
+	tests := []struct {
+		name                string
+		mockPrimaryCities   []models.UserCity
+		mockPrimaryErr      error
+		mockSecondaryCities []models.UserCity
+		mockSecondaryErr    error
+		expectedCities      []models.UserCity
+		expectErr           bool
+	}{
+		{
+			name:              "Primary succeeds",
+			mockPrimaryCities: cities,
+			expectedCities:    cities,
+		},
+		{
+			name:                "Primary fails, secondary succeeds",
+			mockPrimaryErr:      errors.New("primary error"),
+			mockSecondaryCities: cities,
+			expectedCities:      cities,
+		},
+		{
+			name:             "Both fail",
+			mockPrimaryErr:   errors.New("primary error"),
+			mockSecondaryErr: errors.New("secondary error"),
+			expectErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primaryMock := mocks.NewCache(t)
+			secondaryMock := mocks.NewDatabase(t)
+
+			primaryMock.On("GetUserCities", mock.Anything, int64(1)).Return(tt.mockPrimaryCities, tt.mockPrimaryErr)
+			if tt.mockPrimaryErr != nil {
+				secondaryMock.On("GetUserCities", mock.Anything, int64(1)).Return(tt.mockSecondaryCities, tt.mockSecondaryErr)
+			}
+
+			service := services.InitUserService(primaryMock, secondaryMock)
+
+			got, err := service.GetUserCities(context.Background(), 1)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCities, got)
+			}
+		})
+	}
+}
+
+func TestUserService_RemoveUserCity(t *testing.T) {
+	primaryMock := mocks.NewCache(t)
+	secondaryMock := mocks.NewDatabase(t)
+
+	primaryMock.On("RemoveUserCity", mock.Anything, int64(1), "Дача").Return(errors.New("primary error"))
+	secondaryMock.On("RemoveUserCity", mock.Anything, int64(1), "Дача").Return(nil)
+
+	service := services.InitUserService(primaryMock, secondaryMock)
+
+	assert.NoError(t, service.RemoveUserCity(context.Background(), 1, "Дача"))
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"weather-bot/internal/app/services"
@@ -8,6 +9,7 @@ import (
 	"weather-bot/internal/models"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestWeatherService_SaveWeather(t *testing.T) {
@@ -50,12 +52,12 @@ func TestWeatherService_SaveWeather(t *testing.T) {
 			primaryMock := mocks.NewCache(t)
 			secondaryMock := mocks.NewDatabase(t)
 
-			primaryMock.On("SaveWeather", tt.id, tt.forecast).Return(tt.mockPrimaryErr)
-			secondaryMock.On("SaveWeather", tt.id, tt.forecast).Return(tt.mockSecondaryErr)
+			primaryMock.On("SaveWeather", mock.Anything, tt.id, tt.forecast).Return(tt.mockPrimaryErr)
+			secondaryMock.On("SaveWeather", mock.Anything, tt.id, tt.forecast).Return(tt.mockSecondaryErr)
 
 			service := services.InitWeatherService(primaryMock, secondaryMock)
 
-			err := service.SaveWeather(tt.id, tt.forecast)
+			err := service.SaveWeather(context.Background(), tt.id, tt.forecast)
 
 			if tt.expectErr {
 				assert.Error(t, err)
@@ -120,14 +122,14 @@ func TestWeatherService_GetWeather(t *testing.T) {
 			primaryMock := mocks.NewCache(t)
 			secondaryMock := mocks.NewDatabase(t)
 
-			primaryMock.On("GetWeather", tt.id).Return(tt.mockPrimaryWeather, tt.mockPrimaryErr)
+			primaryMock.On("GetWeather", mock.Anything, tt.id).Return(tt.mockPrimaryWeather, tt.mockPrimaryErr)
 			if tt.mockPrimaryErr != nil {
-				secondaryMock.On("GetWeather", tt.id).Return(tt.mockSecondaryWeather, tt.mockSecondaryErr)
+				secondaryMock.On("GetWeather", mock.Anything, tt.id).Return(tt.mockSecondaryWeather, tt.mockSecondaryErr)
 			}
 
 			service := services.InitWeatherService(primaryMock, secondaryMock)
 
-			weather, err := service.GetWeather(tt.id)
+			weather, err := service.GetWeather(context.Background(), tt.id)
 
 			if tt.expectErr {
 				assert.Error(t, err)
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"weather-bot/pkg/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClient запоминает время отправки сообщений и отвечает заданными ошибками
+type fakeClient struct {
+	mu     sync.Mutex
+	sent   []time.Time
+	errors []error
+}
+
+func (c *fakeClient) record() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sent = append(c.sent, time.Now())
+	if len(c.errors) > 0 {
+		err := c.errors[0]
+		c.errors = c.errors[1:]
+		return err
+	}
+	return nil
+}
+
+func (c *fakeClient) Message(context.Context, int64, string, any) error { return c.record() }
+func (c *fakeClient) Sticker(context.Context, int64, string) error      { return c.record() }
+func (c *fakeClient) Edit(context.Context, int64, int, string, *tgbotapi.InlineKeyboardMarkup) error {
+	return c.record()
+}
+func (c *fakeClient) AnswerCallback(context.Context, string, string) error { return c.record() }
+
+func testConfig() telegram.DispatcherConfig {
+	return telegram.DispatcherConfig{
+		Rate:         1000,
+		Burst:        10,
+		ChatInterval: 50 * time.Millisecond,
+		Workers:      4,
+		QueueSize:    10,
+		MaxRetries:   2,
+	}
+}
+
+func TestDispatcher_ChatInterval(t *testing.T) {
+	client := &fakeClient{}
+	d := telegram.NewDispatcher(client, testConfig())
+	ctx := context.Background()
+
+	assert.NoError(t, d.Message(ctx, 1, "первое", nil))
+	assert.NoError(t, d.Sticker(ctx, 1, "sticker"))
+
+	assert.Len(t, client.sent, 2)
+	assert.GreaterOrEqual(t, client.sent[1].Sub(client.sent[0]), 50*time.Millisecond)
+	assert.Zero(t, d.QueueDepth())
+}
+
+func TestDispatcher_RetryAfter(t *testing.T) {
+	tooMany := &tgbotapi.Error{Code: 429, Message: "Too Many Requests: retry after 1", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 1}}
+	client := &fakeClient{errors: []error{tooMany}}
+	d := telegram.NewDispatcher(client, testConfig())
+	ctx := context.Background()
+
+	err := d.Message(ctx, 1, "текст", nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, client.sent, 2)
+	assert.GreaterOrEqual(t, client.sent[1].Sub(client.sent[0]), time.Second)
+	assert.Equal(t, int64(1), d.RateLimited())
+}
+
+func TestDispatcher_OtherErrorsNotRetried(t *testing.T) {
+	forbidden := &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+	client := &fakeClient{errors: []error{forbidden}}
+	d := telegram.NewDispatcher(client, testConfig())
+	ctx := context.Background()
+
+	err := d.Message(ctx, 1, "текст", nil)
+
+	assert.ErrorIs(t, err, telegram.ErrBlocked)
+	assert.ErrorIs(t, err, forbidden)
+	assert.Len(t, client.sent, 1)
+}
+
+func TestDispatcher_PacedChatDoesNotBlockOthers(t *testing.T) {
+	client := &fakeClient{}
+	cfg := testConfig()
+	cfg.Workers = 1
+	cfg.ChatInterval = 200 * time.Millisecond
+	d := telegram.NewDispatcher(client, cfg)
+	ctx := context.Background()
+
+	assert.NoError(t, d.Message(ctx, 1, "первое", nil))
+
+	// Второе сообщение в чат 1 ждёт интервал, чат 2 в той же очереди его не ждёт
+	second := make(chan error, 1)
+	go func() { second <- d.Message(ctx, 1, "второе", nil) }()
+	time.Sleep(10 * time.Millisecond)
+
+	start := time.Now()
+	assert.NoError(t, d.Message(ctx, 2, "другой чат", nil))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	assert.NoError(t, <-second)
+	assert.Len(t, client.sent, 3)
+	assert.GreaterOrEqual(t, client.sent[2].Sub(client.sent[0]), 200*time.Millisecond)
+}
+
+func TestDispatcher_CloseDrainsQueue(t *testing.T) {
+	client := &fakeClient{}
+	d := telegram.NewDispatcher(client, testConfig())
+	ctx := context.Background()
+
+	results := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() { results <- d.Message(ctx, 1, "текст", nil) }()
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	assert.NoError(t, d.Close(ctx))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, <-results)
+	}
+	assert.Len(t, client.sent, 3)
+	assert.Zero(t, d.QueueDepth())
+
+	// После остановки сообщения не принимаются
+	assert.ErrorIs(t, d.Message(ctx, 1, "поздно", nil), telegram.ErrDispatcherClosed)
+}
+
+func TestDispatcher_CloseDeadline(t *testing.T) {
+	client := &fakeClient{}
+	cfg := testConfig()
+	cfg.ChatInterval = time.Hour
+	d := telegram.NewDispatcher(client, cfg)
+	ctx := context.Background()
+
+	assert.NoError(t, d.Message(ctx, 1, "первое", nil))
+	second := make(chan error, 1)
+	go func() { second <- d.Message(ctx, 1, "второе", nil) }()
+	time.Sleep(10 * time.Millisecond)
+
+	deadline, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	// Второе сообщение ждёт час, Close не ждёт его и отказывает отправителю
+	assert.ErrorIs(t, d.Close(deadline), context.DeadlineExceeded)
+	assert.ErrorIs(t, <-second, telegram.ErrDispatcherClosed)
+	assert.Len(t, client.sent, 1)
+}
+
+func TestDispatcher_ContextCanceled(t *testing.T) {
+	client := &fakeClient{}
+	cfg := testConfig()
+	cfg.ChatInterval = time.Hour
+	d := telegram.NewDispatcher(client, cfg)
+
+	assert.NoError(t, d.Message(context.Background(), 1, "первое", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, d.Message(ctx, 1, "второе", nil), context.DeadlineExceeded)
+
+	// Отменённое сообщение не отправляется и после интервала
+	assert.Len(t, client.sent, 1)
+}
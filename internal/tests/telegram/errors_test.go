@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"weather-bot/pkg/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *tgbotapi.Error
+		want error
+	}{
+		{"blocked", &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}, telegram.ErrBlocked},
+		{"kicked", &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was kicked from the group chat"}, telegram.ErrBlocked},
+		{"deactivated", &tgbotapi.Error{Code: 403, Message: "Forbidden: user is deactivated"}, telegram.ErrUserDeactivated},
+		{"chat not found", &tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"}, telegram.ErrChatNotFound},
+		{"bad request", &tgbotapi.Error{Code: 400, Message: "Bad Request: can't parse entities"}, telegram.ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := telegram.Classify(tt.err)
+
+			assert.ErrorIs(t, err, tt.want)
+			// Исходная ошибка доступна вызывающему коду
+			var tgErr *tgbotapi.Error
+			assert.True(t, errors.As(err, &tgErr))
+			assert.Equal(t, tt.err.Code, tgErr.Code)
+		})
+	}
+}
+
+func TestClassify_RateLimited(t *testing.T) {
+	err := telegram.Classify(&tgbotapi.Error{Code: 429, Message: "Too Many Requests: retry after 7", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 7}})
+
+	var rateLimited *telegram.ErrRateLimited
+	assert.True(t, errors.As(err, &rateLimited))
+	assert.Equal(t, 7*time.Second, rateLimited.RetryAfter)
+}
+
+func TestClassify_Migrated(t *testing.T) {
+	err := telegram.Classify(&tgbotapi.Error{Code: 400, Message: "Bad Request: group chat was upgraded to a supergroup chat", ResponseParameters: tgbotapi.ResponseParameters{MigrateToChatID: -100123}})
+
+	var migrated *telegram.ErrMigrated
+	assert.True(t, errors.As(err, &migrated))
+	assert.Equal(t, int64(-100123), migrated.NewChatID)
+	assert.False(t, errors.Is(err, telegram.ErrBadRequest))
+}
+
+func TestClassify_Other(t *testing.T) {
+	assert.Nil(t, telegram.Classify(nil))
+
+	netErr := errors.New("connection reset")
+	assert.Equal(t, netErr, telegram.Classify(netErr))
+
+	// Уже разобранная ошибка не оборачивается повторно
+	blocked := telegram.Classify(&tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"})
+	assert.Equal(t, blocked, telegram.Classify(blocked))
+}
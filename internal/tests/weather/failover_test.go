@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"weather-bot/internal/app/weather"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type namedProvider struct {
+	fakeProvider
+	name string
+}
+
+func (p *namedProvider) Name() string {
+	return p.name
+}
+
+func TestFailover_UsesPrimaryWhenHealthy(t *testing.T) {
+	forecast := &weather.Forecast{Items: []weather.ForecastItem{{Time: 1}}}
+	primary := &namedProvider{name: "primary", fakeProvider: fakeProvider{forecast: forecast}}
+	secondary := &namedProvider{name: "secondary", fakeProvider: fakeProvider{forecast: &weather.Forecast{}}}
+
+	f := weather.NewFailover(primary, secondary)
+	got, err := f.Forecast(context.Background(), weather.Location{CityID: 1})
+
+	assert.NoError(t, err)
+	assert.Same(t, forecast, got)
+	assert.Len(t, primary.calls, 1)
+	assert.Empty(t, secondary.calls)
+	assert.Equal(t, "primary,secondary", f.Name())
+}
+
+func TestFailover_FallsBackOnError(t *testing.T) {
+	forecast := &weather.Forecast{Items: []weather.ForecastItem{{Time: 1}}}
+	primary := &namedProvider{name: "primary", fakeProvider: fakeProvider{err: errors.New("503")}}
+	secondary := &namedProvider{name: "secondary", fakeProvider: fakeProvider{forecast: forecast}}
+
+	f := weather.NewFailover(primary, secondary)
+	got, err := f.Forecast(context.Background(), weather.Location{CityID: 1})
+
+	assert.NoError(t, err)
+	assert.Same(t, forecast, got)
+
+	health := f.Health()
+	assert.Less(t, health[0].SuccessRate, 1.0)
+	assert.Equal(t, 1.0, health[1].SuccessRate)
+}
+
+func TestFailover_DemotesUnhealthyPrimary(t *testing.T) {
+	forecast := &weather.Forecast{Items: []weather.ForecastItem{{Time: 1}}}
+	primary := &namedProvider{name: "primary", fakeProvider: fakeProvider{err: errors.New("timeout")}}
+	secondary := &namedProvider{name: "secondary", fakeProvider: fakeProvider{forecast: forecast}}
+
+	f := weather.NewFailover(primary, secondary)
+	for range 10 {
+		_, err := f.Forecast(context.Background(), weather.Location{CityID: 1})
+		assert.NoError(t, err)
+	}
+
+	// После того как оценка упала ниже порога, основной поставщик больше не опрашивается первым
+	assert.Less(t, len(primary.calls), 10)
+	assert.Len(t, secondary.calls, 10)
+}
+
+func TestFailover_AllProvidersFail(t *testing.T) {
+	primary := &namedProvider{name: "primary", fakeProvider: fakeProvider{err: errors.New("503")}}
+	secondary := &namedProvider{name: "secondary", fakeProvider: fakeProvider{err: weather.ErrNoCoordinates}}
+
+	f := weather.NewFailover(primary, secondary)
+	_, err := f.Forecast(context.Background(), weather.Location{CityID: 1})
+
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, weather.ErrNoCoordinates)
+	// Отсутствие координат не считается сбоем поставщика
+	assert.Equal(t, 1.0, f.Health()[1].SuccessRate)
+}
+
+func TestFailover_CancelledContext(t *testing.T) {
+	primary := &namedProvider{name: "primary", fakeProvider: fakeProvider{err: context.Canceled}}
+	secondary := &namedProvider{name: "secondary", fakeProvider: fakeProvider{forecast: &weather.Forecast{}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	f := weather.NewFailover(primary, secondary)
+	_, err := f.Forecast(ctx, weather.Location{CityID: 1})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	// Остановка бота не переключает на запасного поставщика и не портит оценку основного
+	assert.Empty(t, secondary.calls)
+	assert.Equal(t, 1.0, f.Health()[0].SuccessRate)
+}
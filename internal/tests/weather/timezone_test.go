@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"weather-bot/internal/app/loader"
+	"weather-bot/internal/app/weather"
+	"weather-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimezone(t *testing.T) {
+	tests := []struct {
+		city     models.City
+		expected string
+	}{
+		{models.City{Region: "г Москва"}, "Europe/Moscow"},
+		{models.City{Region: "Калининградская обл"}, "Europe/Kaliningrad"},
+		{models.City{Region: "Приморский край"}, "Asia/Vladivostok"},
+		{models.City{Region: "Камчатский край"}, "Asia/Kamchatka"},
+		{models.City{Country: "BG"}, "Europe/Sofia"},
+		{models.City{}, "Europe/Moscow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			tz := loader.Timezone(tt.city)
+			assert.Equal(t, tt.expected, tz)
+
+			_, err := time.LoadLocation(tz)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestToday(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Kamchatka")
+	expected := time.Now().In(loc).Format("2006-01-02")
+
+	assert.Equal(t, expected, weather.Today(&models.ProcessedForecast{Timezone: "Asia/Kamchatka"}))
+	assert.Equal(t, time.Now().UTC().Format("2006-01-02"), weather.Today(&models.ProcessedForecast{}))
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"weather-bot/internal/app/services"
+	"weather-bot/internal/app/weather"
+	"weather-bot/internal/mocks"
+	"weather-bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type fakeProvider struct {
+	forecast *weather.Forecast
+	err      error
+	calls    []weather.Location
+}
+
+func (p *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeProvider) Forecast(ctx context.Context, loc weather.Location) (*weather.Forecast, error) {
+	p.calls = append(p.calls, loc)
+	return p.forecast, p.err
+}
+
+func item(date string, hour int, temp float64, conditionId int) weather.ForecastItem {
+	t, _ := time.Parse("2006-01-02", date)
+	return weather.ForecastItem{
+		Time:        t.Add(time.Duration(hour) * time.Hour).Unix(),
+		Temperature: temp,
+		FeelsLike:   temp - 2,
+		WindSpeed:   3,
+		ConditionId: conditionId,
+	}
+}
+
+func TestGetNewWeather_ProcessesProviderForecast(t *testing.T) {
+	primaryMock := mocks.NewCache(t)
+	secondaryMock := mocks.NewDatabase(t)
+	provider := &fakeProvider{forecast: &weather.Forecast{Items: []weather.ForecastItem{
+		item("2025-05-01", 6, 10, 800),
+		item("2025-05-01", 9, 14, 800),
+		item("2025-05-01", 12, 18, 801),
+		item("2025-05-01", 15, 20, 800),
+		item("2025-05-01", 18, 16, 500),
+		item("2025-05-01", 21, 12, 500),
+		item("2025-05-02", 0, 8, 804),
+		item("2025-05-02", 3, 6, 804),
+	}}}
+	client := weather.NewClient(services.NewServiceContainer(primaryMock, secondaryMock), provider)
+
+	primaryMock.On("GetCity", mock.Anything, 42).Return(&models.City{ID: 42, Timezone: "UTC", Lat: 55.75, Lon: 37.62}, nil)
+	primaryMock.On("SaveWeather", mock.Anything, 42, mock.Anything).Return(nil)
+	secondaryMock.On("SaveWeather", mock.Anything, 42, mock.Anything).Return(nil)
+
+	forecast, err := client.GetNewWeather(context.Background(), 42)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []weather.Location{{CityID: 42, Lat: 55.75, Lon: 37.62}}, provider.calls)
+
+	day := forecast.FullDay["2025-05-01"]
+	assert.InDelta(t, 14, day.Morning.Temperature, 0.001)
+	assert.Equal(t, 800, day.Morning.ConditionId)
+	assert.InDelta(t, 18, day.Day.Temperature, 0.001)
+	assert.InDelta(t, 14, day.Evening.Temperature, 0.001)
+	assert.Equal(t, 500, day.Evening.ConditionId)
+	assert.InDelta(t, 7, day.Night.Temperature, 0.001)
+	assert.Equal(t, "Пасмурно", day.Night.Condition)
+
+	assert.Len(t, forecast.ShortDays, 2)
+	assert.Equal(t, "2025-05-01", forecast.ShortDays[0].Date)
+
+	primaryMock.AssertExpectations(t)
+	secondaryMock.AssertExpectations(t)
+}
+
+func TestGetNewWeather_ProviderError(t *testing.T) {
+	primaryMock := mocks.NewCache(t)
+	client := weather.NewClient(services.NewServiceContainer(primaryMock, mocks.NewDatabase(t)), &fakeProvider{err: errors.New("timeout")})
+	primaryMock.On("GetCity", mock.Anything, 42).Return(&models.City{ID: 42}, nil)
+
+	forecast, err := client.GetNewWeather(context.Background(), 42)
+
+	assert.Error(t, err)
+	assert.Nil(t, forecast)
+}
+
+func TestGetNewWeather_EmptyForecast(t *testing.T) {
+	primaryMock := mocks.NewCache(t)
+	client := weather.NewClient(services.NewServiceContainer(primaryMock, mocks.NewDatabase(t)), &fakeProvider{forecast: &weather.Forecast{}})
+	primaryMock.On("GetCity", mock.Anything, 42).Return(&models.City{ID: 42}, nil)
+
+	_, err := client.GetNewWeather(context.Background(), 42)
+
+	assert.Error(t, err)
+}
+
+func TestGetNewWeather_LocalDayParts(t *testing.T) {
+	primaryMock := mocks.NewCache(t)
+	secondaryMock := mocks.NewDatabase(t)
+	// Владивосток (UTC+10): 21:00 UTC 30 апреля - это 07:00 утра 1 мая
+	client := weather.NewClient(services.NewServiceContainer(primaryMock, secondaryMock), &fakeProvider{forecast: &weather.Forecast{Items: []weather.ForecastItem{
+		item("2025-04-30", 21, 5, 800),
+		item("2025-05-01", 0, 9, 800),
+		item("2025-05-01", 3, 15, 804),
+		item("2025-05-01", 9, 11, 500),
+		item("2025-05-01", 15, 3, 600),
+	}}})
+
+	primaryMock.On("GetCity", mock.Anything, 7).Return(&models.City{ID: 7, Timezone: "Asia/Vladivostok"}, nil)
+	primaryMock.On("SaveWeather", mock.Anything, 7, mock.Anything).Return(nil)
+	secondaryMock.On("SaveWeather", mock.Anything, 7, mock.Anything).Return(nil)
+
+	forecast, err := client.GetNewWeather(context.Background(), 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Vladivostok", forecast.Timezone)
+	assert.NotContains(t, forecast.FullDay, "2025-04-30")
+
+	day := forecast.FullDay["2025-05-01"]
+	assert.InDelta(t, 7, day.Morning.Temperature, 0.001)
+	assert.InDelta(t, 15, day.Day.Temperature, 0.001)
+	assert.InDelta(t, 11, day.Evening.Temperature, 0.001)
+	assert.InDelta(t, 3, day.Night.Temperature, 0.001)
+}
+
+func TestNewProvider(t *testing.T) {
+	p, err := weather.NewProvider(weather.ProviderOpenMeteo, "")
+	assert.NoError(t, err)
+	assert.Equal(t, weather.ProviderOpenMeteo, p.Name())
+
+	p, err = weather.NewProvider("", "key")
+	assert.NoError(t, err)
+	assert.Equal(t, weather.ProviderOpenWeatherMap, p.Name())
+
+	_, err = weather.NewProvider("unknown", "")
+	assert.Error(t, err)
+
+	_, err = weather.NewOpenMeteo().Forecast(context.Background(), weather.Location{CityID: 1})
+	assert.ErrorIs(t, err, weather.ErrNoCoordinates)
+}
@@ -0,0 +1,281 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Client - отправка сообщений в Telegram, её реализуют Telegram и Dispatcher
+type Client interface {
+	Message(ctx context.Context, chatID int64, text string, keyboard any) error
+	Sticker(ctx context.Context, chatID int64, stickerID string) error
+	Edit(ctx context.Context, chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error
+	AnswerCallback(ctx context.Context, callbackID string, text string) error
+}
+
+var _ Client = (*Telegram)(nil)
+var _ Client = (*Dispatcher)(nil)
+
+type DispatcherConfig struct {
+	Rate         float64       // Сообщений в секунду на всего бота
+	Burst        int           // Сколько сообщений можно отправить подряд без ожидания
+	ChatInterval time.Duration // Минимальный интервал между сообщениями в один чат
+	Workers      int           // Очереди обрабатываются параллельно, чат всегда попадает в одну очередь
+	QueueSize    int           // Размер каждой очереди, при заполнении отправка ждёт
+	MaxRetries   int           // Сколько раз повторять отправку после ответа 429
+}
+
+// ErrDispatcherClosed - диспетчер остановлен и больше не отправляет сообщения
+var ErrDispatcherClosed = errors.New("очередь исходящих сообщений остановлена")
+
+type request struct {
+	ctx    context.Context
+	chatID int64
+	send   func(ctx context.Context) error
+	done   chan error
+}
+
+// Dispatcher ставит исходящие сообщения в очередь и отправляет их с учётом лимитов Telegram.
+// Методы блокируются до отправки и возвращают её результат, порядок сообщений в чат сохраняется.
+type Dispatcher struct {
+	client Client
+	cfg    DispatcherConfig
+	bucket *tokenBucket
+	queues []chan request
+
+	closeOnce sync.Once
+	closing   chan struct{} // Закрыт в Close: новые сообщения не принимаются, очереди дорабатываются
+	abort     chan struct{} // Закрыт, если Close не дождался: оставшиеся сообщения не отправляются
+	stopped   chan struct{} // Закрыт, когда все воркеры завершились
+	workers   sync.WaitGroup
+
+	depth       atomic.Int64
+	rateLimited atomic.Int64
+}
+
+func NewDispatcher(client Client, cfg DispatcherConfig) *Dispatcher {
+	d := &Dispatcher{
+		client:  client,
+		cfg:     cfg,
+		bucket:  newTokenBucket(cfg.Rate, cfg.Burst),
+		queues:  make([]chan request, cfg.Workers),
+		closing: make(chan struct{}),
+		abort:   make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan request, cfg.QueueSize)
+		d.workers.Add(1)
+		go d.worker(d.queues[i])
+	}
+	go func() {
+		d.workers.Wait()
+		close(d.stopped)
+	}()
+	return d
+}
+
+func (d *Dispatcher) Message(ctx context.Context, chatID int64, text string, keyboard any) error {
+	return d.enqueue(ctx, chatID, func(ctx context.Context) error {
+		return d.client.Message(ctx, chatID, text, keyboard)
+	})
+}
+
+func (d *Dispatcher) Sticker(ctx context.Context, chatID int64, stickerID string) error {
+	return d.enqueue(ctx, chatID, func(ctx context.Context) error {
+		return d.client.Sticker(ctx, chatID, stickerID)
+	})
+}
+
+func (d *Dispatcher) Edit(ctx context.Context, chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	return d.enqueue(ctx, chatID, func(ctx context.Context) error {
+		return d.client.Edit(ctx, chatID, messageID, text, keyboard)
+	})
+}
+
+// AnswerCallback не отправляет сообщение в чат, поэтому идёт мимо очередей чатов
+func (d *Dispatcher) AnswerCallback(ctx context.Context, callbackID string, text string) error {
+	return d.send(ctx, func(ctx context.Context) error {
+		return d.client.AnswerCallback(ctx, callbackID, text)
+	})
+}
+
+// QueueDepth - сколько сообщений ждут отправки
+func (d *Dispatcher) QueueDepth() int64 {
+	return d.depth.Load()
+}
+
+// RateLimited - сколько раз Telegram ответил 429
+func (d *Dispatcher) RateLimited() int64 {
+	return d.rateLimited.Load()
+}
+
+// Close перестаёт принимать сообщения и отправляет уже принятые. Если ctx отменят раньше,
+// оставшиеся сообщения не отправляются, их отправители получают ErrDispatcherClosed.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.closeOnce.Do(func() { close(d.closing) })
+
+	select {
+	case <-d.stopped:
+		return nil
+	case <-ctx.Done():
+		close(d.abort)
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, chatID int64, send func(ctx context.Context) error) error {
+	select {
+	case <-d.closing:
+		return ErrDispatcherClosed
+	default:
+	}
+
+	req := request{ctx: ctx, chatID: chatID, send: send, done: make(chan error, 1)}
+
+	d.depth.Add(1)
+	select {
+	case d.queues[uint64(chatID)%uint64(len(d.queues))] <- req:
+	case <-ctx.Done():
+		d.depth.Add(-1)
+		return ctx.Err()
+	case <-d.closing:
+		d.depth.Add(-1)
+		return ErrDispatcherClosed
+	}
+
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		// Воркер пропустит запрос с отменённым контекстом
+		return ctx.Err()
+	case <-d.stopped:
+		select {
+		case err := <-req.done:
+			return err
+		default:
+			// Запрос попал в очередь после остановки воркера
+			d.depth.Add(-1)
+			return ErrDispatcherClosed
+		}
+	}
+}
+
+// worker отправляет сообщения своей очереди. Сообщения в чат, куда недавно отправляли,
+// откладываются, поэтому один чат не задерживает остальные.
+func (d *Dispatcher) worker(queue chan request) {
+	defer d.workers.Done()
+	pacer := newChatPacer(d.cfg.ChatInterval)
+
+	for {
+		for _, req := range pacer.ready(time.Now()) {
+			d.process(pacer, req)
+		}
+
+		var wake <-chan time.Time
+		if at, ok := pacer.next(); ok {
+			wake = time.After(time.Until(at))
+		}
+
+		select {
+		case req := <-queue:
+			if pacer.add(req, time.Now()) {
+				d.process(pacer, req)
+			}
+		case <-wake:
+		case <-d.closing:
+			d.drain(queue, pacer)
+			return
+		}
+	}
+}
+
+// drain дорабатывает очередь после Close, соблюдая те же лимиты
+func (d *Dispatcher) drain(queue chan request, pacer *chatPacer) {
+	for {
+		select {
+		case <-d.abort:
+			d.discard(queue, pacer)
+			return
+		default:
+		}
+
+		for queued := true; queued; {
+			select {
+			case req := <-queue:
+				if pacer.add(req, time.Now()) {
+					d.process(pacer, req)
+				}
+			default:
+				queued = false
+			}
+		}
+		for _, req := range pacer.ready(time.Now()) {
+			d.process(pacer, req)
+		}
+
+		at, ok := pacer.next()
+		if !ok {
+			if len(queue) == 0 {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-time.After(time.Until(at)):
+		case <-d.abort:
+			d.discard(queue, pacer)
+			return
+		}
+	}
+}
+
+// discard отвечает ErrDispatcherClosed на все неотправленные сообщения воркера
+func (d *Dispatcher) discard(queue chan request, pacer *chatPacer) {
+	for _, req := range pacer.pending() {
+		d.finish(req, ErrDispatcherClosed)
+	}
+	for len(queue) > 0 {
+		d.finish(<-queue, ErrDispatcherClosed)
+	}
+}
+
+func (d *Dispatcher) process(pacer *chatPacer, req request) {
+	err := req.ctx.Err()
+	if err == nil {
+		err = d.send(req.ctx, req.send)
+		pacer.sent(req.chatID, time.Now())
+	}
+	d.finish(req, err)
+}
+
+func (d *Dispatcher) finish(req request, err error) {
+	d.depth.Add(-1)
+	req.done <- err
+}
+
+// send отправляет запрос с учётом общего лимита и повторяет его после 429
+func (d *Dispatcher) send(ctx context.Context, send func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		if err := d.bucket.wait(ctx); err != nil {
+			return err
+		}
+
+		err := Classify(send(ctx))
+
+		var rateLimited *ErrRateLimited
+		if !errors.As(err, &rateLimited) || attempt >= d.cfg.MaxRetries {
+			return err
+		}
+
+		d.rateLimited.Add(1)
+		d.bucket.pause(rateLimited.RetryAfter)
+	}
+}
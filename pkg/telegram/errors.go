@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Классы ошибок Telegram API. Исходная *tgbotapi.Error остаётся доступна через errors.As.
+var (
+	ErrBlocked         = errors.New("telegram: бот заблокирован пользователем")
+	ErrUserDeactivated = errors.New("telegram: аккаунт пользователя удалён")
+	ErrChatNotFound    = errors.New("telegram: чат не найден")
+	ErrBadRequest      = errors.New("telegram: неверный запрос")
+)
+
+// ErrRateLimited - превышен лимит запросов (429), повторять не раньше чем через RetryAfter
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("telegram: превышен лимит запросов, повтор через %s: %v", e.RetryAfter, e.Err)
+}
+
+func (e *ErrRateLimited) Unwrap() error {
+	return e.Err
+}
+
+// ErrMigrated - группа стала супергруппой, сообщения нужно отправлять в NewChatID
+type ErrMigrated struct {
+	NewChatID int64
+	Err       error
+}
+
+func (e *ErrMigrated) Error() string {
+	return fmt.Sprintf("telegram: чат перенесён в %d: %v", e.NewChatID, e.Err)
+}
+
+func (e *ErrMigrated) Unwrap() error {
+	return e.Err
+}
+
+// Classify превращает ошибку Telegram API в одну из ошибок пакета.
+// Остальные ошибки (сеть, уже разобранные ошибки) возвращаются как есть.
+func Classify(err error) error {
+	tgErr, ok := err.(*tgbotapi.Error)
+	if !ok {
+		return err
+	}
+
+	switch {
+	case tgErr.Code == 429:
+		retryAfter := time.Duration(tgErr.RetryAfter) * time.Second
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		return &ErrRateLimited{RetryAfter: retryAfter, Err: tgErr}
+	case tgErr.MigrateToChatID != 0:
+		return &ErrMigrated{NewChatID: tgErr.MigrateToChatID, Err: tgErr}
+	case tgErr.Code == 403 && strings.Contains(tgErr.Message, "user is deactivated"):
+		return fmt.Errorf("%w: %w", ErrUserDeactivated, tgErr)
+	case tgErr.Code == 403:
+		// Бот заблокирован, исключён из группы или не может начать диалог - писать в чат нельзя
+		return fmt.Errorf("%w: %w", ErrBlocked, tgErr)
+	case tgErr.Code == 400 && strings.Contains(tgErr.Message, "chat not found"):
+		return fmt.Errorf("%w: %w", ErrChatNotFound, tgErr)
+	case tgErr.Code == 400:
+		return fmt.Errorf("%w: %w", ErrBadRequest, tgErr)
+	}
+	return err
+}
@@ -0,0 +1,148 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket - ограничитель частоты запросов: rate токенов в секунду, не больше burst подряд
+type tokenBucket struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time // Telegram попросил подождать (retry_after)
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait блокируется, пока не появится свободный токен или не отменят ctx
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+
+		var delay time.Duration
+		switch {
+		case now.Before(b.pausedUntil):
+			delay = b.pausedUntil.Sub(now)
+		case b.tokens >= 1:
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		default:
+			delay = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		}
+		b.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// pause останавливает выдачу токенов на d
+func (b *tokenBucket) pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+	b.tokens = 0
+}
+
+// chatPacer выдерживает интервал между сообщениями в один чат. Сообщения в чат, куда недавно
+// отправляли, откладываются по порядку, остальные чаты очереди их не ждут.
+type chatPacer struct {
+	interval time.Duration
+	lastSent map[int64]time.Time
+	waiting  map[int64][]request
+}
+
+func newChatPacer(interval time.Duration) *chatPacer {
+	return &chatPacer{
+		interval: interval,
+		lastSent: make(map[int64]time.Time),
+		waiting:  make(map[int64][]request),
+	}
+}
+
+// add возвращает true, если сообщение можно отправить сразу, иначе откладывает его
+func (p *chatPacer) add(req request, now time.Time) bool {
+	if len(p.waiting[req.chatID]) == 0 && !now.Before(p.readyAt(req.chatID)) {
+		return true
+	}
+	p.waiting[req.chatID] = append(p.waiting[req.chatID], req)
+	return false
+}
+
+// ready снимает с ожидания по одному сообщению в каждый чат, для которого интервал уже прошёл
+func (p *chatPacer) ready(now time.Time) []request {
+	var reqs []request
+	for chatID, waiting := range p.waiting {
+		if now.Before(p.readyAt(chatID)) {
+			continue
+		}
+		reqs = append(reqs, waiting[0])
+		if len(waiting) == 1 {
+			delete(p.waiting, chatID)
+		} else {
+			p.waiting[chatID] = waiting[1:]
+		}
+	}
+	return reqs
+}
+
+// next возвращает время, когда освободится ближайший чат с отложенными сообщениями
+func (p *chatPacer) next() (time.Time, bool) {
+	var next time.Time
+	for chatID := range p.waiting {
+		if at := p.readyAt(chatID); next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// sent запоминает время отправки в чат и забывает чаты, куда давно не писали
+func (p *chatPacer) sent(chatID int64, now time.Time) {
+	p.lastSent[chatID] = now
+	if len(p.lastSent) < 1024 {
+		return
+	}
+	for id, at := range p.lastSent {
+		if _, ok := p.waiting[id]; !ok && now.Sub(at) > p.interval {
+			delete(p.lastSent, id)
+		}
+	}
+}
+
+// pending возвращает все отложенные сообщения и очищает ожидание
+func (p *chatPacer) pending() []request {
+	var reqs []request
+	for chatID, waiting := range p.waiting {
+		reqs = append(reqs, waiting...)
+		delete(p.waiting, chatID)
+	}
+	return reqs
+}
+
+func (p *chatPacer) readyAt(chatID int64) time.Time {
+	return p.lastSent[chatID].Add(p.interval)
+}
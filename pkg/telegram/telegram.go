@@ -1,6 +1,8 @@
 package telegram
 
 import (
+	"context"
+
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -12,22 +14,56 @@ func New(bot *tgbotapi.BotAPI) *Telegram {
 	return &Telegram{Bot: bot}
 }
 
-func (t *Telegram) Message(chatID int64, text string, keyboard any) error {
+func (t *Telegram) Message(ctx context.Context, chatID int64, text string, keyboard any) error {
+	// Библиотека не принимает контекст, поэтому проверяем его только перед запросом
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "HTML"
 	msg.ReplyMarkup = keyboard
 	_, err := t.Bot.Send(msg)
 	if err != nil {
-		return err
+		return Classify(err)
 	}
 	return nil
 }
 
-func (t *Telegram) Sticker(chatID int64, stickerID string) error {
+func (t *Telegram) Sticker(ctx context.Context, chatID int64, stickerID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	msg := tgbotapi.NewSticker(chatID, tgbotapi.FileID(stickerID))
 	_, err := t.Bot.Send(msg)
 	if err != nil {
+		return Classify(err)
+	}
+	return nil
+}
+
+// Edit заменяет текст и inline-клавиатуру уже отправленного сообщения
+func (t *Telegram) Edit(ctx context.Context, chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	msg := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = keyboard
+	_, err := t.Bot.Send(msg)
+	if err != nil {
+		return Classify(err)
+	}
+	return nil
+}
+
+// AnswerCallback подтверждает нажатие inline-кнопки, text показывается всплывающей подсказкой
+func (t *Telegram) AnswerCallback(ctx context.Context, callbackID string, text string) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
+	_, err := t.Bot.Request(tgbotapi.NewCallback(callbackID, text))
+	if err != nil {
+		return Classify(err)
+	}
 	return nil
 }